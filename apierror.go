@@ -0,0 +1,122 @@
+package cliex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/maxbolgarin/lang"
+)
+
+// APIError is the error of the unsuccessful response, use errors.As or AsAPIError to get it.
+// errors.Is matches it with the error of its status code from ErrorMapping (e.g. ErrNotFound),
+// so sentinel errors keep working.
+type APIError struct {
+	// StatusCode is the status code of the response.
+	StatusCode int
+	// Body is the body of the response, up to 1 MB if the response was meant to be saved to RequestOpts.OutputPath.
+	Body []byte
+	// Headers is the headers of the response.
+	Headers http.Header
+	// ParsedMessage is the error message from the JSON body (message, error, details and other common fields),
+	// empty if the body has no message.
+	ParsedMessage string
+	// Retryable is true if the request may succeed if it is sent again: 408, 429 and 5xx status codes.
+	Retryable bool
+
+	err error
+}
+
+// Error returns the error of the status code with the message from the body.
+func (e *APIError) Error() string {
+	switch {
+	case e.ParsedMessage != "":
+		return e.err.Error() + ": " + e.ParsedMessage
+	case len(e.Body) > 0:
+		return e.err.Error() + ": " + maxLen(string(e.Body), 100)
+	}
+	return e.err.Error()
+}
+
+// Unwrap returns the error of the status code from ErrorMapping.
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// IsClientError returns true if the status code is 4xx.
+func (e *APIError) IsClientError() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500
+}
+
+// IsServerError returns true if the status code is 5xx.
+func (e *APIError) IsServerError() bool {
+	return e.StatusCode >= 500
+}
+
+// AsAPIError returns the APIError from the error chain.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
+
+// IsClientError returns true if the request failed with 4xx response.
+func IsClientError(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.IsClientError()
+}
+
+// IsRetryable returns true if the request failed with a retryable response (408, 429 and 5xx)
+// or before it was sent (ErrNotSent). Canceled requests and requests with exceeded deadline are not retryable.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.Retryable
+	}
+	return errors.Is(err, ErrNotSent)
+}
+
+// ErrorBody returns the body of the unsuccessful response from the error of the request, nil if there is no body.
+// The body is captured instead of being saved to RequestOpts.OutputPath, up to 1 MB in this case.
+func ErrorBody(err error) []byte {
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.Body
+	}
+	return nil
+}
+
+// statusError returns APIError of the unsuccessful response with the message from the body.
+func statusError(code int, header http.Header, body []byte) error {
+	apiErr := &APIError{
+		StatusCode: code,
+		Body:       body,
+		Headers:    header,
+		Retryable:  isRetryableStatus(code),
+	}
+
+	var ok bool
+	apiErr.err, ok = ErrorMapping[code]
+	switch {
+	case !ok && code < 400:
+		apiErr.err = fmt.Errorf("%w: code %d", ErrUnsuccessfulResponse, code)
+	case !ok:
+		apiErr.err = fmt.Errorf("code %d", code)
+	}
+
+	var errBody ServerErrorResponse
+	if err := json.Unmarshal(body, &errBody); err == nil {
+		apiErr.ParsedMessage = getErrorMessage(errBody)
+		if errBody.Code != 0 {
+			apiErr.err = lang.Check(ErrorMapping[errBody.Code], apiErr.err)
+		}
+	}
+	return apiErr
+}
+
+func isRetryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+}
@@ -0,0 +1,64 @@
+package cliex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.Header().Set("X-Request-Id", "req-1")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"user not found"}`))
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("maintenance"))
+		case "/teapot":
+			w.WriteHeader(499)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.Get(ctx, "/missing")
+	require.ErrorIs(t, err, cliex.ErrNotFound)
+	apiErr, ok := cliex.AsAPIError(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "user not found", apiErr.ParsedMessage)
+	assert.Equal(t, "req-1", apiErr.Headers.Get("X-Request-Id"))
+	assert.JSONEq(t, `{"message":"user not found"}`, string(apiErr.Body))
+	assert.False(t, apiErr.Retryable)
+	assert.True(t, cliex.IsClientError(err))
+	assert.False(t, cliex.IsServerError(err))
+	assert.False(t, cliex.IsRetryable(err))
+	assert.Equal(t, http.StatusNotFound, cliex.GetCodeFromError(err))
+	assert.Contains(t, err.Error(), "user not found")
+
+	_, err = client.Get(ctx, "/unavailable")
+	require.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	assert.True(t, cliex.IsServerError(err))
+	assert.True(t, cliex.IsRetryable(err))
+	assert.Equal(t, "maintenance", string(cliex.ErrorBody(err)))
+
+	// Wrapped errors are classified by status code, not by the message
+	_, err = client.Get(ctx, "/teapot")
+	require.Error(t, err)
+	wrapped := errors.Join(errors.New("sync failed"), err)
+	assert.True(t, cliex.IsClientError(wrapped))
+	assert.Equal(t, 499, cliex.GetCodeFromError(wrapped))
+
+	assert.False(t, cliex.IsRetryable(errors.New("code 503")))
+}
@@ -0,0 +1,121 @@
+package cliex
+
+import (
+	"cmp"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const apiVersionPlaceholder = "{version}"
+
+// DeprecationInfo describes the response of the deprecated endpoint.
+type DeprecationInfo struct {
+	// Method is the method of the request.
+	Method string
+	// URL is the URL of the request.
+	URL string
+	// Route is the route of the request, see RequestOpts.Route. Default is the path of URL.
+	Route string
+	// Deprecated is true if the response has Deprecation header.
+	Deprecated bool
+	// Deprecation is the time when the endpoint was or will be deprecated, it is zero if the time is unknown.
+	Deprecation time.Time
+	// Sunset is the time when the endpoint will become unresponsive, it is zero if there is no Sunset header.
+	Sunset time.Time
+	// Link is the link to the deprecation or sunset policy from Link header.
+	Link string
+}
+
+// apiVersion adds the API version to requests and detects deprecated endpoints by response headers.
+type apiVersion struct {
+	version  string
+	header   string
+	path     string
+	onNotice func(DeprecationInfo)
+}
+
+func newAPIVersion(cfg Config, log Logger) *apiVersion {
+	out := &apiVersion{
+		version:  cfg.APIVersion,
+		header:   cfg.APIVersionHeader,
+		path:     cfg.APIVersionPath,
+		onNotice: cfg.OnDeprecation,
+	}
+	if out.onNotice == nil {
+		var warned sync.Map
+		out.onNotice = func(info DeprecationInfo) {
+			if _, ok := warned.LoadOrStore(info.Method+" "+info.Route, struct{}{}); ok {
+				return
+			}
+			log.Warn("deprecated endpoint", "method", info.Method, "route", info.Route, "deprecation", info.Deprecation,
+				"sunset", info.Sunset, "link", info.Link)
+		}
+	}
+	return out
+}
+
+// headerValue returns the name and the value of the version header, the name is empty if there is nothing to set.
+func (v *apiVersion) headerValue(opts RequestOpts) (string, string) {
+	version := cmp.Or(opts.APIVersion, v.version)
+	if v.header == "" || version == "" || opts.hasHeader(v.header) {
+		return "", ""
+	}
+	return v.header, version
+}
+
+// prefix adds the version path prefix to the relative URL.
+func (v *apiVersion) prefix(url string, opts RequestOpts) string {
+	version := cmp.Or(opts.APIVersion, v.version)
+	if v.path == "" || version == "" || strings.HasPrefix(url, "http") {
+		return url
+	}
+	prefix := strings.TrimSuffix(strings.ReplaceAll(v.path, apiVersionPlaceholder, version), "/")
+	if !strings.HasPrefix(url, "/") {
+		url = "/" + url
+	}
+	return prefix + url
+}
+
+// check calls the callback if the response has deprecation headers.
+func (v *apiVersion) check(method, url, route string, h http.Header) {
+	deprecation, sunset := h.Get("Deprecation"), h.Get("Sunset")
+	if deprecation == "" && sunset == "" {
+		return
+	}
+	info := DeprecationInfo{
+		Method:      method,
+		URL:         url,
+		Route:       route,
+		Deprecated:  deprecation != "",
+		Deprecation: parseDeprecation(deprecation),
+	}
+	if info.Route == "" {
+		if u, err := neturl.Parse(url); err == nil {
+			info.Route = u.Path
+		}
+	}
+	if t, err := http.ParseTime(sunset); err == nil {
+		info.Sunset = t
+	}
+	links := parseLinks(h)
+	info.Link = cmp.Or(links["deprecation"], links["sunset"])
+	v.onNotice(info)
+}
+
+// parseDeprecation parses the Deprecation header: "@<unix seconds>" of RFC 9745 or HTTP date and "true" of its drafts.
+func parseDeprecation(value string) time.Time {
+	if unix, ok := strings.CutPrefix(value, "@"); ok {
+		if sec, err := strconv.ParseInt(unix, 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC()
+		}
+		return time.Time{}
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
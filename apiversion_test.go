@@ -0,0 +1,106 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_APIVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + "|" + r.Header.Get("X-API-Version")))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithAPIVersionHeader("X-API-Version", "2024-01-01"))
+	require.NoError(t, err)
+
+	resp, err := client.Get(ctx, "/users")
+	require.NoError(t, err)
+	assert.Equal(t, "/users|2024-01-01", resp.String())
+
+	resp, err = client.Request(ctx, "/users", cliex.RequestOpts{APIVersion: "2025-01-01"})
+	require.NoError(t, err)
+	assert.Equal(t, "/users|2025-01-01", resp.String())
+
+	resp, err = client.Request(ctx, "/users", cliex.RequestOpts{Headers: map[string]string{"X-API-Version": "1"}})
+	require.NoError(t, err)
+	assert.Equal(t, "/users|1", resp.String())
+
+	client, err = cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithAPIVersionPath("/api/v{version}", "2"))
+	require.NoError(t, err)
+
+	resp, err = client.Get(ctx, "/users")
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v2/users|", resp.String())
+
+	resp, err = client.Request(ctx, "users", cliex.RequestOpts{APIVersion: "3"})
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v3/users|", resp.String())
+
+	resp, err = client.Get(ctx, srv.URL+"/health")
+	require.NoError(t, err)
+	assert.Equal(t, "/health|", resp.String())
+
+	_, err = cliex.New(cliex.WithAPIVersionPath("/api", "2"))
+	require.Error(t, err)
+}
+
+func TestHTTP_OnDeprecation(t *testing.T) {
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			w.Header().Set("Deprecation", "@1700000000")
+			w.Header().Set("Sunset", sunset.Format(http.TimeFormat))
+			w.Header().Add("Link", `<https://example.com/next>; rel="next", <https://example.com/deprecation>; rel="deprecation"`)
+		case "/legacy":
+			w.Header().Set("Deprecation", "true")
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer srv.Close()
+
+	var (
+		mu    sync.Mutex
+		infos []cliex.DeprecationInfo
+	)
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithOnDeprecation(func(info cliex.DeprecationInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		infos = append(infos, info)
+	}))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.Get(ctx, "/new")
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+
+	_, err = client.Request(ctx, "/old", cliex.RequestOpts{Method: http.MethodPost, Route: "/old"})
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, http.MethodPost, infos[0].Method)
+	assert.Equal(t, srv.URL+"/old", infos[0].URL)
+	assert.Equal(t, "/old", infos[0].Route)
+	assert.True(t, infos[0].Deprecated)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), infos[0].Deprecation)
+	assert.Equal(t, sunset, infos[0].Sunset)
+	assert.Equal(t, "https://example.com/deprecation", infos[0].Link)
+
+	_, err = client.Get(ctx, "/legacy")
+	require.ErrorIs(t, err, cliex.ErrGone)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "/legacy", infos[1].Route)
+	assert.True(t, infos[1].Deprecated)
+	assert.True(t, infos[1].Deprecation.IsZero())
+	assert.True(t, infos[1].Sunset.IsZero())
+}
@@ -0,0 +1,96 @@
+package cliex
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// AuditRecord is the record about a single request sent by the client.
+type AuditRecord struct {
+	// Time is the time when the request was sent.
+	Time time.Time `json:"time"`
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+	// URL is the full URL of the request.
+	URL string `json:"url"`
+	// Name is the name of the request from RequestOpts.RequestName.
+	Name string `json:"name,omitempty"`
+	// Labels is the labels of the request from RequestOpts.RequestLabels.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Initiator is the initiator of the request from the context, see WithInitiator.
+	Initiator string `json:"initiator,omitempty"`
+	// StatusCode is the status code of the response, zero if there is no response.
+	StatusCode int `json:"status_code"`
+	// Duration is the duration of the request.
+	Duration time.Duration `json:"duration"`
+	// Error is the error of the request, empty if it is successful.
+	Error string `json:"error,omitempty"`
+	// Meta is the request-scoped values from RequestOpts.Meta.
+	Meta map[string]any `json:"meta,omitempty"`
+}
+
+// AuditSink receives records about every request sent by the client, including retries.
+// It is called synchronously, so it should not block for a long time.
+type AuditSink interface {
+	Audit(record AuditRecord)
+}
+
+// AuditFunc is a function that implements AuditSink.
+type AuditFunc func(record AuditRecord)
+
+// Audit calls the function.
+func (f AuditFunc) Audit(record AuditRecord) {
+	f(record)
+}
+
+type channelAuditSink chan<- AuditRecord
+
+// NewChannelAuditSink returns an AuditSink that sends records to the channel.
+// Records are dropped if the channel is full, so the requests are never blocked by the audit.
+func NewChannelAuditSink(ch chan<- AuditRecord) AuditSink {
+	return channelAuditSink(ch)
+}
+
+func (s channelAuditSink) Audit(record AuditRecord) {
+	select {
+	case s <- record:
+	default:
+	}
+}
+
+type writerAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterAuditSink returns an AuditSink that writes records to the writer (e.g. file) as JSON lines.
+func NewWriterAuditSink(w io.Writer) AuditSink {
+	return &writerAuditSink{w: w}
+}
+
+func (s *writerAuditSink) Audit(record AuditRecord) {
+	data, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(record)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.w.Write(append(data, '\n'))
+}
+
+type initiatorKey struct{}
+
+// WithInitiator returns a context with the initiator of requests (e.g. user ID or job name) for the audit.
+func WithInitiator(ctx context.Context, initiator string) context.Context {
+	return context.WithValue(ctx, initiatorKey{}, initiator)
+}
+
+// InitiatorFromContext returns the initiator of requests from the context, empty string if it is not set.
+func InitiatorFromContext(ctx context.Context) string {
+	initiator, _ := ctx.Value(initiatorKey{}).(string)
+	return initiator
+}
@@ -0,0 +1,139 @@
+package cliex_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Audit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/ok": func(ctx context.Context, req *http.Request) (any, error) {
+			return map[string]string{"key": "value"}, nil
+		},
+		"/fail": func(ctx context.Context, req *http.Request) (any, error) {
+			return nil, cliex.ErrBadGateway
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	records := make(chan cliex.AuditRecord, 10)
+	cfg.AuditSink = cliex.NewChannelAuditSink(records)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	ctx = cliex.WithInitiator(ctx, "job-1")
+
+	_, err = client.Post(ctx, "/ok", map[string]string{"a": "b"})
+	require.NoError(t, err)
+
+	_, err = client.Request(ctx, "/fail", cliex.RequestOpts{
+		Query:            map[string]string{"q": "1"},
+		RetryCount:       2,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	require.Error(t, err)
+
+	require.Len(t, records, 3)
+
+	record := <-records
+	assert.Equal(t, http.MethodPost, record.Method)
+	assert.Equal(t, cfg.BaseURL+"/ok", record.URL)
+	assert.Equal(t, "job-1", record.Initiator)
+	assert.Equal(t, http.StatusOK, record.StatusCode)
+	assert.Positive(t, record.Duration)
+	assert.False(t, record.Time.IsZero())
+	assert.Empty(t, record.Error)
+
+	for range 2 {
+		record = <-records
+		assert.Equal(t, http.MethodGet, record.Method)
+		assert.Equal(t, cfg.BaseURL+"/fail?q=1", record.URL)
+		assert.Equal(t, http.StatusBadGateway, record.StatusCode)
+		assert.Contains(t, record.Error, "bad gateway")
+	}
+}
+
+func TestWriterAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := cliex.NewWriterAuditSink(&buf)
+
+	sink.Audit(cliex.AuditRecord{Method: http.MethodGet, URL: "http://example.com", StatusCode: 200})
+	sink.Audit(cliex.AuditRecord{Method: http.MethodPut, URL: "http://example.com/1", Error: "failed"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var record cliex.AuditRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, http.MethodPut, record.Method)
+	assert.Equal(t, "failed", record.Error)
+
+	var called atomic.Bool
+	cliex.AuditFunc(func(cliex.AuditRecord) { called.Store(true) }).Audit(record)
+	assert.True(t, called.Load())
+}
+
+func TestHTTP_CaptureFilter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/v1/payments/1": func(ctx context.Context, req *http.Request) (any, error) {
+			return nil, nil
+		},
+		"/v1/users/1": func(ctx context.Context, req *http.Request) (any, error) {
+			return nil, nil
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	records := make(chan cliex.AuditRecord, 10)
+	cfg.AuditSink = cliex.NewChannelAuditSink(records)
+	cliex.WithCaptureFilter(nil, []string{"/v1/payments"})(&cfg)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, "/v1/payments/1")
+	require.NoError(t, err)
+	_, err = client.Get(ctx, "/v1/users/1")
+	require.NoError(t, err)
+
+	require.Len(t, records, 1)
+	assert.Equal(t, cfg.BaseURL+"/v1/payments/1", (<-records).URL)
+}
+
+func TestCaptureFilter_Match(t *testing.T) {
+	assert.True(t, cliex.CaptureFilter{}.Match("http://any.host/path"))
+
+	filter := cliex.CaptureFilter{Hosts: []string{"api.example.com", "*.flaky.io", "local:8080"}}
+	assert.True(t, filter.Match("https://api.example.com/v1"))
+	assert.True(t, filter.Match("https://API.example.com:8443/v1"))
+	assert.True(t, filter.Match("https://eu.flaky.io/v1"))
+	assert.True(t, filter.Match("http://local:8080/"))
+	assert.False(t, filter.Match("http://local:9090/"))
+	assert.False(t, filter.Match("https://flaky.io/v1"))
+	assert.False(t, filter.Match("https://other.com/v1"))
+
+	filter.PathPrefixes = []string{"/v2"}
+	assert.False(t, filter.Match("https://api.example.com/v1"))
+	assert.True(t, filter.Match("https://api.example.com/v2/items"))
+}
@@ -0,0 +1,190 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/lang"
+)
+
+// ErrInvalidBinding is returned by HTTP.Bind when the service definition cannot be bound.
+var ErrInvalidBinding = errors.New("invalid binding")
+
+var (
+	contextType  = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	responseType = reflect.TypeOf((*resty.Response)(nil))
+)
+
+// Bind implements the service definition: target must be a pointer to a struct with func fields
+// annotated with `cliex:"METHOD /path/{param}"` tag. Every func field is set to a function that makes the request.
+//
+// The function must accept context.Context as the first argument and may accept one more argument:
+//   - a struct (or a pointer to it) with fields tagged `path:"name"`, `query:"name"`, `header:"name"` and `body:""`,
+//     tag option "omitempty" skips zero query and header values;
+//   - any other value that is sent as the request body.
+//
+// The function must return error as the last value and may return the result first:
+// a pointer or a value that the response is decoded to, or *resty.Response.
+//
+//	type UsersAPI struct {
+//		Get    func(ctx context.Context, req GetUserRequest) (*User, error) `cliex:"GET /users/{id}"`
+//		Create func(ctx context.Context, user User) (*User, error)          `cliex:"POST /users"`
+//		Delete func(ctx context.Context, req GetUserRequest) error          `cliex:"DELETE /users/{id}"`
+//	}
+func (c *HTTP) Bind(target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: target must be a pointer to struct, got %T", ErrInvalidBinding, target)
+	}
+	v = v.Elem()
+
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		tag, ok := field.Tag.Lookup("cliex")
+		if !ok {
+			continue
+		}
+		if !field.IsExported() || field.Type.Kind() != reflect.Func {
+			return fmt.Errorf("%w: field %s must be an exported func", ErrInvalidBinding, field.Name)
+		}
+		method, path, ok := strings.Cut(strings.TrimSpace(tag), " ")
+		if !ok || method == "" || path == "" {
+			return fmt.Errorf("%w: field %s has tag %q, expected \"METHOD /path\"", ErrInvalidBinding, field.Name, tag)
+		}
+		fn, err := c.bindFunc(field.Type, strings.ToUpper(method), strings.TrimSpace(path))
+		if err != nil {
+			return fmt.Errorf("%w: field %s: %w", ErrInvalidBinding, field.Name, err)
+		}
+		v.Field(i).Set(fn)
+	}
+
+	return nil
+}
+
+func (c *HTTP) bindFunc(fnType reflect.Type, method, path string) (reflect.Value, error) {
+	if fnType.IsVariadic() || fnType.NumIn() == 0 || fnType.NumIn() > 2 || fnType.In(0) != contextType {
+		return reflect.Value{}, errors.New("func must accept context.Context and optional request argument")
+	}
+	if fnType.NumOut() == 0 || fnType.NumOut() > 2 || fnType.Out(fnType.NumOut()-1) != errorType {
+		return reflect.Value{}, errors.New("func must return optional result and error")
+	}
+
+	var resultType reflect.Type
+	if fnType.NumOut() == 2 {
+		resultType = fnType.Out(0)
+	}
+
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		ctx, _ := args[0].Interface().(context.Context)
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		opts := RequestOpts{Method: method, Route: path}
+		if len(args) == 2 {
+			applyBindingArg(&opts, args[1])
+		}
+
+		var result reflect.Value
+		if resultType != nil && resultType != responseType {
+			if resultType.Kind() == reflect.Pointer {
+				result = reflect.New(resultType.Elem())
+			} else {
+				result = reflect.New(resultType)
+			}
+			opts.Result = result.Interface()
+		}
+
+		resp, err := c.Request(ctx, path, opts)
+
+		errValue := reflect.Zero(errorType)
+		if err != nil {
+			errValue = reflect.ValueOf(err)
+		}
+		switch {
+		case resultType == nil:
+			return []reflect.Value{errValue}
+		case err != nil:
+			return []reflect.Value{reflect.Zero(resultType), errValue}
+		case resultType == responseType:
+			return []reflect.Value{reflect.ValueOf(resp), errValue}
+		case resultType.Kind() == reflect.Pointer:
+			return []reflect.Value{result, errValue}
+		default:
+			return []reflect.Value{result.Elem(), errValue}
+		}
+	}), nil
+}
+
+// applyBindingArg sets path params, query, headers and body of the request from the argument.
+func applyBindingArg(opts *RequestOpts, arg reflect.Value) {
+	v := arg
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || !hasBindingTags(v.Type()) {
+		opts.Body = arg.Interface()
+		return
+	}
+
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+
+		if _, ok := field.Tag.Lookup("body"); ok {
+			opts.Body = value.Interface()
+			continue
+		}
+		for _, kind := range []string{"path", "query", "header"} {
+			tag, ok := field.Tag.Lookup(kind)
+			if !ok {
+				continue
+			}
+			name, option, _ := strings.Cut(tag, ",")
+			name = lang.Check(name, field.Name)
+			if option == "omitempty" && value.IsZero() {
+				continue
+			}
+			str := fmt.Sprint(value.Interface())
+			switch kind {
+			case "path":
+				opts.PathParams = setBindingValue(opts.PathParams, name, str)
+			case "query":
+				opts.Query = setBindingValue(opts.Query, name, str)
+			case "header":
+				opts.Headers = setBindingValue(opts.Headers, name, str)
+			}
+		}
+	}
+}
+
+func hasBindingTags(t reflect.Type) bool {
+	for i := range t.NumField() {
+		tag := t.Field(i).Tag
+		for _, kind := range []string{"path", "query", "header", "body"} {
+			if _, ok := tag.Lookup(kind); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func setBindingValue(m map[string]string, key, value string) map[string]string {
+	if m == nil {
+		m = make(map[string]string)
+	}
+	m[key] = value
+	return m
+}
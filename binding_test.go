@@ -0,0 +1,95 @@
+package cliex_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindingUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type getUserRequest struct {
+	ID      string `path:"id"`
+	Fields  string `query:"fields,omitempty"`
+	TraceID string `header:"X-Trace-Id"`
+}
+
+type usersAPI struct {
+	Get    func(ctx context.Context, req getUserRequest) (*bindingUser, error)  `cliex:"GET /users/{id}"`
+	List   func(ctx context.Context) ([]bindingUser, error)                     `cliex:"GET /users"`
+	Create func(ctx context.Context, user bindingUser) (*resty.Response, error) `cliex:"POST /users"`
+	Delete func(ctx context.Context, req *getUserRequest) error                 `cliex:"DELETE /users/{id}"`
+}
+
+func TestHTTP_Bind(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users/1":
+			assert.Equal(t, "name", r.URL.Query().Get("fields"))
+			assert.Equal(t, "trace", r.Header.Get("X-Trace-Id"))
+			w.Write([]byte(`{"id":"1","name":"Alice"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/users":
+			w.Write([]byte(`[{"id":"1"},{"id":"2"}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/users":
+			var user bindingUser
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&user))
+			assert.Equal(t, "Bob", user.Name)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete:
+			assert.False(t, r.URL.Query().Has("fields"))
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL})
+	require.NoError(t, err)
+
+	var api usersAPI
+	require.NoError(t, client.Bind(&api))
+
+	ctx := context.Background()
+
+	user, err := api.Get(ctx, getUserRequest{ID: "1", Fields: "name", TraceID: "trace"})
+	require.NoError(t, err)
+	assert.Equal(t, &bindingUser{ID: "1", Name: "Alice"}, user)
+
+	users, err := api.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	resp, err := api.Create(ctx, bindingUser{Name: "Bob"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode())
+
+	err = api.Delete(ctx, &getUserRequest{ID: "2"})
+	assert.ErrorIs(t, err, cliex.ErrNotFound)
+}
+
+func TestHTTP_BindInvalid(t *testing.T) {
+	client, err := cliex.NewWithConfig(cliex.Config{})
+	require.NoError(t, err)
+
+	var noContext struct {
+		Get func(id string) error `cliex:"GET /users/{id}"`
+	}
+	assert.ErrorIs(t, client.Bind(&noContext), cliex.ErrInvalidBinding)
+
+	var noPath struct {
+		Get func(ctx context.Context) error `cliex:"GET"`
+	}
+	assert.ErrorIs(t, client.Bind(&noPath), cliex.ErrInvalidBinding)
+
+	assert.ErrorIs(t, client.Bind(usersAPI{}), cliex.ErrInvalidBinding)
+}
@@ -0,0 +1,75 @@
+package cliex
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrBodyNotReplayable is returned when a streamed request body cannot be sent again during retries.
+// Provide RequestOpts.GetBody or use a reader that implements io.Seeker to retry such requests.
+var ErrBodyNotReplayable = errors.New("request body is not replayable")
+
+// bodySource provides a fresh reader of a streamed request body for every attempt.
+type bodySource struct {
+	reader  io.Reader
+	getBody func() (io.Reader, error)
+	offset  int64
+	used    bool
+}
+
+func newBodySource(opts RequestOpts) (*bodySource, error) {
+	if opts.BodyReader == nil && opts.GetBody == nil {
+		return nil, nil
+	}
+	b := &bodySource{
+		reader:  opts.BodyReader,
+		getBody: opts.GetBody,
+	}
+	if seeker, ok := b.reader.(io.Seeker); ok && b.getBody == nil {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("get body offset: %w", err)
+		}
+		b.offset = offset
+	}
+	return b, nil
+}
+
+// next returns the reader of the body for the next attempt.
+func (b *bodySource) next() (io.Reader, error) {
+	defer func() { b.used = true }()
+
+	if b.getBody != nil {
+		if !b.used && b.reader != nil {
+			return b.reader, nil
+		}
+		r, err := b.getBody()
+		if err != nil {
+			return nil, fmt.Errorf("get body: %w", err)
+		}
+		return r, nil
+	}
+
+	if !b.used {
+		return b.reader, nil
+	}
+
+	seeker, ok := b.reader.(io.Seeker)
+	if !ok {
+		return nil, ErrBodyNotReplayable
+	}
+	if _, err := seeker.Seek(b.offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBodyNotReplayable, err)
+	}
+	return b.reader, nil
+}
+
+// replayable returns true if the body can be sent again.
+func (b *bodySource) replayable() bool {
+	if b == nil || b.getBody != nil {
+		return true
+	}
+	_, ok := b.reader.(io.Seeker)
+	return ok
+}
@@ -0,0 +1,75 @@
+package cliex_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_BodyReaderReplay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const content = "streamed body content"
+
+	rec := cliex.NewRequestRecorderForTest()
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/upload": cliex.CaptureForTest(rec, func(ctx context.Context, req *http.Request) (any, error) {
+			if rec.Len()%2 == 1 {
+				return nil, cliex.ErrServiceUnavailable
+			}
+			return nil, nil
+		}),
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	opts := cliex.RequestOpts{
+		Method:        http.MethodPost,
+		RetryCount:    3,
+		RetryWaitTime: time.Millisecond,
+	}
+
+	// Seekable reader is rewound
+	opts.BodyReader = bytes.NewReader([]byte(content))
+	_, err = client.Request(ctx, "/upload", opts)
+	require.NoError(t, err)
+	for _, req := range rec.Requests() {
+		assert.Equal(t, content, string(req.Body))
+	}
+
+	// GetBody is called for retries
+	rec.Reset()
+	var getBodyCalls int
+	opts.BodyReader = nil
+	opts.GetBody = func() (io.Reader, error) {
+		getBodyCalls++
+		return strings.NewReader(content), nil
+	}
+	_, err = client.Request(ctx, "/upload", opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, getBodyCalls)
+	for _, req := range rec.Requests() {
+		assert.Equal(t, content, string(req.Body))
+	}
+
+	// Not replayable reader is not retried
+	rec.Reset()
+	opts.GetBody = nil
+	opts.BodyReader = io.MultiReader(strings.NewReader(content))
+	_, err = client.Request(ctx, "/upload", opts)
+	assert.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	assert.Equal(t, 1, rec.Len())
+}
@@ -0,0 +1,297 @@
+package cliex
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/lang"
+	"github.com/sony/gobreaker/v2"
+)
+
+type breaker = gobreaker.CircuitBreaker[*resty.Response]
+
+// BreakerKey is the strategy of sharing circuit breakers between requests, see Config.CircuitBreakerKey.
+type BreakerKey string
+
+const (
+	// BreakerKeyRoute uses a breaker per RequestOpts.Route, it is the URL template (or URL if it is empty).
+	BreakerKeyRoute BreakerKey = ""
+	// BreakerKeyHost uses a breaker per host, so all requests to the failing upstream share the failure state.
+	BreakerKeyHost BreakerKey = "host"
+	// BreakerKeyHostRoute uses a breaker per host and RequestOpts.Route, e.g. "api.example.com /users/{id}".
+	// It separates breakers of the same route of different hosts when one client talks to several upstreams.
+	BreakerKeyHostRoute BreakerKey = "host_route"
+)
+
+// breakerKey returns the key of the breaker of the request according to the strategy.
+func breakerKey(strategy BreakerKey, keyFunc func(info RequestInfo) string, info RequestInfo) string {
+	if keyFunc != nil {
+		return keyFunc(info)
+	}
+	switch {
+	case strategy == BreakerKeyHost && info.Host != "":
+		return info.Host
+	case strategy == BreakerKeyHostRoute && info.Host != "":
+		return info.Host + " " + info.Route
+	}
+	return info.Route
+}
+
+// breakerKey returns the key of the breaker of the request to the url.
+func (c *HTTP) breakerKey(url string, opts RequestOpts) string {
+	return breakerKey(c.cbKey, c.cbKeyFunc, RequestInfo{
+		Method: lang.Check(opts.Method, http.MethodGet),
+		Route:  lang.Check(opts.Route, url),
+		Name:   opts.RequestName,
+		Labels: opts.RequestLabels,
+		Host:   c.requestHost(url),
+		Meta:   opts.Meta,
+	})
+}
+
+type breakerEntry struct {
+	cb       *breaker
+	lastUsed time.Time
+}
+
+// breakers is the registry of circuit breakers per route. Breakers of routes that are not used for ttl
+// are evicted, and the least recently used breaker is evicted when the number of breakers exceeds maxSize.
+type breakers struct {
+	mu        sync.Mutex
+	entries   map[string]*breakerEntry
+	ttl       time.Duration
+	maxSize   int
+	lastSweep time.Time
+	onRemove  func(key string)
+}
+
+func newBreakers(ttl time.Duration, maxSize int) *breakers {
+	return &breakers{
+		entries:   make(map[string]*breakerEntry),
+		ttl:       ttl,
+		maxSize:   maxSize,
+		lastSweep: time.Now(),
+	}
+}
+
+// get returns the breaker of the route, it is created with newBreaker if it doesn't exist.
+func (b *breakers) get(route string, newBreaker func() *breaker) *breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.ttl > 0 && now.Sub(b.lastSweep) >= b.ttl {
+		b.sweep(now)
+	}
+
+	entry, ok := b.entries[route]
+	if !ok {
+		entry = &breakerEntry{cb: newBreaker()}
+		b.entries[route] = entry
+		if b.maxSize > 0 && len(b.entries) > b.maxSize {
+			b.evictOldest(route)
+		}
+	}
+	entry.lastUsed = now
+	return entry.cb
+}
+
+// reset deletes breakers of the routes or all breakers if no routes are provided and returns the deleted breakers.
+func (b *breakers) reset(routes ...string) map[string]*breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(routes) == 0 {
+		routes = make([]string, 0, len(b.entries))
+		for route := range b.entries {
+			routes = append(routes, route)
+		}
+	}
+	deleted := make(map[string]*breaker, len(routes))
+	for _, route := range routes {
+		if entry, ok := b.entries[route]; ok {
+			deleted[route] = entry.cb
+			delete(b.entries, route)
+		}
+	}
+	return deleted
+}
+
+// lookup returns the breaker by the key without creating it and updating the last usage time.
+func (b *breakers) lookup(key string) (*breaker, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entry, ok := b.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return entry.cb, true
+}
+
+func (b *breakers) states() map[string]gobreaker.State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make(map[string]gobreaker.State, len(b.entries))
+	for key, entry := range b.entries {
+		out[key] = entry.cb.State()
+	}
+	return out
+}
+
+func (b *breakers) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
+
+func (b *breakers) sweep(now time.Time) {
+	for route, entry := range b.entries {
+		if now.Sub(entry.lastUsed) >= b.ttl {
+			delete(b.entries, route)
+			b.removed(route)
+		}
+	}
+	b.lastSweep = now
+}
+
+func (b *breakers) evictOldest(keep string) {
+	var (
+		oldest     string
+		oldestTime time.Time
+	)
+	for route, entry := range b.entries {
+		if route == keep {
+			continue
+		}
+		if oldest == "" || entry.lastUsed.Before(oldestTime) {
+			oldest, oldestTime = route, entry.lastUsed
+		}
+	}
+	delete(b.entries, oldest)
+	b.removed(oldest)
+}
+
+func (b *breakers) removed(key string) {
+	if b.onRemove != nil {
+		b.onRemove(key)
+	}
+}
+
+// ResetCircuitBreakers deletes circuit breakers by their keys (routes by default, see Config.CircuitBreakerKey)
+// or all breakers if no keys are provided. The next request with the key starts with a new closed breaker.
+func (c *HTTP) ResetCircuitBreakers(keys ...string) {
+	c.resetBreakers(keys...)
+}
+
+// resetBreakers deletes breakers by the keys and reports the transition to the closed state of not closed ones.
+func (c *HTTP) resetBreakers(keys ...string) {
+	for key, cb := range c.cbs.reset(keys...) {
+		if state := cb.State(); state != gobreaker.StateClosed {
+			c.onBreakerStateChange(key, state, gobreaker.StateClosed)
+		}
+		c.onBreakerRemove(key)
+	}
+}
+
+// onBreakerRemove reports the deleted breaker to BreakerStateHook.
+func (c *HTTP) onBreakerRemove(key string) {
+	if hook, ok := c.metrics.(BreakerStateHook); ok {
+		hook.OnBreakerRemove(key)
+	}
+}
+
+// onBreakerStateChange reports the transition of the breaker to metrics and Config.OnCircuitStateChange.
+// It is called by gobreaker under the lock of the breaker, so the callback is called later from the queue.
+func (c *HTTP) onBreakerStateChange(key string, from, to gobreaker.State) {
+	if hook, ok := c.metrics.(BreakerStateHook); ok {
+		hook.OnBreakerStateChange(key, from, to)
+	}
+	if to == gobreaker.StateOpen {
+		c.metrics.OnBreakerTrip(key)
+	}
+	c.cbEvents.push(breakerEvent{key: key, from: from, to: to})
+}
+
+type breakerEvent struct {
+	key      string
+	from, to gobreaker.State
+}
+
+// breakerEvents calls the callback with state transitions of breakers in order of transitions
+// from a separate goroutine, so the callback may use CircuitState and other methods of the client.
+// Panics of the callback are recovered and logged if Config.RecoverPanics is set.
+type breakerEvents struct {
+	f       func(key string, from, to gobreaker.State)
+	log     Logger
+	recover bool
+
+	mu      sync.Mutex
+	queue   []breakerEvent
+	running bool
+}
+
+func (e *breakerEvents) push(event breakerEvent) {
+	if e.f == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.queue = append(e.queue, event)
+	if !e.running {
+		e.running = true
+		go e.run()
+	}
+}
+
+func (e *breakerEvents) run() {
+	for {
+		e.mu.Lock()
+		if len(e.queue) == 0 {
+			e.running = false
+			e.mu.Unlock()
+			return
+		}
+		event := e.queue[0]
+		e.queue = e.queue[1:]
+		e.mu.Unlock()
+
+		e.call(event)
+	}
+}
+
+func (e *breakerEvents) call(event breakerEvent) {
+	if e.recover {
+		var err error
+		defer recoverPanic(e.log, &err)
+	}
+	e.f(event.key, event.from, event.to)
+}
+
+// CircuitBreakersCount returns the number of circuit breakers of the client, one per key (route by default).
+func (c *HTTP) CircuitBreakersCount() int {
+	return c.cbs.len()
+}
+
+// CircuitState returns the state of the circuit breaker used for requests to the url (or route, see RequestOpts.Route),
+// the optional opts are used to get the key of the breaker with Config.CircuitBreakerKeyFunc.
+// It returns gobreaker.StateClosed if there is no breaker yet, because the next request will create a closed one.
+func (c *HTTP) CircuitState(url string, opts ...RequestOpts) gobreaker.State {
+	cb, ok := c.cbs.lookup(c.breakerKey(url, lang.First(opts)))
+	if !ok {
+		return gobreaker.StateClosed
+	}
+	return cb.State()
+}
+
+// ResetCircuit force-closes the circuit breaker used for requests to the url (or route, see RequestOpts.Route)
+// by deleting it, so the next request doesn't wait for Config.CircuitBreakerTimeout.
+// The transition to the closed state is reported to Config.OnCircuitStateChange and BreakerStateHook.
+func (c *HTTP) ResetCircuit(url string, opts ...RequestOpts) {
+	c.resetBreakers(c.breakerKey(url, lang.First(opts)))
+}
+
+// CircuitStates returns the states of all circuit breakers of the client by their keys.
+func (c *HTTP) CircuitStates() map[string]gobreaker.State {
+	return c.cbs.states()
+}
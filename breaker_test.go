@@ -0,0 +1,181 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_CircuitBreakerEviction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:                srv.URL,
+		CircuitBreaker:         true,
+		CircuitBreakerFailures: 1,
+		CircuitBreakerMaxSize:  2,
+		CircuitBreakerTTL:      50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3"} {
+		_, err := client.Get(ctx, path)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, client.CircuitBreakersCount())
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.Get(ctx, "/users/4")
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.CircuitBreakersCount())
+
+	_, err = client.Get(ctx, "/broken")
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+	_, err = client.Get(ctx, "/broken")
+	require.ErrorIs(t, err, cliex.ErrCBOpenState)
+
+	client.ResetCircuitBreakers("/broken")
+	_, err = client.Get(ctx, "/broken")
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+	assert.Equal(t, 2, client.CircuitBreakersCount())
+
+	client.ResetCircuitBreakers()
+	assert.Zero(t, client.CircuitBreakersCount())
+}
+
+func TestHTTP_CircuitBreakerKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithCircuitBreakerKey(cliex.BreakerKeyHost), func(cfg *cliex.Config) {
+		cfg.CircuitBreaker = true
+		cfg.CircuitBreakerFailures = 1
+	})
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, "/users/1")
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+	_, err = client.Get(ctx, "/users/2")
+	require.ErrorIs(t, err, cliex.ErrCBOpenState)
+	assert.Equal(t, 1, client.CircuitBreakersCount())
+
+	client.ResetCircuitBreakers(strings.TrimPrefix(srv.URL, "http://"))
+	assert.Zero(t, client.CircuitBreakersCount())
+
+	client, err = cliex.New(cliex.WithBaseURL(srv.URL), func(cfg *cliex.Config) {
+		cfg.CircuitBreaker = true
+		cfg.CircuitBreakerFailures = 1
+		cfg.CircuitBreakerKeyFunc = func(info cliex.RequestInfo) string {
+			return info.Method
+		}
+	})
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, "/users/1")
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+	_, err = client.Request(ctx, "/users/1", cliex.RequestOpts{Method: http.MethodPost})
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+	_, err = client.Get(ctx, "/users/2")
+	require.ErrorIs(t, err, cliex.ErrCBOpenState)
+	assert.Equal(t, 2, client.CircuitBreakersCount())
+
+	_, err = cliex.New(cliex.WithCircuitBreakerKey("path"))
+	require.Error(t, err)
+}
+
+func TestHTTP_CircuitState(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var (
+		mu          sync.Mutex
+		transitions []string
+		client      *cliex.HTTP
+	)
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:                srv.URL,
+		CircuitBreaker:         true,
+		CircuitBreakerFailures: 1,
+		OnCircuitStateChange: func(key string, from, to gobreaker.State) {
+			// The callback may use the client, it is not called under the lock of the breaker
+			current := client.CircuitState(key)
+			client.CircuitStates()
+			mu.Lock()
+			defer mu.Unlock()
+			transitions = append(transitions, key+" "+from.String()+"->"+to.String()+" "+current.String())
+		},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	getTransitions := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(transitions)
+	}
+
+	assert.Equal(t, gobreaker.StateClosed, client.CircuitState("/broken"))
+
+	_, err = client.Get(ctx, "/broken")
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+	assert.Equal(t, gobreaker.StateOpen, client.CircuitState("/broken"))
+	assert.Equal(t, map[string]gobreaker.State{"/broken": gobreaker.StateOpen}, client.CircuitStates())
+	require.Eventually(t, func() bool { return len(getTransitions()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"/broken closed->open open"}, getTransitions())
+
+	client.ResetCircuit("/broken")
+	assert.Equal(t, gobreaker.StateClosed, client.CircuitState("/broken"))
+	require.Eventually(t, func() bool { return len(getTransitions()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, "/broken open->closed closed", getTransitions()[1])
+
+	_, err = client.Get(ctx, "/broken")
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+}
+
+func TestHTTP_CircuitStateChangePanic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var calls atomic.Int64
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:                srv.URL,
+		CircuitBreaker:         true,
+		CircuitBreakerFailures: 1,
+		RecoverPanics:          true,
+		OnCircuitStateChange: func(key string, from, to gobreaker.State) {
+			if calls.Add(1) == 1 {
+				panic("callback failed")
+			}
+		},
+	})
+	require.NoError(t, err)
+
+	// The panic of the callback is recovered and next transitions are still delivered
+	_, err = client.Get(context.Background(), "/broken")
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+	client.ResetCircuit("/broken")
+	require.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, time.Millisecond)
+}
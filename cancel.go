@@ -0,0 +1,89 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrClientClosed is the cause of the cancellation of requests interrupted by HTTP.Shutdown.
+var ErrClientClosed = errors.New("client is closed")
+
+// Shutdown cancels in-flight requests of the client with ErrClientClosed cause and closes idle connections.
+// Requests made after Shutdown fail immediately, use Close to close only idle connections.
+func (c *HTTP) Shutdown() {
+	c.shutdown(ErrClientClosed)
+	c.Close()
+}
+
+// IsCanceled returns true if the request failed because the context of the caller was canceled.
+// It is false for requests interrupted by HTTP.Shutdown, see IsClientClosed.
+func IsCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) && !errors.Is(err, ErrClientClosed)
+}
+
+// IsDeadlineExceeded returns true if the request failed because the deadline of the context
+// or Config.RequestTimeout was exceeded.
+func IsDeadlineExceeded(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// IsClientClosed returns true if the request was interrupted by HTTP.Shutdown.
+func IsClientClosed(err error) bool {
+	return errors.Is(err, ErrClientClosed)
+}
+
+// bindLifetime returns the context of the request that is canceled by HTTP.Shutdown
+// and the function that releases it.
+func (c *HTTP) bindLifetime(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(c.lifetime, func() {
+		cancel(ErrClientClosed)
+	})
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// withCancelCause adds the cause of the context cancellation to the error of the canceled request,
+// e.g. the error passed to the cancel function of context.WithCancelCause, so the error matches both
+// the cause and context.Canceled or context.DeadlineExceeded.
+func withCancelCause(ctx context.Context, err error) error {
+	ctxErr := ctx.Err()
+	if ctxErr == nil {
+		return err
+	}
+	cause := context.Cause(ctx)
+	hasErr, hasCause := errors.Is(err, ctxErr), errors.Is(err, cause)
+	switch {
+	case hasErr && !hasCause:
+		return fmt.Errorf("%w: %w", err, cause)
+	case !hasErr && hasCause:
+		return fmt.Errorf("%w: %w", err, ctxErr)
+	}
+	return err
+}
+
+// releaseOnClose defers the release of the request context of the streamed response until its body is closed.
+func releaseOnClose(resp *resty.Response, release func()) bool {
+	if resp == nil || resp.RawResponse == nil || resp.RawResponse.Body == nil {
+		return false
+	}
+	resp.RawResponse.Body = &releasingBody{ReadCloser: resp.RawResponse.Body, release: release}
+	return true
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,64 @@
+package cliex_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_CancelCause(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	errUserLeft := errors.New("user left the page")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(20*time.Millisecond, func() { cancel(errUserLeft) })
+	_, err = client.Get(ctx, "/")
+	require.ErrorIs(t, err, errUserLeft)
+	assert.True(t, cliex.IsCanceled(err))
+	assert.False(t, cliex.IsDeadlineExceeded(err))
+	assert.False(t, cliex.IsClientClosed(err))
+	assert.False(t, cliex.IsRetryable(err))
+
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelTimeout()
+	_, err = client.Get(ctx, "/")
+	require.Error(t, err)
+	assert.True(t, cliex.IsDeadlineExceeded(err))
+	assert.False(t, cliex.IsCanceled(err))
+	assert.False(t, cliex.IsRetryable(err))
+
+	// The streamed body is not canceled after Request returns
+	body, err := client.Stream(context.Background(), "/", cliex.RequestOpts{})
+	require.NoError(t, err)
+
+	time.AfterFunc(20*time.Millisecond, client.Shutdown)
+	_, err = client.Get(context.Background(), "/")
+	require.ErrorIs(t, err, cliex.ErrClientClosed)
+	assert.True(t, cliex.IsClientClosed(err))
+	assert.False(t, cliex.IsCanceled(err))
+
+	_, err = io.ReadAll(body)
+	require.ErrorIs(t, err, cliex.ErrClientClosed)
+	require.NoError(t, body.Close())
+
+	_, err = client.Get(context.Background(), "/")
+	require.ErrorIs(t, err, cliex.ErrClientClosed)
+}
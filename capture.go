@@ -0,0 +1,63 @@
+package cliex
+
+import (
+	"net/url"
+	"strings"
+)
+
+// CaptureFilter selects requests for debug output and audit records, so debugging of one integration
+// doesn't record the traffic of the entire service. Empty filter matches all requests.
+type CaptureFilter struct {
+	// Hosts is the list of hosts to capture, e.g. "api.example.com", "api.example.com:8080" or "*.example.com".
+	// Host without port matches any port. Default is empty, means any host.
+	Hosts []string `yaml:"hosts" json:"hosts" env:"CLIEX_CAPTURE_HOSTS"`
+
+	// PathPrefixes is the list of path prefixes to capture, e.g. "/v1/payments". Default is empty, means any path.
+	PathPrefixes []string `yaml:"path_prefixes" json:"path_prefixes" env:"CLIEX_CAPTURE_PATH_PREFIXES"`
+}
+
+// IsEmpty returns true if the filter matches all requests.
+func (f CaptureFilter) IsEmpty() bool {
+	return len(f.Hosts) == 0 && len(f.PathPrefixes) == 0
+}
+
+// Match returns true if the URL matches both hosts and path prefixes of the filter.
+func (f CaptureFilter) Match(rawURL string) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return f.matchHost(u) && f.matchPath(u.Path)
+}
+
+func (f CaptureFilter) matchHost(u *url.URL) bool {
+	if len(f.Hosts) == 0 {
+		return true
+	}
+	host, hostname := strings.ToLower(u.Host), strings.ToLower(u.Hostname())
+	for _, pattern := range f.Hosts {
+		pattern = strings.ToLower(pattern)
+		switch {
+		case pattern == host, pattern == hostname:
+			return true
+		case strings.HasPrefix(pattern, "*.") && strings.HasSuffix(hostname, pattern[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+func (f CaptureFilter) matchPath(path string) bool {
+	if len(f.PathPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range f.PathPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package cliex
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrUnknownClientCert is returned when RequestOpts.ClientCertName is not configured in Config.ClientCerts.
+var ErrUnknownClientCert = errors.New("unknown client certificate")
+
+// ClientCert is the client certificate and key files of a workload identity used for mTLS.
+type ClientCert struct {
+	CertFile string `yaml:"cert_file" json:"cert_file"`
+	KeyFile  string `yaml:"key_file" json:"key_file"`
+}
+
+type clientCertKey struct{}
+
+// identityTransport sends requests with RequestOpts.ClientCertName through the transport with the selected
+// certificate. Every identity has its own connection pool, so a connection authenticated with one certificate
+// is never reused by a request of another identity.
+type identityTransport struct {
+	base       *http.Transport
+	identities map[string]*http.Transport
+}
+
+func newIdentityTransport(cli *resty.Client, certs map[string]ClientCert) (*identityTransport, error) {
+	if len(certs) == 0 {
+		return nil, nil
+	}
+	base, err := cli.Transport()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &identityTransport{
+		base:       base,
+		identities: make(map[string]*http.Transport, len(certs)),
+	}
+	for name, cert := range certs {
+		pair, err := tls.LoadX509KeyPair(cert.CertFile, cert.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client cert %s: %w", name, err)
+		}
+		transport := base.Clone()
+		if base.TLSClientConfig != nil {
+			transport.TLSClientConfig = base.TLSClientConfig.Clone()
+		} else {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{pair}
+		t.identities[name] = transport
+	}
+	return t, nil
+}
+
+func (t *identityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name, _ := req.Context().Value(clientCertKey{}).(string)
+	if name == "" {
+		return t.base.RoundTrip(req)
+	}
+	transport, ok := t.identities[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownClientCert, name)
+	}
+	return transport.RoundTrip(req)
+}
+
+func (t *identityTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+	for _, transport := range t.identities {
+		transport.CloseIdleConnections()
+	}
+}
+
+func (t *identityTransport) withIdentity(ctx context.Context, name string) (context.Context, error) {
+	if name == "" {
+		return ctx, nil
+	}
+	if t == nil {
+		return ctx, fmt.Errorf("%w: %s", ErrUnknownClientCert, name)
+	}
+	if _, ok := t.identities[name]; !ok {
+		return ctx, fmt.Errorf("%w: %s", ErrUnknownClientCert, name)
+	}
+	return context.WithValue(ctx, clientCertKey{}, name), nil
+}
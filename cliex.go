@@ -3,14 +3,17 @@ package cliex
 import (
 	"context"
 	"crypto/tls"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"math/rand/v2"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	neturl "net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -22,12 +25,52 @@ import (
 
 // HTTP is the resty wrapper for easy use.
 type HTTP struct {
-	cli *resty.Client
-	cbs *abstract.SafeMap[string, *gobreaker.CircuitBreaker[*resty.Response]]
-	log Logger
-
-	cbCfg    gobreaker.Settings
-	enableCB bool
+	cli       *resty.Client
+	cbs       *breakers
+	templates *abstract.SafeMap[string, RequestOpts]
+	log       Logger
+
+	limiter      *tokenBucket
+	hostLimiters *hostLimiters
+	throttle     *hostThrottle
+	headers      *headerPolicy
+	userAgents   UserAgentProvider
+	audit        AuditSink
+	memo         *memoCache
+	cache        *httpCache
+	flights      *flightGroup
+	dedup        *dedupWindow
+	scheduler    *scheduler
+	metrics      MetricsHook
+	capture      CaptureFilter
+	debug        bool
+	recoverPanic bool
+	isSuccess    func(*resty.Response) bool
+	ctxHeaders   []ContextHeader
+	rewrite      []RewriteRule
+	retry        RetryPolicy
+	decoders     map[string]Decoder
+	results      resultDecoder
+	encoders     bodyEncoders
+	encoding     string
+	maxReqSize   int64
+	slowRequest  time.Duration
+	resolver     *baseURLResolver
+	profile      atomic.Pointer[profile]
+	openapi      *OpenAPIValidator
+	slo          *sloTracker
+	identities   *identityTransport
+	chain        *middlewareChain
+	apiVersion   *apiVersion
+	lifetime     context.Context
+	shutdown     context.CancelCauseFunc
+	backoff      backoffHeader
+
+	cbCfg     gobreaker.Settings
+	cbKey     BreakerKey
+	cbKeyFunc func(info RequestInfo) string
+	cbEvents  *breakerEvents
+	enableCB  bool
 }
 
 // New returns a new HTTP client weith applied With* options to Config.
@@ -55,27 +98,64 @@ func NewWithConfig(cfg Config) (*HTTP, error) {
 		return nil, err
 	}
 
+	resolver, err := newBaseURLResolver(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+
 	cli := resty.New().
-		SetBaseURL(cfg.BaseURL).
+		SetBaseURL(lang.If(resolver == nil, cfg.BaseURL, "")).
 		SetLogger(cfg.RestyLogger).
 		SetHeader("User-Agent", cfg.UserAgent).
 		SetTimeout(cfg.RequestTimeout).
 		SetJSONMarshaler(jsoniter.ConfigCompatibleWithStandardLibrary.Marshal).
-		SetJSONUnmarshaler(jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal).
+		SetJSONUnmarshaler(cfg.JSONDecoding.api().Unmarshal).
 		SetTLSClientConfig(&tls.Config{InsecureSkipVerify: cfg.Insecure}).
-		SetRedirectPolicy(resty.FlexibleRedirectPolicy(20)).
+		SetRedirectPolicy(recordRedirects, resty.FlexibleRedirectPolicy(20)).
 		SetAllowGetMethodPayload(true).
-		SetDebug(cfg.Debug).
-		OnAfterResponse(errorHandler)
+		SetDebug(cfg.Debug && cfg.CaptureFilter.IsEmpty()).
+		OnAfterResponse(newErrorHandler(cfg.IsSuccess))
+
+	redactor, err := newJSONRedactor(cfg.RedactJSONPaths)
+	if err != nil {
+		return nil, err
+	}
+	redactor.install(cli)
 
 	if cfg.AuthToken != "" {
 		cli.SetHeader("Authorization", cfg.AuthToken)
 	}
 
+	if cfg.BasicAuthUser != "" {
+		cli.SetBasicAuth(cfg.BasicAuthUser, cfg.BasicAuthPass)
+	}
+
+	if cfg.DisableCompression {
+		transport, err := cli.Transport()
+		if err != nil {
+			return nil, err
+		}
+		transport.DisableCompression = true
+	}
+
+	if cfg.ExpectContinueTimeout > 0 {
+		transport, err := cli.Transport()
+		if err != nil {
+			return nil, err
+		}
+		transport.ExpectContinueTimeout = cfg.ExpectContinueTimeout
+	}
+
 	if cfg.ProxyAddress != "" {
 		cli.SetProxy(cfg.ProxyAddress)
 	}
 
+	if cfg.LocalAddr != "" {
+		if err := setLocalAddr(cli, cfg.LocalAddr); err != nil {
+			return nil, err
+		}
+	}
+
 	if len(cfg.CAFiles) > 0 {
 		for _, caFile := range cfg.CAFiles {
 			cli.SetRootCertificate(caFile)
@@ -90,10 +170,44 @@ func NewWithConfig(cfg Config) (*HTTP, error) {
 		cli.SetCertificates(cert1)
 	}
 
+	identities, err := newIdentityTransport(cli, cfg.ClientCerts)
+	if err != nil {
+		return nil, err
+	}
+	if identities != nil {
+		cli.SetTransport(identities)
+	}
+
+	// Header names are converted by the innermost transport, after all headers are set
+	if cfg.HeaderCase != HeaderCaseDefault {
+		cli.SetTransport(newHeaderCaseTransport(cli.GetClient().Transport, cfg.HeaderCase))
+	}
+
+	if cfg.OAuth2TokenURL != "" {
+		cli.SetTransport(newOAuth2Transport(cli.GetClient().Transport, cfg))
+	}
+
+	if cfg.OpenAPIValidator == nil && cfg.OpenAPISpecFile != "" {
+		cfg.OpenAPIValidator, err = LoadOpenAPISpecFile(cfg.OpenAPISpecFile)
+		if err != nil {
+			return nil, fmt.Errorf("load openapi spec: %w", err)
+		}
+	}
+
+	if cfg.MetricsRegisterer != nil {
+		metrics, err := registerPrometheusMetrics(cfg.MetricsRegisterer)
+		if err != nil {
+			return nil, fmt.Errorf("register metrics: %w", err)
+		}
+		cfg.MetricsHook = lang.If[MetricsHook](cfg.MetricsHook != nil, metricsHooks{cfg.MetricsHook, metrics}, metrics)
+	}
+
+	results := newResultDecoder(cfg)
 	out := &HTTP{
-		cli: cli,
-		cbs: abstract.NewSafeMap[string, *gobreaker.CircuitBreaker[*resty.Response]](),
-		log: cfg.Logger,
+		cli:       cli,
+		cbs:       newBreakers(cfg.CircuitBreakerTTL, cfg.CircuitBreakerMaxSize),
+		templates: abstract.NewSafeMap[string, RequestOpts](),
+		log:       cfg.Logger,
 		cbCfg: gobreaker.Settings{
 			Name:    "HTTP Circuit Breaker",
 			Timeout: cfg.CircuitBreakerTimeout,
@@ -101,8 +215,78 @@ func NewWithConfig(cfg Config) (*HTTP, error) {
 				return counts.ConsecutiveFailures >= cfg.CircuitBreakerFailures
 			},
 		},
-		enableCB: cfg.CircuitBreaker,
+		enableCB:     cfg.CircuitBreaker,
+		cbKey:        cfg.CircuitBreakerKey,
+		cbKeyFunc:    cfg.CircuitBreakerKeyFunc,
+		cbEvents:     &breakerEvents{f: cfg.OnCircuitStateChange, log: cfg.Logger, recover: cfg.RecoverPanics},
+		limiter:      newTokenBucket(RateLimit{RPS: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst}),
+		hostLimiters: newHostLimiters(cfg.HostRateLimits, cfg.HostRateLimitFunc),
+		headers:      newHeaderPolicy(cfg),
+		userAgents:   cfg.UserAgentProvider,
+		audit:        cfg.AuditSink,
+		memo:         newMemoCache(),
+		cache:        newHTTPCache(cfg),
+		flights:      newFlightGroup(cfg.Singleflight),
+		dedup:        newDedupWindow(cfg.DedupWindow),
+		scheduler:    newScheduler(cfg.MaxConcurrentRequests),
+		metrics:      lang.If[MetricsHook](cfg.MetricsHook != nil, cfg.MetricsHook, NoopMetricsHook{}),
+		capture:      cfg.CaptureFilter,
+		debug:        cfg.Debug,
+		recoverPanic: cfg.RecoverPanics,
+		isSuccess:    cfg.IsSuccess,
+		ctxHeaders:   cfg.ContextHeaders,
+		rewrite:      cfg.RewriteRules,
+		retry:        cfg.RetryPolicy,
+		maxReqSize:   cfg.MaxRequestSize,
+		slowRequest:  cfg.SlowRequestThreshold,
+		decoders:     results.decoders,
+		results:      results,
+		encoders:     newBodyEncoders(cfg.Encoders),
+		encoding:     cfg.RequestEncoding,
+		resolver:     resolver,
+		openapi:      cfg.OpenAPIValidator,
+		identities:   identities,
+		chain:        &middlewareChain{},
+		apiVersion:   newAPIVersion(cfg, cfg.Logger),
+		backoff:      backoffHeader{name: cfg.BackoffHeader, unit: cfg.BackoffHeaderUnit},
+	}
+	out.cbs.onRemove = out.onBreakerRemove
+	out.throttle = newHostThrottle(cfg.QueueOn429, cfg.QueueOn429Wait, out.backoff)
+	out.lifetime, out.shutdown = context.WithCancelCause(context.Background())
+	out.Use(cfg.Middlewares...)
+
+	onSLOViolation := cfg.OnSLOViolation
+	if onSLOViolation == nil {
+		onSLOViolation = func(v SLOViolation) {
+			out.log.Warn("slo violation", "route", v.Route, "requests", v.Requests,
+				"slow_rate", v.SlowRate, "error_rate", v.ErrorRate, "window", v.End.Sub(v.Start))
+		}
 	}
+	out.slo = newSLOTracker(cfg.SLOs, onSLOViolation)
+
+	cli.SetPreRequestHook(func(_ *resty.Client, req *http.Request) error {
+		applyStreamBody(req)
+		applyContextHeaders(cfg.ContextHeaders, req)
+		out.headers.apply(req)
+		if out.openapi != nil {
+			if err := out.openapi.ValidateRequest(req); err != nil {
+				return err
+			}
+		}
+		applyRewriteRules(cfg.RewriteRules, req)
+		if err := applyContentEncoding(req); err != nil {
+			return err
+		}
+		if err := applyTransferEncoding(req); err != nil {
+			return err
+		}
+		applyUploadProgress(req)
+		if err := accountRequestSize(req); err != nil {
+			return err
+		}
+		applyTrailers(req)
+		return nil
+	})
 
 	return out, nil
 }
@@ -112,21 +296,97 @@ func (c *HTTP) C() *resty.Client {
 	return c.cli
 }
 
+// Close closes idle connections of the client. In-flight requests are not interrupted.
+func (c *HTTP) Close() {
+	c.cli.GetClient().CloseIdleConnections()
+}
+
 // R returns the resty request with applied context.
 func (c *HTTP) R(ctx context.Context) *resty.Request {
 	return c.cli.R().SetContext(ctx)
 }
 
 // Request makes HTTP request with the given options to the BaseURL + URL and returns response.
-// It also applies circuit breaker if enabled.
+// It also applies circuit breaker if enabled, breakers are separated by Config.CircuitBreakerKey (opts.Route or URL by default).
 func (c *HTTP) Request(ctx context.Context, url string, opts RequestOpts) (*resty.Response, error) {
+	reqCtx, release := c.bindLifetime(ctx)
+	resp, err := c.doRequest(reqCtx, url, opts)
+	if err != nil {
+		release()
+		return resp, withCancelCause(reqCtx, err)
+	}
+	if !opts.StreamResponse || !releaseOnClose(resp, release) {
+		release()
+	}
+	return resp, nil
+}
+
+func (c *HTTP) doRequest(ctx context.Context, url string, opts RequestOpts) (*resty.Response, error) {
+	opts.Route = lang.Check(opts.Route, url)
+	if opts.Meta != nil {
+		ctx = context.WithValue(ctx, metaKey{}, opts.Meta)
+	}
+	ctx, err := c.identities.withIdentity(ctx, opts.ClientCertName)
+	if err != nil {
+		return nil, err
+	}
+
+	dedupKey, err := c.dedup.acquire(ctx, url, opts, c.ctxHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	key, memoize := memoKey(ctx, url, opts, c.ctxHeaders)
+	if memoize {
+		if resp, ok := c.memo.get(key); ok {
+			return c.results.useMemoized(copyResponse(resp, opts.Result), opts)
+		}
+	}
+
+	fullURL := lang.If(strings.HasPrefix(url, "http"), url, c.cli.BaseURL+url)
+	cacheKey, cacheable := c.cache.key(ctx, fullURL, opts, c.ctxHeaders)
+	var cached *CachedResponse
+	if cacheable {
+		cached = c.cache.lookup(ctx, cacheKey, opts, c.cli.Header)
+		if cached != nil && cached.isFresh(opts, time.Now()) {
+			req := c.R(ctx)
+			req.Method, req.URL = http.MethodGet, fullURL
+			return c.cache.cachedResponse(req, cached, opts)
+		}
+		opts = cached.conditional(opts)
+	}
+
+	ctx, stats := withRequestStats(ctx)
+	start := time.Now()
+	resp, err := c.requestShared(ctx, url, opts)
+	stats.setDuration(time.Since(start))
+	c.slo.record(opts.Route, time.Since(start), err)
+	if err != nil {
+		c.dedup.release(dedupKey)
+	}
+	if cacheable {
+		resp, err = c.cache.update(ctx, cacheKey, cached, opts, c.cli.Header, resp, err)
+	}
+	// 404 responses of NilOn404 requests are returned without an error, but they are not memoized
+	if err == nil && memoize && c.isSuccess(resp) {
+		c.memo.set(key, resp, opts.CacheTTL)
+	}
+	return resp, err
+}
+
+func (c *HTTP) requestWithBreaker(ctx context.Context, url string, opts RequestOpts) (*resty.Response, error) {
 	if !c.enableCB {
 		return c.request(ctx, url, opts)
 	}
-	cb, ok := c.cbs.Lookup(url)
-	if !ok {
-		cb = gobreaker.NewCircuitBreaker[*resty.Response](c.cbCfg)
-		c.cbs.Set(url, cb)
+	key := c.breakerKey(url, opts)
+	cb := c.cbs.get(key, func() *breaker {
+		cbCfg := c.cbCfg
+		cbCfg.OnStateChange = c.onBreakerStateChange
+		cbCfg.Name = key
+		return gobreaker.NewCircuitBreaker[*resty.Response](cbCfg)
+	})
+	if cb.State() == gobreaker.StateHalfOpen {
+		getRequestStats(ctx).setHalfOpen()
 	}
 	resp, err := cb.Execute(func() (*resty.Response, error) {
 		return c.request(ctx, url, opts)
@@ -142,33 +402,255 @@ func (c *HTTP) Request(ctx context.Context, url string, opts RequestOpts) (*rest
 	return resp, nil
 }
 
-func (c *HTTP) request(ctx context.Context, url string, opts RequestOpts) (*resty.Response, error) {
+func (c *HTTP) request(ctx context.Context, url string, opts RequestOpts) (_ *resty.Response, err error) {
+	if c.recoverPanic {
+		defer recoverPanic(c.log, &err)
+	}
+
 	req := c.R(ctx).SetBody(opts.Body).SetResult(opts.Result).SetAuthToken(opts.AuthToken).
-		SetHeaders(opts.Headers).SetQueryParams(opts.Query).SetCookies(opts.Cookies).
-		ForceContentType(opts.ForceContentType).SetFormData(opts.FormData)
+		SetHeaders(c.headers.filter(opts.Headers, opts.DeniedHeaders)).SetQueryParams(opts.Query).SetPathParams(opts.PathParams).
+		SetQueryParamsFromValues(opts.QueryValues).SetCookies(opts.Cookies).ForceContentType(opts.ForceContentType)
+	for k, values := range c.headers.filterValues(opts.HeaderValues, opts.DeniedHeaders) {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if c.userAgents != nil && !opts.hasHeader("User-Agent") {
+		if userAgent := c.userAgents.UserAgent(); userAgent != "" {
+			req.SetHeader("User-Agent", userAgent)
+		}
+	}
+	if name, version := c.apiVersion.headerValue(opts); name != "" {
+		req.SetHeader(name, version)
+	}
 	if opts.BasicAuthUser != "" && opts.BasicAuthPass != "" {
 		req.SetBasicAuth(opts.BasicAuthUser, opts.BasicAuthPass)
 	}
-	if opts.EnableTrace {
+	prof := c.profile.Load()
+	if opts.EnableTrace || prof != nil || c.slowRequest > 0 {
 		req.EnableTrace()
 	}
-	if opts.Files != nil {
-		req.SetFiles(opts.Files)
+	if !opts.streamsForm() {
+		req.SetFormData(opts.FormData)
+		if opts.Files != nil {
+			req.SetFiles(opts.Files)
+		}
+	}
+	// Result is decoded after the response with the decoding options of the request instead of the client ones
+	jsonResult := lang.If(opts.JSONDecoding != nil, req.Result, nil)
+	if jsonResult != nil {
+		req.Result = nil
+	}
+	if len(opts.Accept) > 0 && !opts.hasHeader("Accept") {
+		req.SetHeader("Accept", acceptHeader(opts.Accept))
+	}
+	if opts.IfMatch != "" && !opts.hasHeader("If-Match") {
+		req.SetHeader("If-Match", opts.IfMatch)
+	}
+	if opts.ExpectContinue && !opts.hasHeader("Expect") {
+		req.SetHeader("Expect", "100-continue")
+	}
+	if opts.IdentityEncoding && !opts.hasHeader("Accept-Encoding") {
+		req.SetHeader("Accept-Encoding", "identity")
+	}
+	rawBody := opts.OutputPath != "" || opts.StreamResponse
+	if rawBody {
+		req.SetDoNotParseResponse(true)
 	}
 	if opts.OutputPath != "" {
-		req.SetOutput(opts.OutputPath)
+		if opts.OutputEncoding == OutputRaw && !opts.hasHeader("Accept-Encoding") {
+			req.SetHeader("Accept-Encoding", "gzip")
+		}
+	}
+	info := RequestInfo{
+		Method: lang.Check(opts.Method, http.MethodGet),
+		Route:  opts.Route,
+		Name:   opts.RequestName,
+		Labels: opts.RequestLabels,
+		Meta:   opts.Meta,
+	}
+	requestErr := func(err error) error {
+		return &RequestError{Name: opts.RequestName, Labels: opts.RequestLabels, Err: err}
+	}
+
+	body, err := newBodySource(opts)
+	if err != nil {
+		return nil, requestErr(err)
+	}
+	form, err := newMultipartBody(opts)
+	if err != nil {
+		return nil, requestErr(err)
+	}
+	encoding := strings.ToLower(lang.Check(opts.ContentEncoding, c.encoding))
+	encoder, err := c.encoders.get(encoding)
+	if err != nil {
+		return nil, requestErr(err)
+	}
+	if form != nil && !opts.hasHeader("Content-Type") {
+		req.SetHeader("Content-Type", form.contentType())
 	}
-	opts.RequestName = lang.If(opts.RequestName != "", opts.RequestName+" ", "")
 
 	sender := getSender(req, opts.Method)
+	url = c.apiVersion.prefix(url, opts)
+	if c.resolver != nil && !strings.HasPrefix(url, "http") {
+		baseURL, err := c.resolver.resolve(ctx)
+		if err != nil {
+			return nil, requestErr(err)
+		}
+		url = baseURL + url
+	}
 	url = c.prepareURL(url)
+	host := c.requestHost(url)
+	info.Host = host
+	captured := c.capture.Match(lang.If(strings.HasPrefix(url, "http"), url, c.cli.BaseURL+url))
+	if c.debug && !c.capture.IsEmpty() && captured {
+		req.SetDebug(true)
+	}
+
+	stats := getRequestStats(ctx)
+	send := func() (*resty.Response, error) {
+		if body != nil {
+			r, err := body.next()
+			if err != nil {
+				return nil, err
+			}
+			req.SetBody(r)
+		}
+		attemptCtx, state := withRequestState(ctx)
+		state.stopRedirects = opts.ReturnOn3xx
+		state.maxRequestSize = lang.Check(opts.MaxRequestSize, c.maxReqSize)
+		state.chunked, state.contentLength = opts.Chunked, opts.ContentLength
+		state.trailers = opts.Trailers
+		state.onUploadProgress = opts.OnUploadProgress
+		state.encoder, state.encoding = encoder, encoding
+		if form != nil {
+			state.streamBody = form.open()
+			defer state.streamBody.Close()
+			state.contentLength = lang.Check(opts.ContentLength, form.length)
+		}
+		trace := &httptrace.ClientTrace{
+			WroteRequest: func(info httptrace.WroteRequestInfo) {
+				if info.Err == nil {
+					state.wroteRequest.Store(true)
+				}
+			},
+		}
+		tracePhases(trace, state)
+		if opts.OnInformational != nil {
+			trace.Got1xxResponse = func(code int, header textproto.MIMEHeader) error {
+				opts.OnInformational(code, http.Header(header))
+				return nil
+			}
+		}
+		req.SetContext(httptrace.WithClientTrace(attemptCtx, trace))
+		ticket, err := c.throttle.acquire(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		defer ticket.cancel()
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		if err := c.hostLimiters.wait(ctx, host); err != nil {
+			return nil, err
+		}
+		if err := c.scheduler.acquire(ctx, opts.Priority); err != nil {
+			return nil, err
+		}
+		defer c.scheduler.release()
+
+		stats.addAttempt()
+		c.metrics.OnRequestStart(ctx, info)
+		start := time.Now()
+		resp, err := sender(url)
+		duration := time.Since(start)
+		ticket.report(resp)
+		if err != nil && statusCode(resp) == 0 && !state.wroteRequest.Load() && !errors.Is(err, ErrNotSent) {
+			err = fmt.Errorf("%w: %w", ErrNotSent, err)
+		}
+		err = state.timeoutError(resp, err, duration)
+		c.metrics.OnRequestEnd(ctx, info, statusCode(resp), duration, err)
+		if c.slowRequest > 0 && duration > c.slowRequest {
+			c.reportSlowRequest(ctx, info, req, statusCode(resp), duration)
+		}
+		if prof != nil && statusCode(resp) != 0 {
+			prof.record(host, req)
+		}
+		if captured {
+			c.auditRequest(ctx, info, req, url, start, resp, err)
+		}
+		if statusCode(resp) != 0 {
+			c.apiVersion.check(info.Method, resp.Request.URL, info.Route, resp.Header())
+		}
+		if opts.NilOn404 && statusCode(resp) == http.StatusNotFound {
+			if raw := resp.RawBody(); raw != nil {
+				raw.Close()
+			}
+			return resp, nil
+		}
+		if err == nil && opts.ValidateResponse != nil && c.isSuccess(resp) {
+			if err := opts.ValidateResponse(resp); err != nil {
+				if raw := resp.RawBody(); rawBody && raw != nil {
+					raw.Close()
+				}
+				return resp, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+			}
+		}
+		if err == nil && c.openapi != nil && !rawBody {
+			if err := c.openapi.ValidateResponse(resp.Request.RawRequest, resp.StatusCode(), resp.Header(), resp.Body()); err != nil {
+				return resp, err
+			}
+		}
+		if err == nil && !rawBody {
+			if err := decodeResult(c.decoders, c.isSuccess, resp, opts.Result); err != nil {
+				return resp, err
+			}
+			if jsonResult != nil {
+				if err := decodeJSONResult(*opts.JSONDecoding, c.isSuccess, resp, jsonResult); err != nil {
+					return resp, err
+				}
+			}
+		}
+		if redirects := state.getRedirects(); err != nil && len(redirects) > 0 {
+			return resp, &RedirectError{Redirects: redirects, Err: err}
+		}
+		if err == nil && opts.OutputPath != "" {
+			if err := saveOutput(resp, opts, c.isSuccess(resp)); err != nil {
+				return resp, err
+			}
+			state.setSavedFile(opts.OutputPath)
+			return resp, nil
+		}
+		if err == nil && opts.StreamResponse {
+			if !c.isSuccess(resp) {
+				return resp, rawStatusError(resp)
+			}
+			if opts.OnDownloadProgress != nil && resp.RawResponse != nil {
+				resp.RawResponse.Body = &progressReader{
+					ReadCloser: resp.RawResponse.Body,
+					total:      lang.If(resp.RawResponse.ContentLength > 0, resp.RawResponse.ContentLength, -1),
+					f:          opts.OnDownloadProgress,
+				}
+			}
+			state.setStreamed()
+			return resp, nil
+		}
+		if err == nil && opts.TeeWriter != nil && len(resp.Body()) > 0 {
+			if _, err := opts.TeeWriter.Write(resp.Body()); err != nil {
+				return resp, fmt.Errorf("write response to tee: %w", err)
+			}
+		}
+		return resp, err
+	}
 
-	resp, err := sender(url)
+	start := time.Now()
+	resp, err := send()
 	switch {
 	case err == nil:
 		return resp, nil
-	case (opts.RetryCount == 0 && !opts.InfiniteRetry) || (opts.RetryOnlyServerErrors && !IsServerError(err)):
-		return nil, fmt.Errorf("failed %srequest: %w", opts.RequestName, err)
+	case (opts.RetryCount == 0 && !opts.InfiniteRetry) || (opts.RetryOnlyServerErrors && !IsServerError(err)) || !body.replayable() || !form.replayable() ||
+		errors.Is(err, ErrContractViolation) || errors.Is(err, ErrRequestTooLarge) || !opts.canReplay(err):
+		return nil, requestErr(err)
 	}
 
 	// Start retry
@@ -178,44 +660,56 @@ func (c *HTTP) request(ctx context.Context, url string, opts RequestOpts) (*rest
 	opts.RetryMaxWaitTime = lang.Check(opts.RetryMaxWaitTime, defaultMaxWaitTime)
 
 	if !opts.NoLogRetryError {
-		msg := "failed " + opts.RequestName + "request, "
-		if opts.InfiniteRetry {
-			msg += "infinite retry"
-		} else {
-			msg += strconv.Itoa(opts.RetryCount) + " retries"
-		}
-		c.log.Error(msg, "error", err, "address", c.cli.BaseURL+url)
+		retries := lang.If(opts.InfiniteRetry, "infinite", strconv.Itoa(opts.RetryCount))
+		c.log.Error("failed request, retrying", append(identityAttrs(opts.RequestName, opts.RequestLabels),
+			"retries", retries, "error", err, "address", c.cli.BaseURL+opts.Route)...)
 	}
 
-	errs := abstract.NewSet[string]()
+	retryErr := &RetryError{RequestName: opts.RequestName, Labels: opts.RequestLabels}
+	retryErr.add(1, 0, time.Since(start), err)
+	deadline, hasDeadline := ctx.Deadline()
+	policy := c.retryPolicy(opts)
+
 	for retry := 1; retry < opts.RetryCount; retry++ {
-		sleepTime := getSleepTime(retry, opts.RetryWaitTime, opts.RetryMaxWaitTime)
+		sleepTime, ok := policy.NextDelay(retry, resp, err)
+		if !ok {
+			return nil, retryErr
+		}
+		if opts.RetryWithinDeadline && hasDeadline && !retryErr.fitsDeadline(deadline, sleepTime) {
+			retryErr.Cause = ErrRetryDeadline
+			return nil, retryErr
+		}
+		c.metrics.OnRetry(ctx, info, retry+1, sleepTime, err)
 
 		select {
 		case <-ctx.Done():
-			return nil, fmt.Errorf("request canceled after %d retries, got errors: %s", retry, errors.Join(lang.Convert(errs.Values(), func(err string) error {
-				return errors.New(err)
-			})...))
+			retryErr.Cause = ctx.Err()
+			return nil, retryErr
 
 		case <-time.After(sleepTime):
 		}
 
-		resp, err = sender(url)
+		start = time.Now()
+		resp, err = send()
+		if errors.Is(err, ErrBodyNotReplayable) || errors.Is(err, ErrContractViolation) || errors.Is(err, ErrRequestTooLarge) {
+			return nil, requestErr(err)
+		}
 		if err != nil {
 			if !opts.NoLogRetryError {
-				c.log.Warn("failed "+opts.RequestName+"request after retry", "error", err, "n", retry, "address", c.cli.BaseURL+url)
+				c.log.Warn("failed request after retry", append(identityAttrs(opts.RequestName, opts.RequestLabels),
+					"error", err, "n", retry, "address", c.cli.BaseURL+opts.Route)...)
+			}
+			retryErr.add(retry+1, sleepTime, time.Since(start), err)
+			if !opts.canReplay(err) {
+				return nil, retryErr
 			}
-			errs.Add(err.Error())
 			continue
 		}
 
 		return resp, nil
 	}
 
-	return nil, fmt.Errorf("failed %srequest after %d retries, got errors: %s", opts.RequestName, opts.RetryCount,
-		errors.Join(lang.Convert(errs.Values(), func(err string) error {
-			return errors.New(err)
-		})...))
+	return nil, retryErr
 }
 
 // Req performs request with method to the BaseURL +  URL and returns response
@@ -235,8 +729,46 @@ func (c *HTTP) Get(ctx context.Context, url string, responseBody ...any) (*resty
 // GetQ performs GET request to the BaseURL +  URL with query and returns response
 func (c *HTTP) GetQ(ctx context.Context, url string, responseBody any, queryPairs ...string) (*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Result: responseBody,
-		Query:  lang.PairsToMap(queryPairs)})
+		Result:      responseBody,
+		QueryValues: pairsToValues(queryPairs)})
+}
+
+// GetOrNil performs GET request to the BaseURL + URL and returns the response body decoded to T,
+// it returns nil without error if the resource is not found (404).
+func GetOrNil[T any](ctx context.Context, c *HTTP, url string, opts ...RequestOpts) (*T, error) {
+	reqOpts := lang.First(opts)
+	reqOpts.Result = new(T)
+	reqOpts.NilOn404 = true
+	resp, err := c.Request(ctx, url, reqOpts)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil, nil
+	}
+	return reqOpts.Result.(*T), nil
+}
+
+// GetString performs GET request to the BaseURL +  URL and returns response body as a string
+func (c *HTTP) GetString(ctx context.Context, url string) (string, error) {
+	resp, err := c.Request(ctx, url, RequestOpts{})
+	if err != nil {
+		return "", err
+	}
+	return string(resp.Body()), nil
+}
+
+// GetJSONMap performs GET request to the BaseURL +  URL and returns response body decoded from JSON to a map
+func (c *HTTP) GetJSONMap(ctx context.Context, url string) (map[string]any, error) {
+	resp, err := c.Request(ctx, url, RequestOpts{})
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(resp.Body(), &out); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	return out, nil
 }
 
 // Post performs POST request to the BaseURL +  URL and returns response
@@ -250,10 +782,10 @@ func (c *HTTP) Post(ctx context.Context, url string, requestBody any, responseBo
 // PostQ performs POST request to the BaseURL +  URL with query and returns response
 func (c *HTTP) PostQ(ctx context.Context, url string, requestBody any, responseBody any, queryPairs ...string) (*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Method: http.MethodPost,
-		Body:   requestBody,
-		Result: responseBody,
-		Query:  lang.PairsToMap(queryPairs)})
+		Method:      http.MethodPost,
+		Body:        requestBody,
+		Result:      responseBody,
+		QueryValues: pairsToValues(queryPairs)})
 }
 
 // Put performs PUT request to the BaseURL +  URL and returns response
@@ -267,10 +799,10 @@ func (c *HTTP) Put(ctx context.Context, url string, requestBody any, responseBod
 // PutQ performs PUT request to the BaseURL +  URL with query and returns response
 func (c *HTTP) PutQ(ctx context.Context, url string, requestBody any, responseBody any, queryPairs ...string) (*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Method: http.MethodPut,
-		Body:   requestBody,
-		Result: responseBody,
-		Query:  lang.PairsToMap(queryPairs)})
+		Method:      http.MethodPut,
+		Body:        requestBody,
+		Result:      responseBody,
+		QueryValues: pairsToValues(queryPairs)})
 }
 
 // Patch performs PATCH request to the BaseURL +  URL and returns response
@@ -284,10 +816,10 @@ func (c *HTTP) Patch(ctx context.Context, url string, requestBody any, responseB
 // PatchQ performs PATCH request to the BaseURL +  URL with query and returns response
 func (c *HTTP) PatchQ(ctx context.Context, url string, requestBody any, responseBody any, queryPairs ...string) (*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Method: http.MethodPatch,
-		Body:   requestBody,
-		Result: responseBody,
-		Query:  lang.PairsToMap(queryPairs)})
+		Method:      http.MethodPatch,
+		Body:        requestBody,
+		Result:      responseBody,
+		QueryValues: pairsToValues(queryPairs)})
 }
 
 // Delete performs DELETE request to the BaseURL +  URL and returns response
@@ -300,9 +832,41 @@ func (c *HTTP) Delete(ctx context.Context, url string, responseBody ...any) (*re
 // DeleteQ performs DELETE request to the BaseURL +  URL with query and returns response
 func (c *HTTP) DeleteQ(ctx context.Context, url string, responseBody any, queryPairs ...string) (*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Method: http.MethodDelete,
-		Result: responseBody,
-		Query:  lang.PairsToMap(queryPairs)})
+		Method:      http.MethodDelete,
+		Result:      responseBody,
+		QueryValues: pairsToValues(queryPairs)})
+}
+
+func (c *HTTP) auditRequest(ctx context.Context, info RequestInfo, req *resty.Request, url string, start time.Time, resp *resty.Response, err error) {
+	if c.audit == nil {
+		return
+	}
+	record := AuditRecord{
+		Time:      start,
+		Method:    lang.Check(req.Method, http.MethodGet),
+		URL:       c.cli.BaseURL + url,
+		Name:      info.Name,
+		Labels:    info.Labels,
+		Initiator: InitiatorFromContext(ctx),
+		Meta:      MetaFromContext(ctx),
+		Duration:  time.Since(start),
+	}
+	if req.RawRequest != nil {
+		record.URL = req.RawRequest.URL.String()
+	}
+	record.StatusCode = statusCode(resp)
+	if err != nil {
+		record.Error = err.Error()
+	}
+	c.audit.Audit(record)
+}
+
+// statusCode returns the status code of the response, zero if there is no response.
+func statusCode(resp *resty.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode()
 }
 
 func (c *HTTP) prepareURL(url string) string {
@@ -312,32 +876,17 @@ func (c *HTTP) prepareURL(url string) string {
 	return url
 }
 
-func errorHandler(_ *resty.Client, r *resty.Response) error {
-	if r.StatusCode() < 400 {
-		return nil
-	}
-
-	apiErr, ok := ErrorMapping[r.StatusCode()]
-	if !ok {
-		apiErr = fmt.Errorf("code %d", r.StatusCode())
-	}
+func isSuccessStatus(r *resty.Response) bool {
+	return r.StatusCode() < 400
+}
 
-	var errBody ServerErrorResponse
-	if err := json.Unmarshal(r.Body(), &errBody); err == nil {
-		errMsg := getErrorMessage(errBody)
-		if errBody.Code != 0 {
-			apiErr = lang.Check(ErrorMapping[errBody.Code], apiErr)
-		}
-		if errMsg != "" {
-			return fmt.Errorf("%w: %s", apiErr, errMsg)
+func newErrorHandler(isSuccess func(*resty.Response) bool) resty.ResponseMiddleware {
+	return func(_ *resty.Client, r *resty.Response) error {
+		if isSuccess(r) {
+			return nil
 		}
+		return statusError(r.StatusCode(), r.Header(), decodeErrorBody(r.Header().Get("Content-Encoding"), r.Body()))
 	}
-
-	if body := string(r.Body()); body != "" {
-		return fmt.Errorf("%w: %s", apiErr, maxLen(body, 100))
-	}
-
-	return apiErr
 }
 
 func maxLen(a string, b int) string {
@@ -400,16 +949,35 @@ func getErrorMessage(r ServerErrorResponse) string {
 
 // IsServerError returns true if the error is a server error (5xx).
 func IsServerError(err error) bool {
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.IsServerError()
+	}
 	return err != nil && strings.Contains(err.Error(), "code 5")
 }
 
-// GetCodeFromError returns the error code from the error message.
+// GetCodeFromError returns the status code of the unsuccessful response from the error,
+// it is parsed from the error message if the error is not APIError.
 func GetCodeFromError(err error) int {
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.StatusCode
+	}
 	errStr := err.Error()
 	index := strings.Index(errStr, "code ")
-	if index == -1 {
+	if index == -1 || len(errStr) < index+8 {
 		return 0
 	}
 	code, _ := strconv.Atoi(errStr[index+5 : index+8])
 	return code
 }
+
+// pairsToValues returns query values from key-value pairs, repeated keys keep all values.
+func pairsToValues(pairs []string) neturl.Values {
+	if len(pairs) < 2 {
+		return nil
+	}
+	out := make(neturl.Values, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out.Add(pairs[i], pairs[i+1])
+	}
+	return out
+}
@@ -6,14 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/maxbolgarin/cliex"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -97,6 +100,39 @@ func TestHTTP_GetQ(t *testing.T) {
 	assert.Equal(t, int64(1), requestCounter.Load())
 }
 
+func TestHTTP_GetString(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/test": func(ctx context.Context, req *http.Request) (interface{}, error) {
+			return map[string]any{"key": "value", "n": 1}, nil
+		},
+		"/error": func(ctx context.Context, req *http.Request) (interface{}, error) {
+			return nil, cliex.ErrNotFound
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	body, err := client.GetString(ctx, "/test")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"key":"value","n":1}`, body)
+
+	m, err := client.GetJSONMap(ctx, "/test")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"key": "value", "n": float64(1)}, m)
+
+	_, err = client.GetString(ctx, "/error")
+	assert.ErrorIs(t, err, cliex.ErrNotFound)
+
+	_, err = client.GetJSONMap(ctx, "/error")
+	assert.ErrorIs(t, err, cliex.ErrNotFound)
+}
+
 func TestHTTP_Post(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -626,3 +662,258 @@ func TestGetCodeFromError(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTP_TeeWriter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/test": func(ctx context.Context, req *http.Request) (interface{}, error) {
+			return map[string]string{"key": "value"}, nil
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	var (
+		tee          strings.Builder
+		responseBody map[string]string
+	)
+	_, err = client.Request(ctx, "/test", cliex.RequestOpts{
+		Result:    &responseBody,
+		TeeWriter: &tee,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "value", responseBody["key"])
+	assert.JSONEq(t, `{"key":"value"}`, tee.String())
+}
+
+func TestHTTP_Route(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/1" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:                mockServer.URL,
+		CircuitBreaker:         true,
+		CircuitBreakerTimeout:  time.Minute,
+		CircuitBreakerFailures: 2,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// Different IDs of the same route share the circuit breaker
+	for _, id := range []string{"2", "3"} {
+		_, err = client.Request(ctx, "/users/"+id, cliex.RequestOpts{Route: "/users/{id}"})
+		assert.ErrorContains(t, err, "internal server error")
+	}
+	_, err = client.Request(ctx, "/users/1", cliex.RequestOpts{Route: "/users/{id}"})
+	assert.ErrorIs(t, err, cliex.ErrCBOpenState)
+
+	// Templated URL is used as a route by default
+	for _, id := range []string{"4", "5"} {
+		_, err = client.Request(ctx, "/accounts/{id}", cliex.RequestOpts{PathParams: map[string]string{"id": id}})
+		assert.ErrorContains(t, err, "internal server error")
+	}
+	_, err = client.Request(ctx, "/accounts/{id}", cliex.RequestOpts{PathParams: map[string]string{"id": "6"}})
+	assert.ErrorIs(t, err, cliex.ErrCBOpenState)
+
+	_, err = client.Request(ctx, "/users/{id}", cliex.RequestOpts{Route: "/other", PathParams: map[string]string{"id": "1"}})
+	assert.NoError(t, err)
+}
+
+func TestHTTP_OnInformational(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Header().Del("Link")
+		w.Write([]byte("final"))
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL})
+	require.NoError(t, err)
+
+	var hints []string
+	resp, err := client.Request(context.Background(), "/", cliex.RequestOpts{
+		EnableTrace: true,
+		OnInformational: func(code int, header http.Header) {
+			assert.Equal(t, http.StatusEarlyHints, code)
+			hints = append(hints, header.Get("Link"))
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "final", resp.String())
+	assert.Equal(t, []string{"</style.css>; rel=preload; as=style"}, hints)
+	assert.NotZero(t, resp.Request.TraceInfo().TotalTime)
+}
+
+func TestHTTP_QueryValues(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RawQuery))
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	resp, err := client.Request(ctx, "/", cliex.RequestOpts{
+		Query:       map[string]string{"sort": "name"},
+		QueryValues: url.Values{"id": {"1", "2"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "id=1&id=2&sort=name", resp.String())
+
+	resp, err = client.GetQ(ctx, "/", nil, "id", "1", "id", "2", "limit", "10")
+	require.NoError(t, err)
+	assert.Equal(t, "id=1&id=2&limit=10", resp.String())
+}
+
+func TestHTTP_IsSuccess(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/embedded":
+			w.Write([]byte(`{"code":1001,"message":"quota exceeded"}`))
+		default:
+			w.Write([]byte(`{"code":0}`))
+		}
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.New(
+		cliex.WithBaseURL(mockServer.URL),
+		cliex.WithIsSuccess(func(resp *resty.Response) bool {
+			if resp.StatusCode() == http.StatusNotFound {
+				return true
+			}
+			return resp.StatusCode() < 400 && !strings.Contains(resp.String(), `"message"`)
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	resp, err := client.Get(ctx, "/missing")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+
+	_, err = client.Get(ctx, "/embedded")
+	require.ErrorIs(t, err, cliex.ErrUnsuccessfulResponse)
+	assert.Contains(t, err.Error(), "quota exceeded")
+
+	_, err = client.Get(ctx, "/ok")
+	require.NoError(t, err)
+}
+
+func TestHTTP_NilOn404(t *testing.T) {
+	var calls atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/users/1":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"john"}`))
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	resp, err := client.Request(ctx, "/users/2", cliex.RequestOpts{NilOn404: true, RetryCount: 3})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+	assert.Equal(t, int32(1), calls.Load())
+
+	u, err := cliex.GetOrNil[user](ctx, client, "/users/1")
+	require.NoError(t, err)
+	require.NotNil(t, u)
+	assert.Equal(t, "john", u.Name)
+
+	u, err = cliex.GetOrNil[user](ctx, client, "/users/2")
+	require.NoError(t, err)
+	assert.Nil(t, u)
+
+	_, err = cliex.GetOrNil[user](ctx, client, "/broken")
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+}
+
+func TestHTTP_ValidateResponse(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" || requests.Add(1) < 3 {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html>bad gateway</html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"alice"}`))
+	}))
+	defer srv.Close()
+
+	errNotJSON := errors.New("not json")
+	validate := func(resp *resty.Response) error {
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header().Get("Content-Type")); mediaType != "application/json" {
+			return errNotJSON
+		}
+		return nil
+	}
+
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:                srv.URL,
+		CircuitBreaker:         true,
+		CircuitBreakerFailures: 1,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var user struct {
+		Name string `json:"name"`
+	}
+	_, err = client.Request(ctx, "/users/1", cliex.RequestOpts{
+		Result:           &user,
+		ValidateResponse: validate,
+		RetryCount:       3,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Name)
+	assert.EqualValues(t, 3, requests.Load())
+
+	_, err = client.Request(ctx, "/broken", cliex.RequestOpts{ValidateResponse: validate})
+	require.ErrorIs(t, err, cliex.ErrInvalidResponse)
+	require.ErrorIs(t, err, errNotJSON)
+
+	_, err = client.Request(ctx, "/broken", cliex.RequestOpts{ValidateResponse: validate})
+	require.ErrorIs(t, err, cliex.ErrCBOpenState)
+
+	resp, err := client.Request(ctx, "/users/1", cliex.RequestOpts{ValidateResponse: validate, StreamResponse: true})
+	require.NoError(t, err)
+	require.NoError(t, resp.RawBody().Close())
+}
@@ -0,0 +1,71 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/lang"
+)
+
+const defaultConditionalAttempts = 3
+
+// ErrNoETag is returned by UpdateIfMatch when the fetched resource has no ETag header.
+var ErrNoETag = errors.New("no etag in response")
+
+// ConditionalOpts is the options for the optimistic-concurrency update of a resource.
+type ConditionalOpts struct {
+	// RequestOpts is the options of the update request. Default Method is PUT, Body is the result of Modify.
+	RequestOpts
+
+	// FetchOpts is the options of the request that fetches the current resource. Method is always GET.
+	FetchOpts RequestOpts
+
+	// Modify returns the new body of the resource by the fetched one. It is called again after every refetch.
+	Modify func(current *resty.Response) (any, error)
+
+	// MaxAttempts is the maximum number of fetch and update cycles if the server responds with 412
+	// because the resource was changed concurrently. Default is 3.
+	MaxAttempts int
+}
+
+// UpdateIfMatch updates the resource on the BaseURL + URL with optimistic concurrency: it fetches the resource
+// with GET, calls Modify and sends the new body with If-Match header set to the ETag of the fetched resource.
+// If the server responds with 412, the resource is refetched bypassing memoization and HTTP cache and the update
+// is repeated up to MaxAttempts times, after that the error wrapping ErrPreconditionFailed is returned.
+func (c *HTTP) UpdateIfMatch(ctx context.Context, url string, opts ConditionalOpts) (*resty.Response, error) {
+	if opts.Modify == nil {
+		return nil, errors.New("empty modify function")
+	}
+	fetchOpts := opts.FetchOpts
+	fetchOpts.Method = http.MethodGet
+	updateOpts := opts.RequestOpts
+	updateOpts.Method = lang.Check(updateOpts.Method, http.MethodPut)
+
+	var err error
+	for range lang.Check(opts.MaxAttempts, defaultConditionalAttempts) {
+		current, fetchErr := c.Request(ctx, url, fetchOpts)
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		updateOpts.IfMatch = current.Header().Get("ETag")
+		if updateOpts.IfMatch == "" {
+			return nil, ErrNoETag
+		}
+		updateOpts.Body, err = opts.Modify(current)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp *resty.Response
+		resp, err = c.Request(ctx, url, updateOpts)
+		if !errors.Is(err, ErrPreconditionFailed) {
+			return resp, err
+		}
+		// The refetch must get the changed resource, not the memoized or cached one
+		fetchOpts.NoCache, fetchOpts.CacheTTL = true, 0
+		c.log.Debug("resource was changed concurrently, refetch", append(identityAttrs(updateOpts.RequestName, updateOpts.RequestLabels), "url", url)...)
+	}
+	return nil, err
+}
@@ -0,0 +1,95 @@
+package cliex_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_UpdateIfMatch(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		version    = 1
+		value      = "a"
+		concurrent = 1 // number of updates made by another writer before ours
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		etag := `"` + strconv.Itoa(version) + `"`
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("ETag", etag)
+			w.Write([]byte(value))
+		case http.MethodPut:
+			if concurrent > 0 {
+				concurrent--
+				version++
+				value += "x"
+			}
+			if r.Header.Get("If-Match") != `"`+strconv.Itoa(version)+`"` {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			value = string(body)
+			version++
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	resp, err := client.Do(ctx, "/doc", cliex.RequestOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, `"1"`, resp.ETag())
+
+	var modified []string
+	opts := cliex.ConditionalOpts{
+		Modify: func(current *resty.Response) (any, error) {
+			modified = append(modified, current.String())
+			return current.String() + "b", nil
+		},
+	}
+	_, err = client.UpdateIfMatch(ctx, "/doc", opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "ax"}, modified)
+	mu.Lock()
+	assert.Equal(t, "axb", value)
+	// Another writer wins every time
+	concurrent = 10
+	mu.Unlock()
+
+	opts.MaxAttempts = 2
+	_, err = client.UpdateIfMatch(ctx, "/doc", opts)
+	require.ErrorIs(t, err, cliex.ErrPreconditionFailed)
+	mu.Lock()
+	assert.Equal(t, 8, concurrent)
+	mu.Unlock()
+
+	_, err = client.Request(ctx, "/doc", cliex.RequestOpts{Method: http.MethodPut, Body: "c", IfMatch: `"1"`})
+	require.ErrorIs(t, err, cliex.ErrPreconditionFailed)
+
+	// The refetch after 412 is not memoized
+	mu.Lock()
+	concurrent = 1
+	mu.Unlock()
+	modified = nil
+	opts.FetchOpts.CacheTTL = time.Minute
+	_, err = client.UpdateIfMatch(ctx, "/doc", opts)
+	require.NoError(t, err)
+	require.Len(t, modified, 2)
+	assert.Equal(t, modified[0]+"x", modified[1])
+}
@@ -10,11 +10,14 @@ import (
 	"net/http/httptest"
 	"os"
 	"regexp"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/maxbolgarin/lang"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sony/gobreaker/v2"
 )
 
 const (
@@ -35,13 +38,51 @@ type Config struct {
 	// Default is empty, means you should provide full URL in Request methods.
 	BaseURL string `yaml:"base_url" json:"base_url" env:"CLIEX_BASE_URL"`
 
+	// ResolveFunc resolves the service of BaseURL with "srv://" or "srv+https://" scheme (e.g. "srv://api.service.consul")
+	// to the list of "host:port" addresses that are used in round-robin order.
+	// Default is DNS SRV lookup of the service name.
+	ResolveFunc func(ctx context.Context, service string) ([]string, error) `yaml:"-" json:"-"`
+
+	// ResolveCacheTTL is the duration for which resolved addresses of srv:// BaseURL are cached.
+	// Default is 30 seconds.
+	ResolveCacheTTL time.Duration `yaml:"resolve_cache_ttl" json:"resolve_cache_ttl" env:"CLIEX_RESOLVE_CACHE_TTL"`
+
 	// UserAgent is the User-Agent header that is used for every request.
 	// Default is "Golang HTTP client".
 	UserAgent string `yaml:"user_agent" json:"user_agent" env:"CLIEX_USER_AGENT"`
 
+	// UserAgents is the list of User-Agent headers that are rotated in round-robin order for every request.
+	// It overrides UserAgent.
+	UserAgents []string `yaml:"user_agents" json:"user_agents" env:"CLIEX_USER_AGENTS"`
+
+	// UserAgentProvider returns the User-Agent header for every request, it overrides UserAgent and UserAgents.
+	// User-Agent from RequestOpts.Headers takes precedence over the provider.
+	UserAgentProvider UserAgentProvider `yaml:"-" json:"-"`
+
 	// AuthToken is the Bearer token that is used for every request.
 	AuthToken string `yaml:"auth_token" json:"auth_token" env:"CLIEX_AUTH_TOKEN"`
 
+	// BasicAuthUser is the user for basic authentication that is used for every request.
+	// It is overridden by RequestOpts.BasicAuthUser and RequestOpts.BasicAuthPass.
+	BasicAuthUser string `yaml:"basic_auth_user" json:"basic_auth_user" env:"CLIEX_BASIC_AUTH_USER"`
+
+	// BasicAuthPass is the password for basic authentication that is used for every request.
+	BasicAuthPass string `yaml:"basic_auth_pass" json:"basic_auth_pass" env:"CLIEX_BASIC_AUTH_PASS"`
+
+	// OAuth2TokenURL is the token endpoint of the OAuth2 client credentials flow. If it is set, the client fetches
+	// the Bearer token, caches it and refreshes it before expiry for every request without Authorization header.
+	// A request that got 401 is retried once with a fresh token.
+	OAuth2TokenURL string `yaml:"oauth2_token_url" json:"oauth2_token_url" env:"CLIEX_OAUTH2_TOKEN_URL"`
+
+	// OAuth2ClientID is the client ID of the OAuth2 client credentials flow.
+	OAuth2ClientID string `yaml:"oauth2_client_id" json:"oauth2_client_id" env:"CLIEX_OAUTH2_CLIENT_ID"`
+
+	// OAuth2ClientSecret is the client secret of the OAuth2 client credentials flow.
+	OAuth2ClientSecret string `yaml:"oauth2_client_secret" json:"oauth2_client_secret" env:"CLIEX_OAUTH2_CLIENT_SECRET"`
+
+	// OAuth2Scopes is the list of scopes that are requested with the OAuth2 token.
+	OAuth2Scopes []string `yaml:"oauth2_scopes" json:"oauth2_scopes" env:"CLIEX_OAUTH2_SCOPES"`
+
 	// ProxyAddress is the address of the proxy server.
 	// format "http://localhost:3128".
 	// If empty, no proxy will be used.
@@ -60,13 +101,43 @@ type Config struct {
 	// ClientKeyFile and ClientKeyFile are the files that are used to authenticate the client to the server.
 	ClientKeyFile string `yaml:"client_key_file" json:"client_key_file" env:"CLIEX_CLIENT_KEY_FILE"`
 
+	// ClientCerts is the map of named client certificates for mTLS, a certificate is selected per request
+	// with RequestOpts.ClientCertName. Default is empty, means only ClientCertFile is used.
+	ClientCerts map[string]ClientCert `yaml:"client_certs" json:"client_certs"`
+
 	// Insecure is the flag that allows to make requests to the server with invalid SSL certificate.
 	// Default is false.
 	Insecure bool `yaml:"insecure" json:"insecure" env:"CLIEX_INSECURE"`
 
+	// CaptureFilter limits Debug output and AuditSink records to requests with matching hosts and path prefixes.
+	// Default is empty, means all requests are captured.
+	CaptureFilter CaptureFilter `yaml:"capture_filter" json:"capture_filter"`
+
+	// RedactJSONPaths is the list of JSON paths of fields that are masked in request and response bodies
+	// of debug output, e.g. "$.password", "$.card.number" or "$.users[*].email". Default is empty, means no masking.
+	RedactJSONPaths []string `yaml:"redact_json_paths" json:"redact_json_paths" env:"CLIEX_REDACT_JSON_PATHS"`
+
+	// LocalAddr is the local IP address (optionally with port) or the name of the network interface
+	// that outgoing connections are bound to, e.g. to satisfy upstream IP allowlists on multi-homed hosts.
+	// Default is empty, means the address is chosen by the system.
+	LocalAddr string `yaml:"local_addr" json:"local_addr" env:"CLIEX_LOCAL_ADDR"`
+
+	// DisableCompression disables transparent gzip compression of the transport: Accept-Encoding is not added
+	// to requests and responses are not decompressed, so Content-Length and body bytes are exactly as sent by the server.
+	// Default is false.
+	DisableCompression bool `yaml:"disable_compression" json:"disable_compression" env:"CLIEX_DISABLE_COMPRESSION"`
+
+	// ExpectContinueTimeout is the time to wait for 100 Continue response to the request with
+	// RequestOpts.ExpectContinue before sending the body anyway. Default is 1 second.
+	ExpectContinueTimeout time.Duration `yaml:"expect_continue_timeout" json:"expect_continue_timeout" env:"CLIEX_EXPECT_CONTINUE_TIMEOUT"`
+
 	// Debug enables the debug mode.
 	Debug bool `yaml:"debug" json:"debug" env:"CLIEX_DEBUG"`
 
+	// RecoverPanics enables recovering of panics during the request (in hooks, callbacks or unmarshaling to Result),
+	// the panic is logged with the stack trace and returned as *PanicError. Default is false.
+	RecoverPanics bool `yaml:"recover_panics" json:"recover_panics" env:"CLIEX_RECOVER_PANICS"`
+
 	// CircuitBreaker enables the circuit breaker for url.
 	// Default is false.
 	CircuitBreaker bool `yaml:"circuit_breaker" json:"circuit_breaker" env:"CLIEX_CIRCUIT_BREAKER"`
@@ -79,6 +150,196 @@ type Config struct {
 	// Default is 5.
 	CircuitBreakerFailures uint32 `yaml:"circuit_breaker_failures" json:"circuit_breaker_failures" env:"CLIEX_CIRCUIT_BREAKER_FAILURES"`
 
+	// CircuitBreakerTTL is the duration after which the circuit breaker that is not used is deleted,
+	// it limits memory usage when routes contain IDs. Default is 0, means breakers are not deleted.
+	CircuitBreakerTTL time.Duration `yaml:"circuit_breaker_ttl" json:"circuit_breaker_ttl" env:"CLIEX_CIRCUIT_BREAKER_TTL"`
+
+	// CircuitBreakerMaxSize is the maximum number of circuit breakers (one per key, see CircuitBreakerKey),
+	// the least recently used breaker is deleted when it is exceeded. Default is 0, means no limit.
+	CircuitBreakerMaxSize int `yaml:"circuit_breaker_max_size" json:"circuit_breaker_max_size" env:"CLIEX_CIRCUIT_BREAKER_MAX_SIZE"`
+
+	// CircuitBreakerKey is the strategy of sharing circuit breakers between requests: per route (default),
+	// per host or per host and route. See BreakerKey.
+	CircuitBreakerKey BreakerKey `yaml:"circuit_breaker_key" json:"circuit_breaker_key" env:"CLIEX_CIRCUIT_BREAKER_KEY"`
+
+	// CircuitBreakerKeyFunc returns the key of the circuit breaker of the request, requests with the same key
+	// share the breaker. It overrides CircuitBreakerKey. Default is nil.
+	CircuitBreakerKeyFunc func(info RequestInfo) string `yaml:"-" json:"-"`
+
+	// OnCircuitStateChange is called on every state transition of a circuit breaker with the key of the breaker
+	// (see CircuitBreakerKey), including the transition to the closed state after HTTP.ResetCircuit.
+	// It is called asynchronously in order of transitions, so it may use HTTP.CircuitState. Default is nil.
+	OnCircuitStateChange func(key string, from, to gobreaker.State) `yaml:"-" json:"-"`
+
+	// DeniedHeaders is the list of headers that are stripped from RequestOpts.Headers,
+	// e.g. to prevent accidental Host or Content-Length overrides. See DefaultDeniedHeaders.
+	// Default is empty, means all caller-supplied headers are sent.
+	DeniedHeaders []string `yaml:"denied_headers" json:"denied_headers" env:"CLIEX_DENIED_HEADERS"`
+
+	// HeaderOverrides is the map of headers that are set for every request and override any other values.
+	HeaderOverrides map[string]string `yaml:"header_overrides" json:"header_overrides"`
+
+	// HeaderCase is the case of header names in outgoing requests: "canonical", "lower" or empty.
+	// Names are converted right before the request is sent, see HeaderCaseLower for the headers that stay canonical.
+	// Default is empty, header names are sent as net/http sets them.
+	HeaderCase HeaderCase `yaml:"header_case" json:"header_case" env:"CLIEX_HEADER_CASE"`
+
+	// APIVersion is the version of the API that is sent with every request, see APIVersionHeader and APIVersionPath.
+	// It can be overridden with RequestOpts.APIVersion. Default is empty, means no version is sent.
+	APIVersion string `yaml:"api_version" json:"api_version" env:"CLIEX_API_VERSION"`
+
+	// APIVersionHeader is the name of the header with APIVersion, e.g. "X-API-Version" or "Api-Version".
+	// The header is not set if the request has it in RequestOpts.Headers.
+	APIVersionHeader string `yaml:"api_version_header" json:"api_version_header" env:"CLIEX_API_VERSION_HEADER"`
+
+	// APIVersionPath is the template of the path prefix with APIVersion, e.g. "/v{version}" or "/api/{version}".
+	// The prefix is added to relative URLs of requests, absolute URLs are sent as is.
+	APIVersionPath string `yaml:"api_version_path" json:"api_version_path" env:"CLIEX_API_VERSION_PATH"`
+
+	// OnDeprecation is called for every response with Deprecation or Sunset headers (RFC 9745, RFC 8594).
+	// Default is logging a warning with Logger once per route.
+	OnDeprecation func(DeprecationInfo) `yaml:"-" json:"-"`
+
+	// RateLimitRPS is the number of requests per second of the client, requests are throttled with a token bucket
+	// before being sent (including retries). Default is 0, means no limit. Host limits are applied after it.
+	RateLimitRPS float64 `yaml:"rate_limit_rps" json:"rate_limit_rps" env:"CLIEX_RATE_LIMIT_RPS"`
+
+	// RateLimitBurst is the maximum number of requests that can be sent at once with RateLimitRPS.
+	// Default is RateLimitRPS rounded up (at least 1).
+	RateLimitBurst int `yaml:"rate_limit_burst" json:"rate_limit_burst" env:"CLIEX_RATE_LIMIT_BURST"`
+
+	// BackoffHeader is the name of the vendor-specific response header with the wait time before the next retry,
+	// e.g. "X-Backoff-Millis". It is honored for any failed response and takes precedence over Retry-After,
+	// the wait time is capped by RequestOpts.RetryMaxWaitTime. Default is empty, means only Retry-After is used.
+	BackoffHeader string `yaml:"backoff_header" json:"backoff_header" env:"CLIEX_BACKOFF_HEADER"`
+
+	// BackoffHeaderUnit is the unit of the BackoffHeader value, e.g. time.Millisecond. Default is 1 second.
+	BackoffHeaderUnit time.Duration `yaml:"backoff_header_unit" json:"backoff_header_unit" env:"CLIEX_BACKOFF_HEADER_UNIT"`
+
+	// RetryPolicy is the default policy of retries of requests with RetryCount, see RequestOpts.RetryPolicy.
+	// BackoffHeader is not used with it. Default is nil, means exponential backoff with jitter and Retry-After.
+	RetryPolicy RetryPolicy `yaml:"-" json:"-"`
+
+	// QueueOn429 holds requests to the host that returned 429 Too Many Requests until the reset time from
+	// BackoffHeader, Retry-After or rate limit headers, instead of letting every request retry independently
+	// and prolong the throttling. After the reset time one request is sent first, queued requests are released
+	// only if it is not throttled again. Default is false.
+	QueueOn429 bool `yaml:"queue_on_429" json:"queue_on_429" env:"CLIEX_QUEUE_ON_429"`
+
+	// QueueOn429Wait is the time to hold requests after 429 without the reset time in headers. Default is 1 second.
+	QueueOn429Wait time.Duration `yaml:"queue_on_429_wait" json:"queue_on_429_wait" env:"CLIEX_QUEUE_ON_429_WAIT"`
+
+	// HostRateLimits is the map of rate limits per host, key is a host with optional port (e.g. "api.example.com").
+	// It is useful when one client with empty BaseURL talks to several upstreams.
+	// Default is empty, means no limits.
+	HostRateLimits map[string]RateLimit `yaml:"host_rate_limits" json:"host_rate_limits"`
+
+	// HostRateLimitFunc returns the rate limit for the host that is missing in HostRateLimits.
+	// Return false to not limit the host. It is called once per host.
+	HostRateLimitFunc func(host string) (RateLimit, bool) `yaml:"-" json:"-"`
+
+	// IsSuccess reports whether the response is successful, unsuccessful responses are returned as errors
+	// and retried according to RequestOpts. Use it for APIs that return 200 with an embedded error code
+	// or 404 as a legitimate result. Body is empty for responses saved to RequestOpts.OutputPath.
+	// Default is status code below 400.
+	IsSuccess func(resp *resty.Response) bool `yaml:"-" json:"-"`
+
+	// JSONDecoding is the strictness of decoding JSON responses into RequestOpts.Result,
+	// e.g. DisallowUnknownFields to detect upstream schema changes. Default is compatible with encoding/json.
+	JSONDecoding JSONDecoding `yaml:"json_decoding" json:"json_decoding"`
+
+	// Decoders is the map of response decoders by media type (e.g. "application/msgpack") that are used
+	// to decode responses into RequestOpts.Result in addition to JSON and XML. Default is empty.
+	Decoders map[string]Decoder `yaml:"-" json:"-"`
+
+	// RequestEncoding is the content coding of request bodies (e.g. "gzip" or "zstd"), the body is compressed
+	// with the encoder from Encoders and sent with Content-Encoding header. It is overridden by
+	// RequestOpts.ContentEncoding. Default is empty, means bodies are not compressed.
+	RequestEncoding string `yaml:"request_encoding" json:"request_encoding" env:"CLIEX_REQUEST_ENCODING"`
+
+	// Encoders is the map of request body encoders by content coding (e.g. "zstd", "snappy" or "lz4")
+	// in addition to built-in "gzip" and "deflate". Default is empty.
+	Encoders map[string]Encoder `yaml:"-" json:"-"`
+
+	// Singleflight coalesces identical concurrent GET requests (same URL, query, headers and credentials) into one
+	// upstream request and shares its response, it cuts load on flaky upstreams when many goroutines request
+	// the same resource. The request is sent with values of the context of the first request and is canceled
+	// only when all requests are canceled. Default is false, see also RequestOpts.Dedupe.
+	Singleflight bool `yaml:"singleflight" json:"singleflight" env:"CLIEX_SINGLEFLIGHT"`
+
+	// HTTPCache enables the private HTTP cache of GET responses (RFC 9111): fresh responses are returned
+	// according to Cache-Control and Expires without sending requests, stale ones are revalidated
+	// with If-None-Match and If-Modified-Since, 304 Not Modified returns the stored response.
+	// Use RequestOpts.NoCache to bypass it. Default is false.
+	HTTPCache bool `yaml:"http_cache" json:"http_cache" env:"CLIEX_HTTP_CACHE"`
+
+	// CacheStore stores responses of the HTTP cache, it enables the cache if it is set. Responses are shared
+	// by all requests of the client with the same credentials of the request (AuthToken, basic auth, Authorization,
+	// cookies, ClientCertName), use different stores for clients with different credentials set in the Config.
+	// Default is in-memory store with 1000 responses.
+	CacheStore CacheStore `yaml:"-" json:"-"`
+
+	// MaxRequestSize is the maximum size of the request headers and body in bytes, larger requests fail locally
+	// with ErrRequestTooLarge without sending, e.g. when an enormous structure is serialized into a body by mistake.
+	// Default is 0, means no limit.
+	MaxRequestSize int64 `yaml:"max_request_size" json:"max_request_size" env:"CLIEX_MAX_REQUEST_SIZE"`
+
+	// MaxConcurrentRequests is the maximum number of requests that are sent concurrently by the client.
+	// Other requests wait in the queue ordered by RequestOpts.Priority. Default is 0, means no limit.
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests" json:"max_concurrent_requests" env:"CLIEX_MAX_CONCURRENT_REQUESTS"`
+
+	// DedupWindow is the duration within which identical mutating requests (same method, URL, body, form data
+	// and file paths) are rejected locally with DuplicateRequestError. It protects against accidental double submissions.
+	// Requests with BodyReader, GetBody or Multipart are not checked. Default is 0, means no deduplication.
+	DedupWindow time.Duration `yaml:"dedup_window" json:"dedup_window" env:"CLIEX_DEDUP_WINDOW"`
+
+	// OpenAPISpecFile is the path to OpenAPI 3 spec in JSON or YAML format. If it is set, outgoing requests
+	// and successful responses are validated against the spec, violations are returned as ErrContractViolation errors.
+	// It is intended for development and test environments. Default is empty, means no validation.
+	OpenAPISpecFile string `yaml:"openapi_spec_file" json:"openapi_spec_file" env:"CLIEX_OPENAPI_SPEC_FILE"`
+
+	// OpenAPIValidator is the validator that is used instead of loading OpenAPISpecFile.
+	OpenAPIValidator *OpenAPIValidator `yaml:"-" json:"-"`
+
+	// SLOs is the map of latency and error rate objectives per route (see RequestOpts.Route),
+	// key SLOAllRoutes ("*") applies the objective to every other route separately.
+	// Default is empty, means no SLO tracking.
+	SLOs map[string]SLO `yaml:"slos" json:"slos"`
+
+	// OnSLOViolation is called when a window of requests to a route violates its SLO.
+	// Default is logging a warning with Logger.
+	OnSLOViolation func(SLOViolation) `yaml:"-" json:"-"`
+
+	// RewriteRules is the list of rules that change scheme, host, path prefix and headers of outgoing requests,
+	// e.g. to handle differences of staging and production endpoints. Host rate limits, throttling, circuit breakers
+	// and metrics use the rewritten host. Default is empty, means no rewrites.
+	RewriteRules []RewriteRule `yaml:"rewrite_rules" json:"rewrite_rules"`
+
+	// SlowRequestThreshold is the duration of the request attempt after which the request is logged as a warning
+	// with trace timings (DNS, connect, TLS, server time) and reported to MetricsHook.OnSlowRequest.
+	// Tracing is enabled for all requests if it is set. Default is 0, means no slow request detection.
+	SlowRequestThreshold time.Duration `yaml:"slow_request_threshold" json:"slow_request_threshold" env:"CLIEX_SLOW_REQUEST_THRESHOLD"`
+
+	// MetricsHook receives request, retry and circuit breaker events for telemetry. Default is nil, means no metrics.
+	MetricsHook MetricsHook `yaml:"-" json:"-"`
+
+	// MetricsRegisterer is the Prometheus registerer of the built-in PrometheusMetrics, events are sent
+	// to both of them if MetricsHook is also set. Clients with the same registerer share the metrics.
+	// Default is nil, means no Prometheus metrics.
+	MetricsRegisterer prometheus.Registerer `yaml:"-" json:"-"`
+
+	// ContextHeaders is the list of headers that are set on every request from values of the request context,
+	// so cross-cutting values are propagated without setting them in every call. Headers from RequestOpts
+	// take precedence. Default is nil, means no headers from context.
+	ContextHeaders []ContextHeader `yaml:"-" json:"-"`
+
+	// Middlewares wrap the transport of the client in order, see HTTP.Use. Default is nil, means no middlewares.
+	Middlewares []Middleware `yaml:"-" json:"-"`
+
+	// AuditSink receives a record about every request sent by the client (including retries) for compliance logging.
+	// It is independent of the Logger and Debug mode. Default is nil, means no audit.
+	AuditSink AuditSink `yaml:"-" json:"-"`
+
 	// Logger is the logger that is used in cliex.
 	// Default is noop logger, if Debug == true default is JSON debug slog in stderr.
 	Logger Logger `yaml:"-" json:"-"`
@@ -95,6 +356,13 @@ func WithBaseURL(baseURL string) func(*Config) {
 	}
 }
 
+// WithResolveFunc sets the ResolveFunc field of the Config.
+func WithResolveFunc(f func(ctx context.Context, service string) ([]string, error)) func(*Config) {
+	return func(cfg *Config) {
+		cfg.ResolveFunc = f
+	}
+}
+
 // WithUserAgent sets the UserAgent field of the Config.
 func WithUserAgent(userAgent string) func(*Config) {
 	return func(cfg *Config) {
@@ -102,6 +370,20 @@ func WithUserAgent(userAgent string) func(*Config) {
 	}
 }
 
+// WithUserAgents sets the UserAgents field of the Config.
+func WithUserAgents(userAgents ...string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.UserAgents = userAgents
+	}
+}
+
+// WithUserAgentProvider sets the UserAgentProvider field of the Config.
+func WithUserAgentProvider(provider UserAgentProvider) func(*Config) {
+	return func(cfg *Config) {
+		cfg.UserAgentProvider = provider
+	}
+}
+
 // WithAuthToken sets the AuthToken field of the Config.
 func WithAuthToken(authToken string) func(*Config) {
 	return func(cfg *Config) {
@@ -109,6 +391,24 @@ func WithAuthToken(authToken string) func(*Config) {
 	}
 }
 
+// WithBasicAuth sets the BasicAuthUser and BasicAuthPass fields of the Config.
+func WithBasicAuth(user, pass string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.BasicAuthUser = user
+		cfg.BasicAuthPass = pass
+	}
+}
+
+// WithOAuth2 sets the OAuth2TokenURL, OAuth2ClientID, OAuth2ClientSecret and OAuth2Scopes fields of the Config.
+func WithOAuth2(tokenURL, clientID, clientSecret string, scopes ...string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.OAuth2TokenURL = tokenURL
+		cfg.OAuth2ClientID = clientID
+		cfg.OAuth2ClientSecret = clientSecret
+		cfg.OAuth2Scopes = scopes
+	}
+}
+
 // WithProxyAddress sets the ProxyAddress field of the Config.
 func WithProxyAddress(proxyAddress string) func(*Config) {
 	return func(cfg *Config) {
@@ -151,6 +451,20 @@ func WithDebug(debug bool) func(*Config) {
 	}
 }
 
+// WithRecoverPanics sets the RecoverPanics field of the Config.
+func WithRecoverPanics(recoverPanics bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.RecoverPanics = recoverPanics
+	}
+}
+
+// WithCaptureFilter sets the CaptureFilter field of the Config.
+func WithCaptureFilter(hosts []string, pathPrefixes []string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.CaptureFilter = CaptureFilter{Hosts: hosts, PathPrefixes: pathPrefixes}
+	}
+}
+
 // WithCAFiles sets the CAFiles field of the Config.
 func WithCAFiles(caFiles ...string) func(*Config) {
 	return func(cfg *Config) {
@@ -158,6 +472,65 @@ func WithCAFiles(caFiles ...string) func(*Config) {
 	}
 }
 
+// WithLocalAddr sets the LocalAddr field of the Config.
+func WithLocalAddr(localAddr string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.LocalAddr = localAddr
+	}
+}
+
+// WithDisableCompression sets the DisableCompression field of the Config.
+func WithDisableCompression(disableCompression bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.DisableCompression = disableCompression
+	}
+}
+
+// WithExpectContinueTimeout sets the ExpectContinueTimeout field of the Config.
+func WithExpectContinueTimeout(timeout time.Duration) func(*Config) {
+	return func(cfg *Config) {
+		cfg.ExpectContinueTimeout = timeout
+	}
+}
+
+// WithAPIVersionHeader sets the APIVersionHeader and APIVersion fields of the Config.
+func WithAPIVersionHeader(header, version string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.APIVersionHeader = header
+		cfg.APIVersion = version
+	}
+}
+
+// WithAPIVersionPath sets the APIVersionPath and APIVersion fields of the Config.
+func WithAPIVersionPath(template, version string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.APIVersionPath = template
+		cfg.APIVersion = version
+	}
+}
+
+// WithOnDeprecation sets the OnDeprecation field of the Config.
+func WithOnDeprecation(f func(DeprecationInfo)) func(*Config) {
+	return func(cfg *Config) {
+		cfg.OnDeprecation = f
+	}
+}
+
+// WithRedactJSONPaths sets the RedactJSONPaths field of the Config.
+func WithRedactJSONPaths(paths ...string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.RedactJSONPaths = paths
+	}
+}
+
+// WithBackoffHeader sets the BackoffHeader and BackoffHeaderUnit fields of the Config.
+func WithBackoffHeader(header string, unit time.Duration) func(*Config) {
+	return func(cfg *Config) {
+		cfg.BackoffHeader = header
+		cfg.BackoffHeaderUnit = unit
+	}
+}
+
 // WithClientCertFile sets the ClientCertFile field of the Config.
 func WithClientCertFile(clientCertFile string) func(*Config) {
 	return func(cfg *Config) {
@@ -165,6 +538,16 @@ func WithClientCertFile(clientCertFile string) func(*Config) {
 	}
 }
 
+// WithClientCert adds the named client certificate to the ClientCerts field of the Config.
+func WithClientCert(name, certFile, keyFile string) func(*Config) {
+	return func(cfg *Config) {
+		if cfg.ClientCerts == nil {
+			cfg.ClientCerts = make(map[string]ClientCert)
+		}
+		cfg.ClientCerts[name] = ClientCert{CertFile: certFile, KeyFile: keyFile}
+	}
+}
+
 // WithClientKeyFile sets the ClientKeyFile field of the Config.
 func WithClientKeyFile(clientKeyFile string) func(*Config) {
 	return func(cfg *Config) {
@@ -172,25 +555,299 @@ func WithClientKeyFile(clientKeyFile string) func(*Config) {
 	}
 }
 
+// WithDeniedHeaders sets the DeniedHeaders field of the Config.
+func WithDeniedHeaders(headers ...string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.DeniedHeaders = headers
+	}
+}
+
+// WithHeaderOverrides sets the HeaderOverrides field of the Config.
+func WithHeaderOverrides(headers map[string]string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.HeaderOverrides = headers
+	}
+}
+
+// WithHeaderCase sets the HeaderCase field of the Config.
+func WithHeaderCase(headerCase HeaderCase) func(*Config) {
+	return func(cfg *Config) {
+		cfg.HeaderCase = headerCase
+	}
+}
+
+// WithCircuitBreakerKey sets the CircuitBreakerKey field of the Config.
+func WithCircuitBreakerKey(key BreakerKey) func(*Config) {
+	return func(cfg *Config) {
+		cfg.CircuitBreakerKey = key
+	}
+}
+
+// WithCircuitBreakerKeyFunc sets the CircuitBreakerKeyFunc field of the Config.
+func WithCircuitBreakerKeyFunc(f func(info RequestInfo) string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.CircuitBreakerKeyFunc = f
+	}
+}
+
+// WithOnCircuitStateChange sets the OnCircuitStateChange field of the Config.
+func WithOnCircuitStateChange(f func(key string, from, to gobreaker.State)) func(*Config) {
+	return func(cfg *Config) {
+		cfg.OnCircuitStateChange = f
+	}
+}
+
+// WithRateLimit sets the RateLimitRPS and RateLimitBurst fields of the Config.
+func WithRateLimit(rps float64, burst int) func(*Config) {
+	return func(cfg *Config) {
+		cfg.RateLimitRPS = rps
+		cfg.RateLimitBurst = burst
+	}
+}
+
+// WithRetryPolicy sets the RetryPolicy field of the Config.
+func WithRetryPolicy(policy RetryPolicy) func(*Config) {
+	return func(cfg *Config) {
+		cfg.RetryPolicy = policy
+	}
+}
+
+// WithQueueOn429 sets the QueueOn429 and QueueOn429Wait fields of the Config.
+func WithQueueOn429(wait time.Duration) func(*Config) {
+	return func(cfg *Config) {
+		cfg.QueueOn429 = true
+		cfg.QueueOn429Wait = wait
+	}
+}
+
+// WithHostRateLimit sets the rate limit for the host in the HostRateLimits field of the Config.
+func WithHostRateLimit(host string, rps float64, burst int) func(*Config) {
+	return func(cfg *Config) {
+		if cfg.HostRateLimits == nil {
+			cfg.HostRateLimits = make(map[string]RateLimit)
+		}
+		cfg.HostRateLimits[host] = RateLimit{RPS: rps, Burst: burst}
+	}
+}
+
+// WithIsSuccess sets the IsSuccess field of the Config.
+func WithIsSuccess(isSuccess func(resp *resty.Response) bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.IsSuccess = isSuccess
+	}
+}
+
+// WithJSONDecoding sets the JSONDecoding field of the Config.
+func WithJSONDecoding(decoding JSONDecoding) func(*Config) {
+	return func(cfg *Config) {
+		cfg.JSONDecoding = decoding
+	}
+}
+
+// WithDecoder adds the decoder of the media type to the Decoders field of the Config.
+func WithDecoder(mediaType string, decoder Decoder) func(*Config) {
+	return func(cfg *Config) {
+		if cfg.Decoders == nil {
+			cfg.Decoders = make(map[string]Decoder)
+		}
+		cfg.Decoders[mediaType] = decoder
+	}
+}
+
+// WithRequestEncoding sets the RequestEncoding field of the Config.
+func WithRequestEncoding(encoding string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.RequestEncoding = encoding
+	}
+}
+
+// WithEncoder adds the encoder of the content coding to the Encoders field of the Config.
+func WithEncoder(encoding string, encoder Encoder) func(*Config) {
+	return func(cfg *Config) {
+		if cfg.Encoders == nil {
+			cfg.Encoders = make(map[string]Encoder)
+		}
+		cfg.Encoders[encoding] = encoder
+	}
+}
+
+// WithSingleflight sets the Singleflight field of the Config.
+func WithSingleflight(singleflight bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Singleflight = singleflight
+	}
+}
+
+// WithHTTPCache enables the HTTP cache with the store, nil store means in-memory store.
+func WithHTTPCache(store CacheStore) func(*Config) {
+	return func(cfg *Config) {
+		cfg.HTTPCache = true
+		cfg.CacheStore = store
+	}
+}
+
+// WithMaxRequestSize sets the MaxRequestSize field of the Config.
+func WithMaxRequestSize(size int64) func(*Config) {
+	return func(cfg *Config) {
+		cfg.MaxRequestSize = size
+	}
+}
+
+// WithSlowRequestThreshold sets the SlowRequestThreshold field of the Config.
+func WithSlowRequestThreshold(threshold time.Duration) func(*Config) {
+	return func(cfg *Config) {
+		cfg.SlowRequestThreshold = threshold
+	}
+}
+
+// WithHostRateLimitFunc sets the HostRateLimitFunc field of the Config.
+func WithHostRateLimitFunc(f func(host string) (RateLimit, bool)) func(*Config) {
+	return func(cfg *Config) {
+		cfg.HostRateLimitFunc = f
+	}
+}
+
+// WithMaxConcurrentRequests sets the MaxConcurrentRequests field of the Config.
+func WithMaxConcurrentRequests(maxConcurrentRequests int) func(*Config) {
+	return func(cfg *Config) {
+		cfg.MaxConcurrentRequests = maxConcurrentRequests
+	}
+}
+
+// WithDedupWindow sets the DedupWindow field of the Config.
+func WithDedupWindow(window time.Duration) func(*Config) {
+	return func(cfg *Config) {
+		cfg.DedupWindow = window
+	}
+}
+
+// WithOpenAPISpecFile sets the OpenAPISpecFile field of the Config.
+func WithOpenAPISpecFile(path string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.OpenAPISpecFile = path
+	}
+}
+
+// WithOpenAPIValidator sets the OpenAPIValidator field of the Config.
+func WithOpenAPIValidator(validator *OpenAPIValidator) func(*Config) {
+	return func(cfg *Config) {
+		cfg.OpenAPIValidator = validator
+	}
+}
+
+// WithSLO sets the SLO for the route, use SLOAllRoutes to apply it to every route.
+func WithSLO(route string, slo SLO) func(*Config) {
+	return func(cfg *Config) {
+		if cfg.SLOs == nil {
+			cfg.SLOs = make(map[string]SLO)
+		}
+		cfg.SLOs[route] = slo
+	}
+}
+
+// WithOnSLOViolation sets the OnSLOViolation field of the Config.
+func WithOnSLOViolation(f func(SLOViolation)) func(*Config) {
+	return func(cfg *Config) {
+		cfg.OnSLOViolation = f
+	}
+}
+
+// WithRewriteRules sets the RewriteRules field of the Config.
+func WithRewriteRules(rules ...RewriteRule) func(*Config) {
+	return func(cfg *Config) {
+		cfg.RewriteRules = rules
+	}
+}
+
+// WithMetricsHook sets the MetricsHook field of the Config.
+func WithMetricsHook(hook MetricsHook) func(*Config) {
+	return func(cfg *Config) {
+		cfg.MetricsHook = hook
+	}
+}
+
+// WithMetricsRegisterer sets the MetricsRegisterer field of the Config.
+func WithMetricsRegisterer(reg prometheus.Registerer) func(*Config) {
+	return func(cfg *Config) {
+		cfg.MetricsRegisterer = reg
+	}
+}
+
+// WithContextHeader appends the header with the value of the context key to the ContextHeaders field of the Config.
+func WithContextHeader(header string, key any) func(*Config) {
+	return func(cfg *Config) {
+		cfg.ContextHeaders = append(cfg.ContextHeaders, ContextHeader{Header: header, Key: key})
+	}
+}
+
+// WithMiddlewares appends middlewares to the Middlewares field of the Config.
+func WithMiddlewares(middlewares ...Middleware) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Middlewares = append(cfg.Middlewares, middlewares...)
+	}
+}
+
+// WithAuditSink sets the AuditSink field of the Config.
+func WithAuditSink(sink AuditSink) func(*Config) {
+	return func(cfg *Config) {
+		cfg.AuditSink = sink
+	}
+}
+
 // HTTPAddressRegexp is used to match URLs starting with "http://" or "https://", with an optional "www." prefix.
 var HTTPAddressRegexp = regexp.MustCompile(`^https?:\/\/(www\.)?([-a-zA-Z0-9@:%._\+~#=]{1,256}(\.|:)[a-zA-Z0-9()]{1,5}|:[0-9]{2,5})(/[-a-zA-Z0-9()@:%_\+.~#?&//=]*)*$`)
 
 func (cfg *Config) prepareAndValidate() error {
 	cfg.UserAgent = lang.Check(cfg.UserAgent, defaultUserAgent)
 	cfg.RequestTimeout = lang.Check(cfg.RequestTimeout, defaultRequestTimeout)
+	if cfg.UserAgentProvider == nil && len(cfg.UserAgents) > 0 {
+		cfg.UserAgentProvider = NewRoundRobinUserAgents(cfg.UserAgents...)
+	}
+
+	cfg.ResolveCacheTTL = lang.Check(cfg.ResolveCacheTTL, defaultResolveCacheTTL)
+	if cfg.IsSuccess == nil {
+		cfg.IsSuccess = isSuccessStatus
+	}
 
-	if cfg.BaseURL != "" && !HTTPAddressRegexp.MatchString(cfg.BaseURL) {
+	if cfg.BaseURL != "" && !HTTPAddressRegexp.MatchString(cfg.BaseURL) && !isSRVURL(cfg.BaseURL) {
 		return fmt.Errorf("invalid base url address=%s", cfg.BaseURL)
 	}
 	if cfg.ProxyAddress != "" && !HTTPAddressRegexp.MatchString(cfg.ProxyAddress) {
 		return fmt.Errorf("invalid proxy address=%s", cfg.ProxyAddress)
 	}
+	if cfg.OAuth2TokenURL != "" && !HTTPAddressRegexp.MatchString(cfg.OAuth2TokenURL) {
+		return fmt.Errorf("invalid oauth2 token url address=%s", cfg.OAuth2TokenURL)
+	}
+	if cfg.OAuth2TokenURL != "" && cfg.OAuth2ClientID == "" {
+		return errors.New("oauth2 client id is empty")
+	}
 	if cfg.ClientCertFile != "" && cfg.ClientKeyFile == "" {
 		return errors.New("client key file is empty")
 	}
 	if cfg.ClientKeyFile != "" && cfg.ClientCertFile == "" {
 		return errors.New("client cert file is empty")
 	}
+	for name, cert := range cfg.ClientCerts {
+		if cert.CertFile == "" || cert.KeyFile == "" {
+			return fmt.Errorf("client cert %s: cert and key files are required", name)
+		}
+	}
+	if cfg.APIVersionPath != "" && !strings.Contains(cfg.APIVersionPath, apiVersionPlaceholder) {
+		return fmt.Errorf("api version path=%s has no %s placeholder", cfg.APIVersionPath, apiVersionPlaceholder)
+	}
+	if _, err := newBodyEncoders(cfg.Encoders).get(cfg.RequestEncoding); err != nil {
+		return fmt.Errorf("invalid request encoding: %w", err)
+	}
+	switch cfg.CircuitBreakerKey {
+	case BreakerKeyRoute, BreakerKeyHost, BreakerKeyHostRoute:
+	default:
+		return fmt.Errorf("invalid circuit breaker key=%s", cfg.CircuitBreakerKey)
+	}
+	switch cfg.HeaderCase {
+	case HeaderCaseDefault, HeaderCaseCanonical, HeaderCaseLower:
+	default:
+		return fmt.Errorf("invalid header case=%s", cfg.HeaderCase)
+	}
 	if cfg.Logger == nil {
 		if cfg.Debug {
 			cfg.Logger = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
@@ -206,6 +863,8 @@ func (cfg *Config) prepareAndValidate() error {
 	}
 	cfg.CircuitBreakerTimeout = lang.Check(cfg.CircuitBreakerTimeout, defaultCircuitBreakerTimeout)
 	cfg.CircuitBreakerFailures = lang.Check(cfg.CircuitBreakerFailures, defaultCircuitBreakerFailures)
+	cfg.BackoffHeaderUnit = lang.Check(cfg.BackoffHeaderUnit, time.Second)
+	cfg.QueueOn429Wait = lang.Check(cfg.QueueOn429Wait, defaultQueueOn429Wait)
 
 	return nil
 }
@@ -213,9 +872,14 @@ func (cfg *Config) prepareAndValidate() error {
 // ResponseMapForTest is a map that contains functions that will be used to generate responses for tests.
 type ResponseMapForTest map[string]func(context.Context, *http.Request) (any, error)
 
+// ResponseFuncForTest is a function that generates a response for a single path of the test server.
+type ResponseFuncForTest func(context.Context, *http.Request) (any, error)
+
 // GetConfigForTest returns a new Config with the server address set to a test server that will be closed when the given context is closed.
 // The requests counter will be increased every time a request is made to the test server.
 // The responses will be generated by the functions in the response map.
+// If a function returns one of the errors from ErrorMapping, the server responds with the matching status code,
+// otherwise it responds with 500 Internal Server Error.
 func GetConfigForTest(ctx context.Context, requestCounter *atomic.Int64, responseMap ResponseMapForTest) Config {
 	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		requestCounter.Add(1)
@@ -226,7 +890,7 @@ func GetConfigForTest(ctx context.Context, requestCounter *atomic.Int64, respons
 		if f, ok := responseMap[req.URL.Path]; ok {
 			var err error
 			if out, err = f(ctx, req); err != nil {
-				http.Error(rw, err.Error(), http.StatusInternalServerError)
+				http.Error(rw, err.Error(), statusCodeForTest(err))
 				return
 			}
 		}
@@ -46,6 +46,15 @@ func TestConfig_WithAuthToken(t *testing.T) {
 	assert.Equal(t, "my-token", config.AuthToken)
 }
 
+func TestConfig_WithBasicAuth(t *testing.T) {
+	config := cliex.Config{}
+	assert.Empty(t, config.BasicAuthUser)
+
+	cliex.WithBasicAuth("user", "pass")(&config)
+	assert.Equal(t, "user", config.BasicAuthUser)
+	assert.Equal(t, "pass", config.BasicAuthPass)
+}
+
 func TestConfig_WithProxyAddress(t *testing.T) {
 	config := cliex.Config{}
 	assert.Empty(t, config.ProxyAddress)
@@ -165,3 +174,14 @@ func (l restyLogger) Warnf(format string, v ...any) {
 func (l restyLogger) Errorf(format string, v ...any) {
 	l.l.Error(fmt.Sprintf(format, v...))
 }
+
+func TestConfig_WithUserAgents(t *testing.T) {
+	config := cliex.Config{}
+	assert.Nil(t, config.UserAgents)
+
+	cliex.WithUserAgents("agent-1", "agent-2")(&config)
+	assert.Equal(t, []string{"agent-1", "agent-2"}, config.UserAgents)
+
+	cliex.WithUserAgentProvider(cliex.UserAgentFunc(func() string { return "agent" }))(&config)
+	assert.Equal(t, "agent", config.UserAgentProvider.UserAgent())
+}
@@ -0,0 +1,212 @@
+package cliex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConfigFileEnv is the environment variable with the path to the profiles file for NewFromProfile.
+	ConfigFileEnv = "CLIEX_CONFIG_FILE"
+	// ProfileEnv is the environment variable with the profile name for NewFromProfile if the name is empty.
+	ProfileEnv = "CLIEX_PROFILE"
+	// DefaultConfigFile is the profiles file that is used by NewFromProfile if ConfigFileEnv is not set.
+	DefaultConfigFile = "cliex.yaml"
+
+	profileExtendsKey = "extends"
+)
+
+// ErrUnknownProfile is returned when the profile is missing in the profiles file.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// NewFromProfile returns a new HTTP client with the config of the profile from the profiles file,
+// see LoadProfile. The file is DefaultConfigFile or the file from ConfigFileEnv environment variable.
+// If the name is empty, the profile from ProfileEnv environment variable is used.
+// With* options are applied after the profile and environment overrides.
+func NewFromProfile(name string, optsFuncs ...func(*Config)) (*HTTP, error) {
+	path := os.Getenv(ConfigFileEnv)
+	if path == "" {
+		path = DefaultConfigFile
+	}
+	if name == "" {
+		name = os.Getenv(ProfileEnv)
+	}
+	cfg, err := LoadProfile(path, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, optsFunc := range optsFuncs {
+		optsFunc(&cfg)
+	}
+	return NewWithConfig(cfg)
+}
+
+// LoadProfile reads the config of the profile from the YAML (or JSON) file with profiles by their names,
+// e.g. dev, stage and prod. A profile inherits fields of the profile from its "extends" key and overrides them,
+// nested maps are merged. Environment variables from env tags of Config (e.g. CLIEX_BASE_URL)
+// override values from the file.
+//
+//	base:
+//	  request_timeout: 10s
+//	prod:
+//	  extends: base
+//	  base_url: https://api.example.com
+func LoadProfile(path, name string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read profiles file: %w", err)
+	}
+	return ParseProfile(data, name)
+}
+
+// ParseProfile returns the config of the profile from the YAML (or JSON) data with profiles, see LoadProfile.
+func ParseProfile(data []byte, name string) (Config, error) {
+	var profiles map[string]map[string]any
+	if err := yaml.Unmarshal(data, &profiles); err != nil {
+		return Config{}, fmt.Errorf("parse profiles: %w", err)
+	}
+
+	merged, err := resolveProfile(profiles, name, nil)
+	if err != nil {
+		return Config{}, err
+	}
+	raw, err := yaml.Marshal(merged)
+	if err != nil {
+		return Config{}, fmt.Errorf("profile %s: %w", name, err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return Config{}, fmt.Errorf("profile %s: %w", name, err)
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func resolveProfile(profiles map[string]map[string]any, name string, chain []string) (map[string]any, error) {
+	for _, n := range chain {
+		if n == name {
+			return nil, fmt.Errorf("profile %s: cyclic extends %s", chain[0], strings.Join(append(chain, name), " -> "))
+		}
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+	}
+
+	out := make(map[string]any, len(profile))
+	if parent, ok := profile[profileExtendsKey]; ok {
+		parentName, ok := parent.(string)
+		if !ok {
+			return nil, fmt.Errorf("profile %s: extends must be a string", name)
+		}
+		base, err := resolveProfile(profiles, parentName, append(chain, name))
+		if err != nil {
+			return nil, err
+		}
+		out = base
+	}
+	for k, v := range profile {
+		if k != profileExtendsKey {
+			out[k] = mergeProfileValue(out[k], v)
+		}
+	}
+	return out, nil
+}
+
+// mergeProfileValue merges nested maps of the parent and the child profiles, other values are replaced.
+func mergeProfileValue(parent, child any) any {
+	parentMap, ok1 := parent.(map[string]any)
+	childMap, ok2 := child.(map[string]any)
+	if !ok1 || !ok2 {
+		return child
+	}
+	out := make(map[string]any, len(parentMap)+len(childMap))
+	for k, v := range parentMap {
+		out[k] = v
+	}
+	for k, v := range childMap {
+		out[k] = mergeProfileValue(out[k], v)
+	}
+	return out
+}
+
+// applyEnv overrides fields of the config with values of environment variables from env tags.
+// Lists are comma separated.
+func applyEnv(cfg *Config) error {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := range t.NumField() {
+		key := t.Field(i).Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		if err := setEnvValue(v.Field(i), value); err != nil {
+			return fmt.Errorf("parse %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func setEnvValue(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Uint32:
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
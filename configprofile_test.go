@@ -0,0 +1,80 @@
+package cliex_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProfiles = `
+base:
+  request_timeout: 10s
+  user_agent: app/1.0
+  host_rate_limits:
+    api.example.com: {rps: 10, burst: 1}
+stage:
+  extends: base
+  base_url: https://stage.example.com
+  insecure: true
+prod:
+  extends: base
+  base_url: https://api.example.com
+  ca_files: [ca.pem]
+  host_rate_limits:
+    auth.example.com: {rps: 1, burst: 1}
+loop:
+  extends: loop2
+loop2:
+  extends: loop
+`
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cliex.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testProfiles), 0o600))
+
+	cfg, err := cliex.LoadProfile(path, "stage")
+	require.NoError(t, err)
+	assert.Equal(t, "https://stage.example.com", cfg.BaseURL)
+	assert.Equal(t, 10*time.Second, cfg.RequestTimeout)
+	assert.Equal(t, "app/1.0", cfg.UserAgent)
+	assert.True(t, cfg.Insecure)
+
+	cfg, err = cliex.LoadProfile(path, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com", cfg.BaseURL)
+	assert.False(t, cfg.Insecure)
+	assert.Equal(t, []string{"ca.pem"}, cfg.CAFiles)
+	assert.Equal(t, map[string]cliex.RateLimit{
+		"api.example.com":  {RPS: 10, Burst: 1},
+		"auth.example.com": {RPS: 1, Burst: 1},
+	}, cfg.HostRateLimits)
+
+	t.Setenv("CLIEX_BASE_URL", "https://override.example.com")
+	t.Setenv("CLIEX_REQUEST_TIMEOUT", "3s")
+	t.Setenv("CLIEX_CA_FILES", "a.pem, b.pem")
+	t.Setenv("CLIEX_RATE_LIMIT_RPS", "2.5")
+	cfg, err = cliex.LoadProfile(path, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "https://override.example.com", cfg.BaseURL)
+	assert.Equal(t, 3*time.Second, cfg.RequestTimeout)
+	assert.Equal(t, []string{"a.pem", "b.pem"}, cfg.CAFiles)
+	assert.Equal(t, 2.5, cfg.RateLimitRPS)
+
+	_, err = cliex.LoadProfile(path, "dev")
+	require.ErrorIs(t, err, cliex.ErrUnknownProfile)
+
+	_, err = cliex.LoadProfile(path, "loop")
+	require.ErrorContains(t, err, "cyclic")
+
+	t.Setenv(cliex.ConfigFileEnv, path)
+	t.Setenv(cliex.ProfileEnv, "stage")
+	client, err := cliex.NewFromProfile("", cliex.WithUserAgent("app/2.0"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://override.example.com", client.C().BaseURL)
+	assert.Equal(t, "app/2.0", client.C().Header.Get("User-Agent"))
+}
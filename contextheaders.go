@@ -0,0 +1,61 @@
+package cliex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ContextHeader is the header that is set on every request from the value of the key in the request context,
+// e.g. the auth subject, the locale or feature flags, see Config.ContextHeaders.
+type ContextHeader struct {
+	// Header is the name of the header, e.g. "X-Locale".
+	Header string
+
+	// Key is the key of the value in context.Context.
+	Key any
+
+	// Value returns the value of the header from the context value, the header is not set if it returns empty string.
+	// Default is the string, the result of String method for fmt.Stringer or fmt.Sprint for other types.
+	Value func(v any) string
+}
+
+// value returns the value of the header from the context value.
+func (h ContextHeader) value(v any) string {
+	if h.Value != nil {
+		return h.Value(v)
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// resolve returns the value of the header from the context, empty string means the header is not set.
+func (h ContextHeader) resolve(ctx context.Context) string {
+	if h.Header == "" {
+		return ""
+	}
+	v := ctx.Value(h.Key)
+	if v == nil {
+		return ""
+	}
+	return h.value(v)
+}
+
+// applyContextHeaders sets headers from values of the request context, headers that are set in the request are kept.
+func applyContextHeaders(headers []ContextHeader, req *http.Request) {
+	ctx := req.Context()
+	for _, h := range headers {
+		if h.Header == "" || getHeader(req.Header, h.Header) != "" {
+			continue
+		}
+		if value := h.resolve(ctx); value != "" {
+			req.Header.Set(h.Header, value)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	localeKey  struct{}
+	subjectKey struct{}
+	flagsKey   struct{}
+)
+
+func TestHTTP_ContextHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Locale") + "|" + r.Header.Get("X-Subject") + "|" + r.Header.Get("X-Flags")))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL),
+		cliex.WithContextHeader("X-Locale", localeKey{}),
+		cliex.WithContextHeader("X-Subject", subjectKey{}),
+		func(cfg *cliex.Config) {
+			cfg.ContextHeaders = append(cfg.ContextHeaders, cliex.ContextHeader{
+				Header: "X-Flags",
+				Key:    flagsKey{},
+				Value:  func(v any) string { return strings.Join(v.([]string), ",") },
+			})
+		})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), localeKey{}, "en-US")
+	ctx = context.WithValue(ctx, subjectKey{}, 42)
+	ctx = context.WithValue(ctx, flagsKey{}, []string{"beta", "dark"})
+
+	resp, err := client.Get(ctx, "/")
+	require.NoError(t, err)
+	assert.Equal(t, "en-US|42|beta,dark", resp.String())
+
+	resp, err = client.Request(ctx, "/", cliex.RequestOpts{Headers: map[string]string{"X-Locale": "de-DE"}})
+	require.NoError(t, err)
+	assert.Equal(t, "de-DE|42|beta,dark", resp.String())
+
+	resp, err = client.Get(context.Background(), "/")
+	require.NoError(t, err)
+	assert.Equal(t, "||", resp.String())
+}
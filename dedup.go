@@ -0,0 +1,136 @@
+package cliex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ErrDuplicateRequest is matched by DuplicateRequestError with errors.Is.
+var ErrDuplicateRequest = errors.New("duplicate request")
+
+// DuplicateRequestError is returned when an identical mutating request was sent within Config.DedupWindow.
+type DuplicateRequestError struct {
+	// Method is the HTTP method of the request.
+	Method string
+	// URL is the URL of the request with applied path params and query.
+	URL string
+	// Previous is the time when the identical request was sent.
+	Previous time.Time
+}
+
+// Error returns the description of the duplicate.
+func (e *DuplicateRequestError) Error() string {
+	return fmt.Sprintf("duplicate %s %s request, previous was sent %s ago", e.Method, e.URL, time.Since(e.Previous).Round(time.Millisecond))
+}
+
+// Is reports whether the target is ErrDuplicateRequest.
+func (e *DuplicateRequestError) Is(target error) bool {
+	return target == ErrDuplicateRequest
+}
+
+// dedupWindow rejects identical mutating requests (same method, URL, hash of the body, form data and files,
+// credentials and values of Config.ContextHeaders) sent within the window.
+type dedupWindow struct {
+	window time.Duration
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+func newDedupWindow(window time.Duration) *dedupWindow {
+	if window <= 0 {
+		return nil
+	}
+	return &dedupWindow{window: window, sent: make(map[string]time.Time)}
+}
+
+// acquire registers the request and returns DuplicateRequestError if the identical request was sent within the window.
+// It returns an empty key if the request is not checked: it is not mutating or its body or multipart parts are streams.
+func (d *dedupWindow) acquire(ctx context.Context, url string, opts RequestOpts, contextHeaders []ContextHeader) (string, error) {
+	if d == nil || !isMutating(opts.Method) || opts.BodyReader != nil || opts.GetBody != nil || len(opts.Multipart) > 0 {
+		return "", nil
+	}
+	url = requestURLKey(url, opts)
+
+	bodyHash, err := hashBody(opts)
+	if err != nil {
+		return "", fmt.Errorf("hash body: %w", err)
+	}
+	// Identical requests of different callers are not duplicates
+	key := opts.Method + " " + url + " " + bodyHash + credentialsKey(ctx, opts, contextHeaders)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if prev, ok := d.sent[key]; ok && now.Sub(prev) < d.window {
+		return "", &DuplicateRequestError{Method: opts.Method, URL: url, Previous: prev}
+	}
+	for k, t := range d.sent {
+		if now.Sub(t) >= d.window {
+			delete(d.sent, k)
+		}
+	}
+	d.sent[key] = now
+
+	return key, nil
+}
+
+// release forgets the request, it is used when the request failed and can be sent again.
+func (d *dedupWindow) release(key string) {
+	if d == nil || key == "" {
+		return
+	}
+	d.mu.Lock()
+	delete(d.sent, key)
+	d.mu.Unlock()
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// hashBody returns the hash of the body, form data and paths of files of the request.
+func hashBody(opts RequestOpts) (string, error) {
+	var data []byte
+	switch b := opts.Body.(type) {
+	case nil:
+	case []byte:
+		data = b
+	case string:
+		data = []byte(b)
+	default:
+		var err error
+		data, err = jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(b)
+		if err != nil {
+			return "", err
+		}
+	}
+	h := sha256.New()
+	h.Write(data)
+	if len(opts.FormData) > 0 || len(opts.Files) > 0 {
+		form := make(neturl.Values, len(opts.FormData)+len(opts.Files))
+		for k, v := range opts.FormData {
+			form.Set("form:"+k, v)
+		}
+		for k, v := range opts.Files {
+			form.Set("file:"+k, v)
+		}
+		// Encode sorts the form by keys
+		h.Write([]byte("\n" + form.Encode()))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,131 @@
+package cliex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_DedupWindow(t *testing.T) {
+	var hits atomic.Int64
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/fail" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:     mockServer.URL,
+		DedupWindow: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	order := map[string]any{"id": 1}
+
+	_, err = client.Post(ctx, "/orders", order)
+	require.NoError(t, err)
+
+	_, err = client.Post(ctx, "/orders", order)
+	assert.ErrorIs(t, err, cliex.ErrDuplicateRequest)
+	var dupErr *cliex.DuplicateRequestError
+	require.True(t, errors.As(err, &dupErr))
+	assert.Equal(t, http.MethodPost, dupErr.Method)
+
+	// Different body and GET requests are not deduplicated
+	_, err = client.Post(ctx, "/orders", map[string]any{"id": 2})
+	assert.NoError(t, err)
+	for range 2 {
+		_, err = client.Get(ctx, "/orders")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int64(4), hits.Load())
+
+	// Failed requests can be sent again
+	for range 2 {
+		_, err = client.Post(ctx, "/fail", order)
+		assert.ErrorContains(t, err, "bad request")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	_, err = client.Post(ctx, "/orders", order)
+	assert.NoError(t, err)
+}
+
+func TestHTTP_DedupWindowForm(t *testing.T) {
+	var hits atomic.Int64
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:     mockServer.URL,
+		DedupWindow: time.Minute,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	form := func(id string) cliex.RequestOpts {
+		return cliex.RequestOpts{Method: http.MethodPost, FormData: map[string]string{"id": id}}
+	}
+	_, err = client.Request(ctx, "/orders", form("1"))
+	require.NoError(t, err)
+	_, err = client.Request(ctx, "/orders", form("2"))
+	require.NoError(t, err)
+	_, err = client.Request(ctx, "/orders", form("1"))
+	assert.ErrorIs(t, err, cliex.ErrDuplicateRequest)
+
+	// Streamed multipart parts are not deduplicated
+	for range 2 {
+		_, err = client.Request(ctx, "/upload", cliex.RequestOpts{
+			Method:    http.MethodPost,
+			Multipart: []cliex.MultipartField{{Name: "file", FileName: "a.txt", Reader: strings.NewReader("a")}},
+		})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(4), hits.Load())
+}
+
+func TestHTTP_DedupWindowCallers(t *testing.T) {
+	var hits atomic.Int64
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:        mockServer.URL,
+		DedupWindow:    time.Minute,
+		ContextHeaders: []cliex.ContextHeader{{Header: "X-Subject", Key: subjectKey{}}},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	order := map[string]any{"id": 1}
+
+	// Identical requests with different credentials or context header values are not duplicates
+	for _, token := range []string{"alice", "bob"} {
+		_, err = client.Request(ctx, "/orders", cliex.RequestOpts{Method: http.MethodPost, Body: order, AuthToken: token})
+		require.NoError(t, err)
+	}
+	for _, subject := range []string{"alice", "bob"} {
+		_, err = client.Post(context.WithValue(ctx, subjectKey{}, subject), "/orders", order)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(4), hits.Load())
+
+	_, err = client.Post(context.WithValue(ctx, subjectKey{}, "alice"), "/orders", order)
+	assert.ErrorIs(t, err, cliex.ErrDuplicateRequest)
+}
@@ -0,0 +1,129 @@
+package cliex
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	defaultClient   atomic.Pointer[HTTP]
+	defaultClientMu sync.Mutex
+)
+
+// Default returns the default HTTP client that is used by package-level functions.
+// It is lazily created with an empty Config on first use, so you should provide full URL in requests.
+func Default() *HTTP {
+	if cli := defaultClient.Load(); cli != nil {
+		return cli
+	}
+
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+
+	if cli := defaultClient.Load(); cli != nil {
+		return cli
+	}
+	cli := MustNew()
+	defaultClient.Store(cli)
+
+	return cli
+}
+
+// SetDefault replaces the default HTTP client with a new one inited with provided config.
+func SetDefault(cfg Config) error {
+	cli, err := NewWithConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+
+	defaultClient.Store(cli)
+	return nil
+}
+
+// SetDefaultClient replaces the default HTTP client with the provided one.
+func SetDefaultClient(cli *HTTP) {
+	if cli == nil {
+		return
+	}
+
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+
+	defaultClient.Store(cli)
+}
+
+// Request makes HTTP request with the given options using the default client.
+func Request(ctx context.Context, url string, opts RequestOpts) (*resty.Response, error) {
+	return Default().Request(ctx, url, opts)
+}
+
+// Req performs request with method to the URL using the default client and returns response.
+func Req(ctx context.Context, method string, url string, requestAndResponseBody ...any) (*resty.Response, error) {
+	return Default().Req(ctx, method, url, requestAndResponseBody...)
+}
+
+// Get performs GET request to the URL using the default client and returns response.
+func Get(ctx context.Context, url string, responseBody ...any) (*resty.Response, error) {
+	return Default().Get(ctx, url, responseBody...)
+}
+
+// GetQ performs GET request to the URL with query using the default client and returns response.
+func GetQ(ctx context.Context, url string, responseBody any, queryPairs ...string) (*resty.Response, error) {
+	return Default().GetQ(ctx, url, responseBody, queryPairs...)
+}
+
+// GetString performs GET request to the URL using the default client and returns response body as a string.
+func GetString(ctx context.Context, url string) (string, error) {
+	return Default().GetString(ctx, url)
+}
+
+// GetJSONMap performs GET request to the URL using the default client and returns response body decoded from JSON to a map.
+func GetJSONMap(ctx context.Context, url string) (map[string]any, error) {
+	return Default().GetJSONMap(ctx, url)
+}
+
+// Post performs POST request to the URL using the default client and returns response.
+func Post(ctx context.Context, url string, requestBody any, responseBody ...any) (*resty.Response, error) {
+	return Default().Post(ctx, url, requestBody, responseBody...)
+}
+
+// PostQ performs POST request to the URL with query using the default client and returns response.
+func PostQ(ctx context.Context, url string, requestBody any, responseBody any, queryPairs ...string) (*resty.Response, error) {
+	return Default().PostQ(ctx, url, requestBody, responseBody, queryPairs...)
+}
+
+// Put performs PUT request to the URL using the default client and returns response.
+func Put(ctx context.Context, url string, requestBody any, responseBody ...any) (*resty.Response, error) {
+	return Default().Put(ctx, url, requestBody, responseBody...)
+}
+
+// PutQ performs PUT request to the URL with query using the default client and returns response.
+func PutQ(ctx context.Context, url string, requestBody any, responseBody any, queryPairs ...string) (*resty.Response, error) {
+	return Default().PutQ(ctx, url, requestBody, responseBody, queryPairs...)
+}
+
+// Patch performs PATCH request to the URL using the default client and returns response.
+func Patch(ctx context.Context, url string, requestBody any, responseBody ...any) (*resty.Response, error) {
+	return Default().Patch(ctx, url, requestBody, responseBody...)
+}
+
+// PatchQ performs PATCH request to the URL with query using the default client and returns response.
+func PatchQ(ctx context.Context, url string, requestBody any, responseBody any, queryPairs ...string) (*resty.Response, error) {
+	return Default().PatchQ(ctx, url, requestBody, responseBody, queryPairs...)
+}
+
+// Delete performs DELETE request to the URL using the default client and returns response.
+func Delete(ctx context.Context, url string, responseBody ...any) (*resty.Response, error) {
+	return Default().Delete(ctx, url, responseBody...)
+}
+
+// DeleteQ performs DELETE request to the URL with query using the default client and returns response.
+func DeleteQ(ctx context.Context, url string, responseBody any, queryPairs ...string) (*resty.Response, error) {
+	return Default().DeleteQ(ctx, url, responseBody, queryPairs...)
+}
@@ -0,0 +1,48 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultClient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.NotNil(t, cliex.Default())
+	assert.Equal(t, cliex.Default(), cliex.Default())
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/test": func(ctx context.Context, req *http.Request) (any, error) {
+			return map[string]string{"key": "value"}, nil
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	var responseBody map[string]string
+	resp, err := cliex.Get(ctx, cfg.BaseURL+"/test", &responseBody)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "value", responseBody["key"])
+
+	prev := cliex.Default()
+	defer cliex.SetDefaultClient(prev)
+
+	require.NoError(t, cliex.SetDefault(cfg))
+	assert.NotEqual(t, prev, cliex.Default())
+
+	responseBody = nil
+	_, err = cliex.Post(ctx, "/test", map[string]string{"a": "b"}, &responseBody)
+	require.NoError(t, err)
+	assert.Equal(t, "value", responseBody["key"])
+
+	assert.Error(t, cliex.SetDefault(cliex.Config{BaseURL: "invalid"}))
+	assert.Equal(t, int64(2), requestCounter.Load())
+}
@@ -0,0 +1,339 @@
+package cliex
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ExtractMode is the mode of extraction of a downloaded archive.
+type ExtractMode string
+
+const (
+	// ExtractNone means the downloaded file is saved as is.
+	ExtractNone ExtractMode = ""
+	// ExtractAuto detects the archive format by the file name and the Content-Type of the response.
+	// File is saved as is if the format is unknown.
+	ExtractAuto ExtractMode = "auto"
+	// ExtractGzip decompresses a single gzip file.
+	ExtractGzip ExtractMode = "gzip"
+	// ExtractZip extracts a zip archive.
+	ExtractZip ExtractMode = "zip"
+	// ExtractTar extracts a tar archive.
+	ExtractTar ExtractMode = "tar"
+	// ExtractTarGz extracts a gzipped tar archive.
+	ExtractTarGz ExtractMode = "tar.gz"
+)
+
+// ErrUnsafeArchivePath is returned when an archive entry points outside of the extraction directory (zip slip).
+var ErrUnsafeArchivePath = errors.New("unsafe path in archive")
+
+// ErrArchiveTooLarge is returned when the extracted content exceeds DownloadOpts.MaxExtractSize.
+var ErrArchiveTooLarge = errors.New("extracted archive is too large")
+
+// DownloadOpts is the options for downloading files.
+type DownloadOpts struct {
+	// RequestOpts is the options of the download request, OutputPath is ignored.
+	RequestOpts
+
+	// Extract is the mode of extraction of the downloaded archive.
+	// Default is ExtractNone, the file is saved as is.
+	Extract ExtractMode
+
+	// ExtractDir is the directory where the archive will be extracted.
+	// Default is the directory of the downloaded file.
+	ExtractDir string
+
+	// KeepArchive is whether to keep the downloaded archive after extraction.
+	// Default is false, archive is deleted after successful extraction.
+	KeepArchive bool
+
+	// MaxExtractSize is the maximum total size of extracted files in bytes, it protects from archive bombs.
+	// Default is 0, means no limit.
+	MaxExtractSize int64
+
+	// Resume is whether to download the file to <path>.part and keep it after failures, so the next call
+	// (or the retry within RetryCount) continues the download from the end of the partial file with Range request.
+	// The partial file is used only if the server confirms with ETag or Last-Modified that the resource is not changed.
+	// The content is requested without compression.
+	Resume bool
+
+	// Checksum is the expected checksum "<algorithm>:<hex>" of the downloaded file, e.g. "sha256:9f86d08...".
+	// Supported algorithms are md5, sha1, sha256 and sha512. The file is deleted if the checksum doesn't match.
+	Checksum string
+}
+
+// DownloadFile downloads the file from the BaseURL + URL and saves it to the path.
+// If Resume is set, the partially downloaded file is continued with Range request.
+// If Checksum is set, the checksum of the file is verified. If Extract mode is set,
+// the downloaded archive is extracted to ExtractDir.
+func (c *HTTP) DownloadFile(ctx context.Context, url, path string, opts DownloadOpts) (*resty.Response, error) {
+	if path == "" {
+		return nil, errors.New("empty download path")
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create download dir: %w", err)
+		}
+	}
+
+	if opts.Checksum != "" {
+		if _, _, err := parseChecksum(opts.Checksum); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		resp *resty.Response
+		err  error
+	)
+	if opts.Resume {
+		resp, err = c.downloadResumable(ctx, url, path, opts)
+	} else {
+		reqOpts := opts.RequestOpts
+		reqOpts.OutputPath = path
+		resp, err = c.Request(ctx, url, reqOpts)
+	}
+	if err != nil {
+		return resp, err
+	}
+	if opts.Checksum != "" {
+		if err := verifyChecksum(path, opts.Checksum); err != nil {
+			return resp, err
+		}
+	}
+
+	mode := opts.Extract
+	if mode == ExtractAuto {
+		mode = detectExtractMode(path, resp.Header().Get("Content-Type"))
+	}
+	if mode == ExtractNone || mode == ExtractAuto {
+		return resp, nil
+	}
+
+	dir := opts.ExtractDir
+	if dir == "" {
+		dir = filepath.Dir(path)
+	}
+	if err := extractArchive(path, dir, mode, opts.MaxExtractSize); err != nil {
+		return resp, fmt.Errorf("extract %s: %w", path, err)
+	}
+	if !opts.KeepArchive {
+		if err := os.Remove(path); err != nil {
+			return resp, fmt.Errorf("remove archive: %w", err)
+		}
+	}
+
+	return resp, nil
+}
+
+func detectExtractMode(path, contentType string) ExtractMode {
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ExtractTarGz
+	case strings.HasSuffix(name, ".zip"):
+		return ExtractZip
+	case strings.HasSuffix(name, ".tar"):
+		return ExtractTar
+	case strings.HasSuffix(name, ".gz"):
+		return ExtractGzip
+	}
+
+	switch ExtensionByMIMEType(contentType) {
+	case ".zip":
+		return ExtractZip
+	case ".tar":
+		return ExtractTar
+	case ".gz":
+		return ExtractGzip
+	}
+	if strings.Contains(contentType, "x-gzip") {
+		return ExtractGzip
+	}
+
+	return ExtractNone
+}
+
+func extractArchive(path, dir string, mode ExtractMode, maxSize int64) error {
+	budget := &extractBudget{max: maxSize}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	if mode == ExtractZip {
+		return extractZip(path, dir, budget)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch mode {
+	case ExtractTar:
+		return extractTar(f, dir, budget)
+
+	case ExtractTarGz, ExtractGzip:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		if mode == ExtractTarGz {
+			return extractTar(gz, dir, budget)
+		}
+
+		name := gz.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		target, err := safeArchivePath(dir, filepath.Base(name))
+		if err != nil {
+			return err
+		}
+		return writeArchiveFile(target, gz, 0o644, budget)
+	}
+
+	return fmt.Errorf("unknown extract mode %q", mode)
+}
+
+func extractZip(path, dir string, budget *extractBudget) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		target, err := safeArchivePath(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.Mode()&os.ModeSymlink != 0 {
+			continue // symlinks in zip are skipped for safety
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeArchiveFile(target, rc, f.Mode().Perm(), budget)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractTar(r io.Reader, dir string, budget *extractBudget) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := safeArchivePath(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(target, tr, os.FileMode(hdr.Mode).Perm(), budget); err != nil {
+				return err
+			}
+		default:
+			// other entry types (symlinks, devices, fifos, hard links) are skipped for safety,
+			// a chain of links may point outside of the directory
+		}
+	}
+}
+
+func writeArchiveFile(target string, r io.Reader, perm os.FileMode, budget *extractBudget) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if perm == 0 {
+		perm = 0o644
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return budget.copy(f, r)
+}
+
+// extractBudget limits the total size of extracted files.
+type extractBudget struct {
+	max  int64
+	used int64
+}
+
+func (b *extractBudget) copy(w io.Writer, r io.Reader) error {
+	if b.max <= 0 {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	n, err := io.Copy(w, io.LimitReader(r, b.max-b.used+1))
+	b.used += n
+	if err != nil {
+		return err
+	}
+	if b.used > b.max {
+		return ErrArchiveTooLarge
+	}
+	return nil
+}
+
+func safeArchivePath(dir, name string) (string, error) {
+	if name == "" || filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchivePath, name)
+	}
+	target := filepath.Join(dir, name)
+	if target == filepath.Clean(dir) || !isInsideDir(dir, target) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchivePath, name)
+	}
+	return target, nil
+}
+
+func isInsideDir(dir, target string) bool {
+	rel, err := filepath.Rel(dir, filepath.Clean(target))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
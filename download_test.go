@@ -0,0 +1,152 @@
+package cliex_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_DownloadFile_Extract(t *testing.T) {
+	files := map[string]string{
+		"bin/app":        "binary",
+		"config/app.yml": "key: value",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/release.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(makeTarGz(t, files))
+	})
+	mux.HandleFunc("/release.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(makeZip(t, files))
+	})
+	mux.HandleFunc("/archive", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", cliex.MIMETypeZIP)
+		_, _ = w.Write(makeZip(t, files))
+	})
+	mux.HandleFunc("/evil.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(makeZip(t, map[string]string{"../evil": "evil"}))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	for _, name := range []string{"release.tar.gz", "release.zip"} {
+		target := filepath.Join(dir, name+"-out")
+		_, err = client.DownloadFile(ctx, "/"+name, filepath.Join(dir, name), cliex.DownloadOpts{
+			Extract:    cliex.ExtractAuto,
+			ExtractDir: target,
+		})
+		require.NoError(t, err, name)
+
+		for path, content := range files {
+			data, err := os.ReadFile(filepath.Join(target, path))
+			require.NoError(t, err, name)
+			assert.Equal(t, content, string(data))
+		}
+		_, err = os.Stat(filepath.Join(dir, name))
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	// Detect by Content-Type and keep archive
+	_, err = client.DownloadFile(ctx, "/archive", filepath.Join(dir, "archive"), cliex.DownloadOpts{
+		Extract:     cliex.ExtractAuto,
+		ExtractDir:  filepath.Join(dir, "archive-out"),
+		KeepArchive: true,
+	})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "archive"))
+	assert.FileExists(t, filepath.Join(dir, "archive-out", "bin", "app"))
+
+	// Without extraction
+	_, err = client.DownloadFile(ctx, "/release.zip", filepath.Join(dir, "raw", "release.zip"), cliex.DownloadOpts{})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "raw", "release.zip"))
+
+	// Zip slip
+	_, err = client.DownloadFile(ctx, "/evil.zip", filepath.Join(dir, "evil", "evil.zip"), cliex.DownloadOpts{
+		Extract: cliex.ExtractZip,
+	})
+	assert.ErrorIs(t, err, cliex.ErrUnsafeArchivePath)
+	assert.NoFileExists(t, filepath.Join(dir, "evil"))
+
+	// Symlinks are skipped, so a chain of links cannot point outside of the directory
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "d", Linkname: ".", Typeflag: tar.TypeSymlink}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "d/e", Linkname: "..", Typeflag: tar.TypeSymlink}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "d/e/evil", Mode: 0o644, Size: 4, Typeflag: tar.TypeReg}))
+	_, err = tw.Write([]byte("evil"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	mux.HandleFunc("/links.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(buf.Bytes())
+	})
+	linksDir := filepath.Join(dir, "links", "out")
+	_, err = client.DownloadFile(ctx, "/links.tar.gz", filepath.Join(dir, "links", "links.tar.gz"), cliex.DownloadOpts{
+		Extract:    cliex.ExtractTarGz,
+		ExtractDir: linksDir,
+	})
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "links", "evil"))
+	assert.FileExists(t, filepath.Join(linksDir, "d", "e", "evil"))
+	info, err := os.Lstat(filepath.Join(linksDir, "d"))
+	require.NoError(t, err)
+	assert.Zero(t, info.Mode()&os.ModeSymlink)
+
+	// Archive bomb protection
+	_, err = client.DownloadFile(ctx, "/release.zip", filepath.Join(dir, "limit", "release.zip"), cliex.DownloadOpts{
+		Extract:        cliex.ExtractZip,
+		MaxExtractSize: 8,
+	})
+	assert.ErrorIs(t, err, cliex.ErrArchiveTooLarge)
+}
+
+func makeTarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,111 @@
+package cliex
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// EncodingIdentity is the content coding that disables compression of the request body, see RequestOpts.ContentEncoding.
+const EncodingIdentity = "identity"
+
+// ErrUnknownEncoding is returned when the content coding of the request body has no encoder in Config.Encoders.
+var ErrUnknownEncoding = errors.New("unknown content encoding")
+
+// Encoder returns the writer that compresses the request body written to it with the content coding
+// (e.g. zstd or snappy) into w. The writer is closed after the whole body is written.
+type Encoder func(w io.Writer) (io.WriteCloser, error)
+
+// defaultEncoders is the encoders of content codings that are supported without registration.
+var defaultEncoders = map[string]Encoder{
+	"gzip": func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	},
+	"deflate": func(w io.Writer) (io.WriteCloser, error) {
+		return zlib.NewWriter(w), nil
+	},
+}
+
+// bodyEncoders is the set of encoders of the client by content coding.
+type bodyEncoders map[string]Encoder
+
+func newBodyEncoders(encoders map[string]Encoder) bodyEncoders {
+	out := make(bodyEncoders, len(defaultEncoders)+len(encoders))
+	for coding, encoder := range defaultEncoders {
+		out[coding] = encoder
+	}
+	for coding, encoder := range encoders {
+		out[strings.ToLower(coding)] = encoder
+	}
+	return out
+}
+
+// get returns the encoder of the content coding, nil encoder means the body is not compressed.
+func (e bodyEncoders) get(coding string) (Encoder, error) {
+	coding = strings.ToLower(strings.TrimSpace(coding))
+	if coding == "" || coding == EncodingIdentity {
+		return nil, nil
+	}
+	encoder, ok := e[coding]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEncoding, coding)
+	}
+	return encoder, nil
+}
+
+// applyContentEncoding compresses the request body with the encoder of the request and sets Content-Encoding header.
+// In-memory bodies are compressed at once and sent with Content-Length, streamed bodies are compressed while they are sent.
+func applyContentEncoding(req *http.Request) error {
+	state := getRequestState(req.Context())
+	if state == nil || state.encoder == nil || req.Body == nil || req.Body == http.NoBody || getHeader(req.Header, "Content-Encoding") != "" {
+		return nil
+	}
+	req.Header.Set("Content-Encoding", state.encoding)
+	req.Header.Del("Content-Length")
+
+	if req.GetBody == nil {
+		body := req.Body
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(encodeBody(pw, body, state.encoder))
+		}()
+		// Size of the compressed body is unknown
+		req.Body, req.ContentLength = pr, 0
+		state.contentLength = 0
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := encodeBody(&buf, req.Body, state.encoder); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
+// encodeBody writes the body compressed by the encoder to w and closes the body.
+func encodeBody(w io.Writer, body io.ReadCloser, encoder Encoder) error {
+	defer body.Close()
+	enc, err := encoder(w)
+	if err != nil {
+		return fmt.Errorf("create encoder: %w", err)
+	}
+	if _, err := io.Copy(enc, body); err != nil {
+		enc.Close()
+		return fmt.Errorf("encode body: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encode body: %w", err)
+	}
+	return nil
+}
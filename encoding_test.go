@@ -0,0 +1,79 @@
+package cliex_test
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upperEncoder is the test content coding that changes the body to upper case.
+type upperEncoder struct {
+	w io.Writer
+}
+
+func (e upperEncoder) Write(p []byte) (int, error) {
+	return e.w.Write([]byte(strings.ToUpper(string(p))))
+}
+
+func (e upperEncoder) Close() error { return nil }
+
+func TestHTTP_RequestEncoding(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = gz
+		}
+		data, _ := io.ReadAll(body)
+		w.Write([]byte(r.Header.Get("Content-Encoding") + "|" + strconv.FormatInt(r.ContentLength, 10) + "|" + string(data)))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithRequestEncoding("gzip"),
+		cliex.WithEncoder("X-Upper", func(w io.Writer) (io.WriteCloser, error) { return upperEncoder{w: w}, nil }))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	payload := strings.Repeat("compressed payload;", 100)
+	resp, err := client.Request(ctx, "/", cliex.RequestOpts{Method: http.MethodPost, Body: payload})
+	require.NoError(t, err)
+	parts := strings.SplitN(resp.String(), "|", 3)
+	assert.Equal(t, "gzip", parts[0])
+	length, err := strconv.Atoi(parts[1])
+	require.NoError(t, err)
+	assert.Positive(t, length)
+	assert.Less(t, length, len(payload))
+	assert.Equal(t, payload, parts[2])
+
+	resp, err = client.Request(ctx, "/", cliex.RequestOpts{Method: http.MethodPost, Body: "plain", ContentEncoding: cliex.EncodingIdentity})
+	require.NoError(t, err)
+	assert.Equal(t, "|5|plain", resp.String())
+
+	resp, err = client.Request(ctx, "/", cliex.RequestOpts{
+		Method:          http.MethodPost,
+		Multipart:       []cliex.MultipartField{{Name: "field", Reader: strings.NewReader("value")}},
+		ContentEncoding: "x-upper",
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.String(), "x-upper|-1|"))
+	assert.Contains(t, resp.String(), "VALUE")
+
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{Method: http.MethodPost, Body: "data", ContentEncoding: "br"})
+	require.ErrorIs(t, err, cliex.ErrUnknownEncoding)
+
+	_, err = cliex.New(cliex.WithRequestEncoding("zstd"))
+	require.ErrorIs(t, err, cliex.ErrUnknownEncoding)
+}
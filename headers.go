@@ -0,0 +1,268 @@
+package cliex
+
+import (
+	"net/http"
+	"strings"
+)
+
+// HeaderCase is the case of header names in outgoing requests.
+type HeaderCase string
+
+const (
+	// HeaderCaseDefault keeps header names as they are set by net/http (canonical for most headers).
+	HeaderCaseDefault HeaderCase = ""
+	// HeaderCaseCanonical converts all header names to the canonical form, e.g. "X-Request-Id".
+	HeaderCaseCanonical HeaderCase = "canonical"
+	// HeaderCaseLower converts header names to lowercase, e.g. "x-request-id". Headers that net/http
+	// writes or reads itself (Host, User-Agent, Accept-Encoding, Content-Length, etc.) stay canonical.
+	HeaderCaseLower HeaderCase = "lower"
+)
+
+// DefaultDeniedHeaders is the list of headers that are managed by the transport and
+// should not be overridden from caller-supplied maps. Use it in Config.DeniedHeaders.
+var DefaultDeniedHeaders = []string{
+	"Host",
+	"Content-Length",
+	"Transfer-Encoding",
+	"Connection",
+	"Keep-Alive",
+	"Upgrade",
+	"Te",
+	"Trailer",
+}
+
+// transportHeaders is the set of headers that net/http writes itself or reads from the request by canonical
+// names (e.g. it adds "Accept-Encoding: gzip" if there is no canonical Accept-Encoding), so they are kept
+// canonical with HeaderCaseLower to not be duplicated or ignored.
+var transportHeaders = headerSet([]string{
+	"Host",
+	"User-Agent",
+	"Accept-Encoding",
+	"Range",
+	"Connection",
+	"Expect",
+	"Upgrade",
+	"Te",
+	"Content-Length",
+	"Transfer-Encoding",
+	"Trailer",
+})
+
+// headerPolicy strips denied headers and applies overrides, names of headers are normalized by headerCaseTransport.
+type headerPolicy struct {
+	denied    map[string]struct{}
+	overrides map[string]string
+}
+
+func newHeaderPolicy(cfg Config) *headerPolicy {
+	if len(cfg.DeniedHeaders) == 0 && len(cfg.HeaderOverrides) == 0 {
+		return nil
+	}
+	return &headerPolicy{
+		denied:    headerSet(cfg.DeniedHeaders),
+		overrides: cfg.HeaderOverrides,
+	}
+}
+
+// filter returns caller-supplied headers without denied ones.
+func (p *headerPolicy) filter(headers map[string]string, extraDenied []string) map[string]string {
+	if len(headers) == 0 || (p == nil && len(extraDenied) == 0) {
+		return headers
+	}
+	var denied map[string]struct{}
+	if p != nil {
+		denied = p.denied
+	}
+	extra := headerSet(extraDenied)
+
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		key := http.CanonicalHeaderKey(k)
+		if _, ok := denied[key]; ok {
+			continue
+		}
+		if _, ok := extra[key]; ok {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// filterValues returns caller-supplied multi-value headers without denied ones.
+func (p *headerPolicy) filterValues(headers http.Header, extraDenied []string) http.Header {
+	if len(headers) == 0 || (p == nil && len(extraDenied) == 0) {
+		return headers
+	}
+	allowed := p.filter(headerKeys(headers), extraDenied)
+
+	out := make(http.Header, len(allowed))
+	for k := range allowed {
+		out[k] = headers[k]
+	}
+	return out
+}
+
+// apply applies overrides to the outgoing request.
+func (p *headerPolicy) apply(req *http.Request) {
+	if p == nil {
+		return
+	}
+	for k, v := range p.overrides {
+		if http.CanonicalHeaderKey(k) == "Host" {
+			req.Host = v
+			continue
+		}
+		deleteHeader(req.Header, k)
+		req.Header[k] = []string{v}
+	}
+}
+
+// headerCaseTransport converts names of headers right before the request is written to the connection,
+// after headers are set by hooks, middlewares, OAuth2 and net/http lookups of the headers are done.
+type headerCaseTransport struct {
+	base    http.RoundTripper
+	convert func(string) string
+}
+
+func newHeaderCaseTransport(base http.RoundTripper, nameCase HeaderCase) http.RoundTripper {
+	switch nameCase {
+	case HeaderCaseLower:
+		return &headerCaseTransport{base: base, convert: lowerHeaderName}
+	case HeaderCaseCanonical:
+		return &headerCaseTransport{base: base, convert: http.CanonicalHeaderKey}
+	}
+	return base
+}
+
+func (t *headerCaseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Header = convertHeaderNames(req.Header, t.convert)
+	return t.base.RoundTrip(out)
+}
+
+func (t *headerCaseTransport) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// lowerHeaderName returns the lowercase name of the header, names of transportHeaders are canonical.
+func lowerHeaderName(name string) string {
+	canonical := http.CanonicalHeaderKey(name)
+	if _, ok := transportHeaders[canonical]; ok {
+		return canonical
+	}
+	return strings.ToLower(name)
+}
+
+func convertHeaderNames(h http.Header, convert func(string) string) http.Header {
+	out := make(http.Header, len(h))
+	for k, v := range h {
+		key := convert(k)
+		out[key] = append(out[key], v...)
+	}
+	return out
+}
+
+// deleteHeader deletes the header in any case.
+func deleteHeader(h http.Header, key string) {
+	for k := range h {
+		if strings.EqualFold(k, key) {
+			delete(h, k)
+		}
+	}
+}
+
+// getHeader returns the first value of the header in any case of its name.
+func getHeader(h http.Header, key string) string {
+	if v := h.Get(key); v != "" {
+		return v
+	}
+	for k, values := range h {
+		if strings.EqualFold(k, key) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// hasHeader returns true if the header is set in the map in any case.
+func hasHeader(headers map[string]string, key string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func headerKeys(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k := range h {
+		out[k] = ""
+	}
+	return out
+}
+
+// SetHeader sets the header of the request replacing all previous values, including ones from HeaderValues.
+func (o *RequestOpts) SetHeader(key, value string) {
+	deleteHeader(o.HeaderValues, key)
+	for k := range o.Headers {
+		if strings.EqualFold(k, key) {
+			delete(o.Headers, k)
+		}
+	}
+	if o.Headers == nil {
+		o.Headers = make(map[string]string)
+	}
+	o.Headers[key] = value
+}
+
+// AddHeader adds the value to the header of the request, existing values are kept.
+func (o *RequestOpts) AddHeader(key, value string) {
+	if o.HeaderValues == nil {
+		o.HeaderValues = make(http.Header)
+	}
+	o.HeaderValues.Add(key, value)
+}
+
+// hasHeader returns true if the header is set in Headers or HeaderValues in any case.
+func (o RequestOpts) hasHeader(key string) bool {
+	if hasHeader(o.Headers, key) {
+		return true
+	}
+	for k := range o.HeaderValues {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerValue returns the value of the header from Headers or HeaderValues in any case,
+// multiple values are joined with a comma.
+func (o RequestOpts) headerValue(key string) string {
+	for k, v := range o.Headers {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	for k, values := range o.HeaderValues {
+		if strings.EqualFold(k, key) {
+			return strings.Join(values, ", ")
+		}
+	}
+	return ""
+}
+
+func headerSet(keys []string) map[string]struct{} {
+	if len(keys) == 0 {
+		return nil
+	}
+	out := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		out[http.CanonicalHeaderKey(k)] = struct{}{}
+	}
+	return out
+}
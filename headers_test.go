@@ -0,0 +1,166 @@
+package cliex_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := cliex.NewRequestRecorderForTest()
+	var requestCounter atomic.Int64
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, cliex.ResponseMapForTest{
+		"/test": cliex.CaptureForTest(rec, nil),
+	})
+	cfg.DeniedHeaders = cliex.DefaultDeniedHeaders
+	cfg.HeaderOverrides = map[string]string{"X-Service": "cliex"}
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	_, err = client.Request(ctx, "/test", cliex.RequestOpts{
+		Headers: map[string]string{
+			"host":           "evil.example.com",
+			"Content-Length": "100500",
+			"X-Service":      "caller",
+			"X-Secret":       "secret",
+			"X-Custom":       "value",
+		},
+		DeniedHeaders: []string{"x-secret"},
+	})
+	require.NoError(t, err)
+
+	req, ok := rec.Last()
+	require.True(t, ok)
+	assert.Equal(t, "cliex", req.Header.Get("X-Service"))
+	assert.Equal(t, "value", req.Header.Get("X-Custom"))
+	assert.Empty(t, req.Header.Get("X-Secret"))
+
+	_, err = cliex.New(cliex.WithHeaderCase("upper"))
+	assert.Error(t, err)
+}
+
+func TestHeaderCaseLower(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	headerLines := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var lines []string
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			lines = append(lines, strings.TrimSpace(line))
+		}
+		headerLines <- lines
+		_, _ = conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+	}()
+
+	client, err := cliex.New(
+		cliex.WithBaseURL("http://"+ln.Addr().String()),
+		cliex.WithHeaderCase(cliex.HeaderCaseLower),
+		cliex.WithRewriteRules(cliex.RewriteRule{SetHeaders: map[string]string{"X-Gw": "1"}}),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Request(context.Background(), "/", cliex.RequestOpts{
+		Method:           http.MethodGet,
+		Headers:          map[string]string{"X-Request-Id": "1"},
+		IdentityEncoding: true,
+	})
+	require.NoError(t, err)
+
+	lines := <-headerLines
+	assert.Contains(t, lines, "x-request-id: 1")
+	assert.Contains(t, lines, "x-gw: 1")
+	// Headers that net/http writes or reads itself stay canonical
+	assert.Contains(t, lines, "User-Agent: Golang HTTP client")
+	assert.Contains(t, lines, "Accept-Encoding: identity")
+
+	names := map[string]string{}
+	for _, line := range lines[1:] {
+		name, _, _ := strings.Cut(line, ":")
+		key := strings.ToLower(name)
+		assert.NotContains(t, names, key, "duplicate header %s", name)
+		names[key] = name
+	}
+}
+
+func TestHTTP_UserAgentRotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := cliex.NewRequestRecorderForTest()
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/ua": cliex.CaptureForTest(rec, nil),
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+	cfg.UserAgents = []string{"agent-1", "agent-2", "agent-3"}
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	for range 4 {
+		_, err = client.Get(ctx, "/ua")
+		require.NoError(t, err)
+	}
+	_, err = client.Request(ctx, "/ua", cliex.RequestOpts{Headers: map[string]string{"user-agent": "custom"}})
+	require.NoError(t, err)
+
+	var agents []string
+	for _, req := range rec.Requests() {
+		agents = append(agents, req.Header.Get("User-Agent"))
+	}
+	assert.Equal(t, []string{"agent-1", "agent-2", "agent-3", "agent-1", "custom"}, agents)
+}
+
+func TestHTTP_HeaderValues(t *testing.T) {
+	var got http.Header
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL, DeniedHeaders: []string{"X-Internal"}})
+	require.NoError(t, err)
+
+	opts := cliex.RequestOpts{
+		Headers:      map[string]string{"X-Mode": "first"},
+		HeaderValues: http.Header{"X-Internal": {"true"}},
+	}
+	opts.AddHeader("Forwarded", "for=192.0.2.60")
+	opts.AddHeader("Forwarded", "for=198.51.100.17")
+	opts.AddHeader("X-Tag", "a")
+	opts.SetHeader("x-tag", "b")
+
+	_, err = client.Request(context.Background(), "/", opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"for=192.0.2.60", "for=198.51.100.17"}, got.Values("Forwarded"))
+	assert.Equal(t, []string{"b"}, got.Values("X-Tag"))
+	assert.Equal(t, []string{"first"}, got.Values("X-Mode"))
+	assert.Empty(t, got.Values("X-Internal"))
+}
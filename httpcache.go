@@ -0,0 +1,412 @@
+package cliex
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const defaultCacheEntries = 1000
+
+// CachedResponse is the response stored in CacheStore by the HTTP cache, see Config.HTTPCache.
+type CachedResponse struct {
+	// StatusCode is the status code of the response.
+	StatusCode int `json:"status_code"`
+	// Header is the headers of the response, they are updated after the response is revalidated.
+	Header http.Header `json:"header"`
+	// Body is the body of the response.
+	Body []byte `json:"body"`
+	// Vary is the values of request headers listed in Vary header of the response,
+	// the response is used only for requests with the same values.
+	Vary map[string]string `json:"vary,omitempty"`
+	// StoredAt is the time when the response was generated by the server, it is updated after revalidation.
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// CacheStore stores responses of the HTTP cache, e.g. in memory or in Redis.
+// Errors of the store are logged and the request is sent without the cache.
+type CacheStore interface {
+	// Get returns the response by the key, false means there is no response.
+	Get(ctx context.Context, key string) (*CachedResponse, bool, error)
+	// Set stores the response by the key.
+	Set(ctx context.Context, key string, resp *CachedResponse) error
+	// Delete deletes the response by the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryCacheStore is the in-memory CacheStore that evicts the least recently used responses.
+type MemoryCacheStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type memoryCacheEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewMemoryCacheStore returns the in-memory CacheStore with at most maxEntries responses.
+// Default maxEntries (0 or less) is 1000.
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	return &MemoryCacheStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Get returns the response by the key.
+func (s *MemoryCacheStore) Get(_ context.Context, key string) (*CachedResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(elem)
+	return elem.Value.(*memoryCacheEntry).resp, true, nil
+}
+
+// Set stores the response by the key and evicts the least recently used response if the store is full.
+func (s *MemoryCacheStore) Set(_ context.Context, key string, resp *CachedResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		elem.Value.(*memoryCacheEntry).resp = resp
+		s.lru.MoveToFront(elem)
+		return nil
+	}
+	s.entries[key] = s.lru.PushFront(&memoryCacheEntry{key: key, resp: resp})
+	if s.lru.Len() > s.maxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
+
+// Delete deletes the response by the key.
+func (s *MemoryCacheStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.lru.Remove(elem)
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Len returns the number of stored responses.
+func (s *MemoryCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// httpCache is the private HTTP cache (RFC 9111) of GET responses that honors Cache-Control, Expires
+// and revalidates stale responses with ETag and Last-Modified.
+type httpCache struct {
+	store   CacheStore
+	log     Logger
+	results resultDecoder
+}
+
+func newHTTPCache(cfg Config) *httpCache {
+	if !cfg.HTTPCache && cfg.CacheStore == nil {
+		return nil
+	}
+	store := cfg.CacheStore
+	if store == nil {
+		store = NewMemoryCacheStore(0)
+	}
+	return &httpCache{store: store, log: cfg.Logger, results: newResultDecoder(cfg)}
+}
+
+// key returns the key of the request by the full URL, path params, query, credentials of the request and values
+// of Config.ContextHeaders, so the response stored for one caller is not returned to another one.
+// It returns false if the request cannot be cached.
+func (hc *httpCache) key(ctx context.Context, url string, opts RequestOpts, contextHeaders []ContextHeader) (string, bool) {
+	if hc == nil || opts.NoCache || (opts.Method != "" && opts.Method != http.MethodGet) ||
+		opts.OutputPath != "" || opts.StreamResponse || opts.BodyReader != nil || opts.GetBody != nil ||
+		opts.hasHeader("If-None-Match") || opts.hasHeader("If-Modified-Since") || opts.hasHeader("Range") {
+		return "", false
+	}
+	if _, ok := parseCacheControl(opts.headerValue("Cache-Control"))["no-store"]; ok {
+		return "", false
+	}
+	return requestURLKey(url, opts) + credentialsKey(ctx, opts, contextHeaders), true
+}
+
+// requestCredentials returns the credentials set in the request options, empty string if there are none.
+func requestCredentials(opts RequestOpts) string {
+	var b strings.Builder
+	if opts.AuthToken != "" {
+		b.WriteString("token:" + opts.AuthToken + "\n")
+	}
+	if opts.BasicAuthUser != "" || opts.BasicAuthPass != "" {
+		b.WriteString("basic:" + opts.BasicAuthUser + ":" + opts.BasicAuthPass + "\n")
+	}
+	if opts.ClientCertName != "" {
+		b.WriteString("cert:" + opts.ClientCertName + "\n")
+	}
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if value := opts.headerValue(name); value != "" {
+			b.WriteString(name + ":" + value + "\n")
+		}
+	}
+	for _, cookie := range opts.Cookies {
+		b.WriteString("cookie:" + cookie.Name + "=" + cookie.Value + "\n")
+	}
+	return b.String()
+}
+
+// credentialsKey returns the suffix of the key of the stored response with the hash of the credentials of the request
+// and the values of Config.ContextHeaders from the context, empty string if there are none.
+func credentialsKey(ctx context.Context, opts RequestOpts, contextHeaders []ContextHeader) string {
+	creds := requestCredentials(opts)
+	for _, h := range contextHeaders {
+		if value := h.resolve(ctx); value != "" {
+			creds += "context " + strings.ToLower(h.Header) + ":" + value + "\n"
+		}
+	}
+	if creds == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(creds))
+	return "\ncredentials:" + hex.EncodeToString(sum[:])
+}
+
+// lookup returns the stored response for the request, nil if there is no matching response.
+func (hc *httpCache) lookup(ctx context.Context, key string, opts RequestOpts, defaults http.Header) *CachedResponse {
+	cached, ok, err := hc.store.Get(ctx, key)
+	if err != nil {
+		hc.log.Warn("cannot get cached response", "key", key, "error", err)
+		return nil
+	}
+	if !ok || cached == nil {
+		return nil
+	}
+	for name, value := range cached.Vary {
+		if requestHeader(opts, defaults, name) != value {
+			return nil
+		}
+	}
+	return cached
+}
+
+// update stores the response of the request or refreshes the stored response after 304 Not Modified
+// and returns the response for the caller.
+func (hc *httpCache) update(ctx context.Context, key string, cached *CachedResponse, opts RequestOpts,
+	defaults http.Header, resp *resty.Response, err error) (*resty.Response, error) {
+	switch {
+	case err != nil || resp == nil:
+		return resp, err
+
+	case cached != nil && resp.StatusCode() == http.StatusNotModified:
+		revalidated := cached.revalidate(resp.Header())
+		hc.set(ctx, key, revalidated)
+		return hc.cachedResponse(resp.Request, revalidated, opts)
+
+	case isStorable(resp):
+		hc.set(ctx, key, newCachedResponse(resp, opts, defaults))
+	}
+	return resp, nil
+}
+
+func (hc *httpCache) set(ctx context.Context, key string, resp *CachedResponse) {
+	if err := hc.store.Set(ctx, key, resp); err != nil {
+		hc.log.Warn("cannot store cached response", "key", key, "error", err)
+	}
+}
+
+// newCachedResponse returns the response to store with the values of request headers listed in Vary.
+func newCachedResponse(resp *resty.Response, opts RequestOpts, defaults http.Header) *CachedResponse {
+	out := &CachedResponse{
+		StatusCode: resp.StatusCode(),
+		Header:     resp.Header().Clone(),
+		Body:       resp.Body(),
+		StoredAt:   time.Now(),
+	}
+	if age, err := strconv.Atoi(resp.Header().Get("Age")); err == nil && age > 0 {
+		out.StoredAt = out.StoredAt.Add(-time.Duration(age) * time.Second)
+	}
+	for _, name := range headerList(resp.Header().Values("Vary")) {
+		if out.Vary == nil {
+			out.Vary = make(map[string]string)
+		}
+		out.Vary[name] = requestHeader(opts, defaults, name)
+	}
+	return out
+}
+
+// isStorable returns true if the successful response may be stored and used later.
+func isStorable(resp *resty.Response) bool {
+	if resp.StatusCode() != http.StatusOK {
+		return false
+	}
+	h := resp.Header()
+	if _, ok := parseCacheControl(h.Get("Cache-Control"))["no-store"]; ok {
+		return false
+	}
+	for _, name := range headerList(h.Values("Vary")) {
+		if name == "*" {
+			return false
+		}
+	}
+	return freshnessLifetime(h) > 0 || h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+}
+
+// isFresh returns true if the response can be used without revalidation for the request.
+func (r *CachedResponse) isFresh(opts RequestOpts, now time.Time) bool {
+	directives := parseCacheControl(opts.headerValue("Cache-Control"))
+	if _, ok := directives["no-cache"]; ok {
+		return false
+	}
+	age := now.Sub(r.StoredAt)
+	if maxAge, ok := directives["max-age"]; ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil && age >= time.Duration(seconds)*time.Second {
+			return false
+		}
+	}
+	return age < freshnessLifetime(r.Header)
+}
+
+// conditional returns the options of the request that revalidates the stale response.
+func (r *CachedResponse) conditional(opts RequestOpts) RequestOpts {
+	if r == nil {
+		return opts
+	}
+	etag, lastModified := r.Header.Get("ETag"), r.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return opts
+	}
+	opts = opts.clone()
+	if etag != "" {
+		opts.SetHeader("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		opts.SetHeader("If-Modified-Since", lastModified)
+	}
+	return opts
+}
+
+// revalidate returns the copy of the response with headers updated from 304 Not Modified response.
+func (r *CachedResponse) revalidate(h http.Header) *CachedResponse {
+	out := *r
+	out.Header = r.Header.Clone()
+	for name, values := range h {
+		switch http.CanonicalHeaderKey(name) {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+			continue
+		}
+		out.Header[name] = values
+	}
+	out.StoredAt = time.Now()
+	if age, err := strconv.Atoi(h.Get("Age")); err == nil && age > 0 {
+		out.StoredAt = out.StoredAt.Add(-time.Duration(age) * time.Second)
+	}
+	return &out
+}
+
+// cachedResponse returns the response with the stored body and decodes it into opts.Result.
+func (hc *httpCache) cachedResponse(req *resty.Request, cached *CachedResponse, opts RequestOpts) (*resty.Response, error) {
+	if opts.Result != nil {
+		req.SetResult(opts.Result)
+	}
+	resp := &resty.Response{
+		Request: req,
+		RawResponse: &http.Response{
+			Status:     strconv.Itoa(cached.StatusCode) + " " + http.StatusText(cached.StatusCode),
+			StatusCode: cached.StatusCode,
+			Header:     cached.Header.Clone(),
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+		},
+	}
+	resp.SetBody(cached.Body)
+	return hc.results.useMemoized(resp, opts)
+}
+
+// freshnessLifetime returns the time the response is fresh from max-age, Expires or Last-Modified
+// (10% of the time since the last modification).
+func freshnessLifetime(h http.Header) time.Duration {
+	directives := parseCacheControl(h.Get("Cache-Control"))
+	if _, ok := directives["no-cache"]; ok {
+		return 0
+	}
+	if maxAge, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	date, err := http.ParseTime(h.Get("Date"))
+	if err != nil {
+		date = time.Now()
+	}
+	if expires := h.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+	if lastModified, err := http.ParseTime(h.Get("Last-Modified")); err == nil && lastModified.Before(date) {
+		return date.Sub(lastModified) / 10
+	}
+	return 0
+}
+
+// parseCacheControl returns directives of Cache-Control header with lower case names.
+func parseCacheControl(value string) map[string]string {
+	if value == "" {
+		return nil
+	}
+	out := make(map[string]string)
+	for _, directive := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			out[name] = strings.Trim(strings.TrimSpace(arg), `"`)
+		}
+	}
+	return out
+}
+
+// headerList returns canonical names of the comma-separated header list, e.g. Vary.
+func headerList(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				out = append(out, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return out
+}
+
+// requestHeader returns the value of the request header from options or default headers of the client.
+func requestHeader(opts RequestOpts, defaults http.Header, name string) string {
+	if opts.hasHeader(name) {
+		return opts.headerValue(name)
+	}
+	return strings.Join(defaults.Values(name), ", ")
+}
@@ -0,0 +1,163 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_HTTPCache(t *testing.T) {
+	var hits, notModified atomic.Int64
+	lastModified := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/modified":
+			w.Header().Set("Cache-Control", "max-age=0")
+			w.Header().Set("Last-Modified", lastModified)
+			if r.Header.Get("If-Modified-Since") == lastModified {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+			w.Write([]byte(`{"name":"` + r.Header.Get("Accept-Language") + `"}`))
+			return
+		}
+		w.Write([]byte(`{"name":"` + r.URL.Path + `"}`))
+	}))
+	defer srv.Close()
+
+	store := cliex.NewMemoryCacheStore(10)
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithHTTPCache(store))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	type response struct {
+		Name string `json:"name"`
+	}
+	get := func(url string, opts cliex.RequestOpts) string {
+		var result response
+		opts.Result = &result
+		resp, err := client.Request(ctx, url, opts)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+		assert.Equal(t, &result, resp.Result())
+		return result.Name
+	}
+
+	for range 3 {
+		assert.Equal(t, "/fresh", get("/fresh", cliex.RequestOpts{}))
+	}
+	assert.EqualValues(t, 1, hits.Load())
+
+	assert.Equal(t, "/fresh", get("/fresh", cliex.RequestOpts{NoCache: true}))
+	assert.Equal(t, "/fresh", get("/fresh", cliex.RequestOpts{Headers: map[string]string{"Cache-Control": "no-cache"}}))
+	assert.EqualValues(t, 3, hits.Load())
+
+	for _, url := range []string{"/etag", "/modified"} {
+		hits.Store(0)
+		notModified.Store(0)
+		for range 3 {
+			assert.Equal(t, url, get(url, cliex.RequestOpts{}))
+		}
+		assert.EqualValues(t, 3, hits.Load(), url)
+		assert.EqualValues(t, 2, notModified.Load(), url)
+	}
+
+	hits.Store(0)
+	get("/no-store", cliex.RequestOpts{})
+	get("/no-store", cliex.RequestOpts{})
+	assert.EqualValues(t, 2, hits.Load())
+
+	hits.Store(0)
+	english := cliex.RequestOpts{Headers: map[string]string{"Accept-Language": "en"}}
+	german := cliex.RequestOpts{Headers: map[string]string{"Accept-Language": "de"}}
+	assert.Equal(t, "en", get("/vary", english))
+	assert.Equal(t, "en", get("/vary", english))
+	assert.Equal(t, "de", get("/vary", german))
+	assert.EqualValues(t, 2, hits.Load())
+	assert.Equal(t, 4, store.Len())
+}
+
+func TestHTTP_HTTPCacheCredentials(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(r.Header.Get("Authorization") + r.Header.Get("X-Subject")))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithHTTPCache(nil),
+		cliex.WithContextHeader("X-Subject", subjectKey{}))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for range 2 {
+		for _, token := range []string{"alice", "bob"} {
+			resp, err := client.Request(ctx, "/me", cliex.RequestOpts{AuthToken: token})
+			require.NoError(t, err)
+			assert.Equal(t, "Bearer "+token, resp.String())
+		}
+	}
+	assert.EqualValues(t, 2, hits.Load())
+
+	resp, err := client.Request(ctx, "/me", cliex.RequestOpts{Cookies: []*http.Cookie{{Name: "session", Value: "alice"}}})
+	require.NoError(t, err)
+	assert.Empty(t, resp.String())
+	assert.EqualValues(t, 3, hits.Load())
+
+	// Responses are stored per values of context headers
+	for range 2 {
+		for _, subject := range []string{"alice", "bob"} {
+			resp, err := client.Request(context.WithValue(ctx, subjectKey{}, subject), "/me", cliex.RequestOpts{})
+			require.NoError(t, err)
+			assert.Equal(t, subject, resp.String())
+		}
+	}
+	assert.EqualValues(t, 5, hits.Load())
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := cliex.NewMemoryCacheStore(2)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "a", &cliex.CachedResponse{StatusCode: 200}))
+	require.NoError(t, store.Set(ctx, "b", &cliex.CachedResponse{StatusCode: 200}))
+	_, ok, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// "b" is the least recently used one
+	require.NoError(t, store.Set(ctx, "c", &cliex.CachedResponse{StatusCode: 200}))
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok, _ = store.Get(ctx, "a")
+	assert.True(t, ok)
+
+	require.NoError(t, store.Delete(ctx, "a"))
+	assert.Equal(t, 1, store.Len())
+}
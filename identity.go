@@ -0,0 +1,42 @@
+package cliex
+
+// RequestError is returned when the request failed without retries. It keeps the identity of the request
+// from RequestOpts.RequestName and RequestOpts.RequestLabels, use errors.As to get it.
+type RequestError struct {
+	// Name is the name of the request from RequestOpts.RequestName.
+	Name string
+	// Labels is the labels of the request from RequestOpts.RequestLabels.
+	Labels map[string]string
+	// Err is the error of the request.
+	Err error
+}
+
+// Error returns the error message with the name of the request.
+func (e *RequestError) Error() string {
+	return "failed " + requestTitle(e.Name) + "request: " + e.Err.Error()
+}
+
+// Unwrap returns the error of the request.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// requestTitle returns the name of the request with a trailing space to be put before "request" in messages.
+func requestTitle(name string) string {
+	if name == "" {
+		return ""
+	}
+	return name + " "
+}
+
+// identityAttrs returns log attributes with the identity of the request, empty if the request has no name and labels.
+func identityAttrs(name string, labels map[string]string) []any {
+	var attrs []any
+	if name != "" {
+		attrs = append(attrs, "request", name)
+	}
+	if len(labels) > 0 {
+		attrs = append(attrs, "labels", labels)
+	}
+	return attrs
+}
@@ -0,0 +1,67 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_RequestIdentity(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/fail": func(ctx context.Context, req *http.Request) (any, error) {
+			return nil, cliex.ErrBadGateway
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	records := make(chan cliex.AuditRecord, 10)
+	cfg.AuditSink = cliex.NewChannelAuditSink(records)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	labels := map[string]string{"tenant": "acme"}
+
+	_, err = client.Request(ctx, "/fail", cliex.RequestOpts{
+		RequestName:   "sync users",
+		RequestLabels: labels,
+	})
+	require.ErrorIs(t, err, cliex.ErrBadGateway)
+	assert.ErrorContains(t, err, "failed sync users request: ")
+
+	var requestErr *cliex.RequestError
+	require.ErrorAs(t, err, &requestErr)
+	assert.Equal(t, "sync users", requestErr.Name)
+	assert.Equal(t, labels, requestErr.Labels)
+
+	record := <-records
+	assert.Equal(t, "sync users", record.Name)
+	assert.Equal(t, labels, record.Labels)
+
+	_, err = client.Request(ctx, "/fail", cliex.RequestOpts{
+		RequestName:      "sync users",
+		RequestLabels:    labels,
+		RetryCount:       2,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	var retryErr *cliex.RetryError
+	require.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, "sync users", retryErr.RequestName)
+	assert.Equal(t, labels, retryErr.Labels)
+	assert.Contains(t, err.Error(), "failed sync users request after 1 retries")
+
+	_, err = client.Request(ctx, "/fail", cliex.RequestOpts{})
+	assert.ErrorContains(t, err, "failed request: ")
+}
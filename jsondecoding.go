@@ -0,0 +1,61 @@
+package cliex
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// JSONDecoding is the strictness of decoding JSON responses into RequestOpts.Result.
+// Zero value is the behavior of encoding/json: unknown fields are ignored and field names are case-insensitive.
+type JSONDecoding struct {
+	// DisallowUnknownFields returns an error if the response has a field that is missing in Result,
+	// it reveals upstream schema changes instead of silently dropping the field.
+	DisallowUnknownFields bool `yaml:"disallow_unknown_fields" json:"disallow_unknown_fields"`
+
+	// CaseSensitive matches JSON fields to struct fields case-sensitively.
+	CaseSensitive bool `yaml:"case_sensitive" json:"case_sensitive"`
+
+	// UseNumber decodes numbers into json.Number in interface{} values instead of float64,
+	// so large integers (e.g. IDs above 2^53) don't lose precision. There is no separate option
+	// to fail on number overflow: numbers that don't fit into typed fields (e.g. 300 into int8
+	// or a fraction into int) always return an error, only interface{} values lose precision silently.
+	UseNumber bool `yaml:"use_number" json:"use_number"`
+}
+
+var jsonDecodingAPIs sync.Map // JSONDecoding -> jsoniter.API
+
+// api returns the frozen jsoniter API with the decoding options, it is cached for every combination.
+func (d JSONDecoding) api() jsoniter.API {
+	if d == (JSONDecoding{}) {
+		return jsoniter.ConfigCompatibleWithStandardLibrary
+	}
+	if api, ok := jsonDecodingAPIs.Load(d); ok {
+		return api.(jsoniter.API)
+	}
+	api := jsoniter.Config{
+		EscapeHTML:             true,
+		SortMapKeys:            true,
+		ValidateJsonRawMessage: true,
+		DisallowUnknownFields:  d.DisallowUnknownFields,
+		CaseSensitive:          d.CaseSensitive,
+		UseNumber:              d.UseNumber,
+	}.Froze()
+	jsonDecodingAPIs.Store(d, api)
+	return api
+}
+
+// decodeJSONResult decodes the JSON response that is successful by Config.IsSuccess into result
+// with the decoding options of the request.
+func decodeJSONResult(decoding JSONDecoding, isSuccess func(*resty.Response) bool, resp *resty.Response, result any) error {
+	if result == nil || len(resp.Body()) == 0 || !isSuccess(resp) || !resty.IsJSONType(resp.Header().Get("Content-Type")) {
+		return nil
+	}
+	if err := decoding.api().Unmarshal(resp.Body(), result); err != nil {
+		return fmt.Errorf("decode json response: %w", err)
+	}
+	resp.Request.Result = result
+	return nil
+}
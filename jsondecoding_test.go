@@ -0,0 +1,89 @@
+package cliex_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_JSONDecoding(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ID":9007199254740993,"name":"john","email":"john@example.com"}`))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(
+		cliex.WithBaseURL(srv.URL),
+		cliex.WithJSONDecoding(cliex.JSONDecoding{DisallowUnknownFields: true}),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	type user struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	var u user
+	_, err = client.Get(ctx, "/", &u)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "email")
+
+	u = user{}
+	resp, err := client.Request(ctx, "/", cliex.RequestOpts{Result: &u, JSONDecoding: &cliex.JSONDecoding{}})
+	require.NoError(t, err)
+	assert.Equal(t, user{ID: 9007199254740993, Name: "john"}, u)
+	assert.Same(t, &u, resp.Result())
+
+	u = user{}
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{Result: &u, JSONDecoding: &cliex.JSONDecoding{CaseSensitive: true}})
+	require.NoError(t, err)
+	assert.Equal(t, user{Name: "john"}, u)
+
+	var m map[string]any
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{Result: &m, JSONDecoding: &cliex.JSONDecoding{UseNumber: true}})
+	require.NoError(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), m["ID"])
+}
+
+func TestHTTP_JSONDecodingIsSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"id":300,"name":"john"}`))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(
+		cliex.WithBaseURL(srv.URL),
+		cliex.WithIsSuccess(func(resp *resty.Response) bool {
+			return resp.StatusCode() < 300 || resp.StatusCode() == http.StatusConflict
+		}),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Responses that are successful by Config.IsSuccess are decoded with the options of the request
+	var u struct {
+		Name string `json:"name"`
+	}
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{Result: &u, JSONDecoding: &cliex.JSONDecoding{CaseSensitive: true}})
+	require.NoError(t, err)
+	assert.Equal(t, "john", u.Name)
+
+	// Numbers that overflow typed fields are errors
+	var small struct {
+		ID int8 `json:"id"`
+	}
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{Result: &small, JSONDecoding: &cliex.JSONDecoding{}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "overflow")
+}
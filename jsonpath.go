@@ -0,0 +1,117 @@
+package cliex
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parseJSONPath splits a simple JSON path like "$.user.items[0].id" or "$['user']['id']" into keys.
+// Array indexes are returned as ints, object keys as strings.
+func parseJSONPath(path string) ([]any, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+
+	var keys []any
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in json path")
+			}
+			keys = append(keys, path[:end])
+			path = path[end:]
+
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket in json path")
+			}
+			inner := path[1:end]
+			path = path[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				keys = append(keys, inner[1:len(inner)-1])
+				continue
+			}
+			if inner == "*" {
+				keys = append(keys, "*")
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q in json path", inner)
+			}
+			keys = append(keys, idx)
+
+		default:
+			// Path without leading "$." like "user.id"
+			path = "." + path
+		}
+	}
+
+	return keys, nil
+}
+
+// lookupJSONPath returns a value from the decoded JSON (maps, slices and scalars) by the path.
+// Wildcard "[*]" matches all elements of arrays and values of objects in order of keys,
+// values matched by the path with wildcards are returned as []any.
+func lookupJSONPath(v any, path string) (any, bool) {
+	keys, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false
+	}
+	matches := matchJSONPath(v, keys, nil)
+	if !slices.Contains(keys, any("*")) {
+		if len(matches) == 0 {
+			return nil, false
+		}
+		return matches[0], true
+	}
+	return matches, len(matches) > 0
+}
+
+// matchJSONPath appends values matched by the path keys to out, "*" matches all elements.
+func matchJSONPath(v any, keys []any, out []any) []any {
+	if len(keys) == 0 {
+		return append(out, v)
+	}
+	switch k := keys[0].(type) {
+	case string:
+		switch value := v.(type) {
+		case map[string]any:
+			if k != "*" {
+				if elem, ok := value[k]; ok {
+					out = matchJSONPath(elem, keys[1:], out)
+				}
+				return out
+			}
+			names := make([]string, 0, len(value))
+			for name := range value {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			for _, name := range names {
+				out = matchJSONPath(value[name], keys[1:], out)
+			}
+		case []any:
+			if k != "*" {
+				return out
+			}
+			for _, elem := range value {
+				out = matchJSONPath(elem, keys[1:], out)
+			}
+		}
+	case int:
+		if s, ok := v.([]any); ok && k >= 0 && k < len(s) {
+			out = matchJSONPath(s[k], keys[1:], out)
+		}
+	}
+	return out
+}
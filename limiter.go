@@ -0,0 +1,195 @@
+package cliex
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is the token bucket rate limit for requests.
+type RateLimit struct {
+	// RPS is the number of requests per second, zero or negative means no limit.
+	RPS float64 `yaml:"rps" json:"rps"`
+
+	// Burst is the maximum number of requests that can be sent at once.
+	// Default is RPS rounded up (at least 1).
+	Burst int `yaml:"burst" json:"burst"`
+}
+
+// tokenBucket is a simple token bucket rate limiter.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.RPS <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst <= 0 {
+		burst = math.Max(1, math.Ceil(limit.RPS))
+	}
+	return &tokenBucket{
+		rate:   limit.RPS,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a token is available or the context is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	delay := b.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token and returns the time to wait until it becomes available.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// isFull returns true if the bucket is refilled to the burst at the time, the nil bucket is always full.
+func (b *tokenBucket) isFull(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst
+}
+
+// cancel returns the reserved token back to the bucket.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// hostLimiterTTL is the minimum duration after which the bucket of the host that is not used is deleted.
+const hostLimiterTTL = time.Minute
+
+// hostLimiters holds token buckets for every host. Buckets of hosts that are not used for hostLimiterTTL
+// and have been refilled since are deleted, so the number of buckets is bounded by the recently used hosts.
+type hostLimiters struct {
+	mu        sync.Mutex
+	buckets   map[string]*hostBucket
+	limits    map[string]RateLimit
+	getter    func(host string) (RateLimit, bool)
+	lastSweep time.Time
+}
+
+type hostBucket struct {
+	b        *tokenBucket
+	lastUsed time.Time
+}
+
+func newHostLimiters(limits map[string]RateLimit, getter func(host string) (RateLimit, bool)) *hostLimiters {
+	if len(limits) == 0 && getter == nil {
+		return nil
+	}
+	normalized := make(map[string]RateLimit, len(limits))
+	for host, limit := range limits {
+		normalized[strings.ToLower(host)] = limit
+	}
+	return &hostLimiters{
+		buckets:   make(map[string]*hostBucket),
+		limits:    normalized,
+		getter:    getter,
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *hostLimiters) wait(ctx context.Context, host string) error {
+	if l == nil || host == "" {
+		return nil
+	}
+	return l.bucket(strings.ToLower(host)).wait(ctx)
+}
+
+func (l *hostLimiters) bucket(host string) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= hostLimiterTTL {
+		l.sweep(now)
+	}
+
+	if entry, ok := l.buckets[host]; ok {
+		entry.lastUsed = now
+		return entry.b
+	}
+
+	limit, ok := l.limits[host]
+	if !ok {
+		// Try host without port
+		if i := strings.LastIndexByte(host, ':'); i != -1 {
+			limit, ok = l.limits[host[:i]]
+		}
+	}
+	if !ok && l.getter != nil {
+		limit, ok = l.getter(host)
+	}
+
+	var b *tokenBucket
+	if ok {
+		b = newTokenBucket(limit)
+	}
+	l.buckets[host] = &hostBucket{b: b, lastUsed: now}
+
+	return b
+}
+
+// sweep deletes buckets that are not used for hostLimiterTTL. A bucket is deleted only if it is full,
+// so the new bucket of the host doesn't allow more requests than the deleted one.
+func (l *hostLimiters) sweep(now time.Time) {
+	for host, entry := range l.buckets {
+		if now.Sub(entry.lastUsed) >= hostLimiterTTL && entry.b.isFull(now) {
+			delete(l.buckets, host)
+		}
+	}
+	l.lastSweep = now
+}
+
+// hostFromURL returns host of the URL with port if it is provided.
+func hostFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,88 @@
+package cliex_test
+
+import (
+	"context"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostRateLimits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, nil)
+	u, err := url.Parse(cfg.BaseURL)
+	require.NoError(t, err)
+
+	slow := cliex.GetConfigForTest(ctx, &requestCounter, nil)
+	fast := cliex.GetConfigForTest(ctx, &requestCounter, nil)
+
+	client, err := cliex.New(
+		cliex.WithHostRateLimit(u.Host, 20, 1),
+		cliex.WithHostRateLimitFunc(func(host string) (cliex.RateLimit, bool) {
+			if "http://"+host == slow.BaseURL {
+				return cliex.RateLimit{RPS: 10, Burst: 1}, true
+			}
+			return cliex.RateLimit{}, false
+		}),
+	)
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err = client.Get(ctx, cfg.BaseURL+"/test")
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 140*time.Millisecond)
+
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		_, err = client.Get(ctx, slow.BaseURL+"/test")
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+
+	start = time.Now()
+	for i := 0; i < 10; i++ {
+		_, err = client.Get(ctx, fast.BaseURL+"/test")
+		require.NoError(t, err)
+	}
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	assert.Equal(t, int64(17), requestCounter.Load())
+}
+
+func TestRateLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	first := cliex.GetConfigForTest(ctx, &requestCounter, nil)
+	second := cliex.GetConfigForTest(ctx, &requestCounter, nil)
+
+	client, err := cliex.New(cliex.WithRateLimit(20, 2))
+	require.NoError(t, err)
+
+	// Two requests are sent at once, then the client limit applies to all hosts
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err = client.Get(ctx, first.BaseURL+"/test")
+		require.NoError(t, err)
+		_, err = client.Get(ctx, second.BaseURL+"/test")
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+	assert.Equal(t, int64(6), requestCounter.Load())
+
+	canceled, cancelRequest := context.WithCancel(ctx)
+	cancelRequest()
+	_, err = client.Get(canceled, first.BaseURL+"/test")
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,61 @@
+package cliex
+
+import (
+	"net/http"
+	"strings"
+)
+
+// parseLinks returns URLs of Link headers (RFC 8288) by their relation types, e.g. "next" or "deprecation".
+// The first link is used if there are several links with the same relation type.
+func parseLinks(h http.Header) map[string]string {
+	var out map[string]string
+	for _, header := range h.Values("Link") {
+		for _, link := range splitLinks(header) {
+			start, end := strings.IndexByte(link, '<'), strings.IndexByte(link, '>')
+			if start == -1 || end < start {
+				continue
+			}
+			target := strings.TrimSpace(link[start+1 : end])
+			for _, param := range strings.Split(link[end+1:], ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+					rel = strings.ToLower(rel)
+					if out == nil {
+						out = make(map[string]string)
+					}
+					if _, ok := out[rel]; !ok {
+						out[rel] = target
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// splitLinks splits the Link header value by commas outside of angle brackets and quotes.
+func splitLinks(header string) []string {
+	var (
+		out      []string
+		start    int
+		inURL    bool
+		inQuotes bool
+	)
+	for i, r := range header {
+		switch {
+		case r == '<' && !inQuotes:
+			inURL = true
+		case r == '>' && !inQuotes:
+			inURL = false
+		case r == '"' && !inURL:
+			inQuotes = !inQuotes
+		case r == ',' && !inURL && !inQuotes:
+			out = append(out, header[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, header[start:])
+}
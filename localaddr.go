@@ -0,0 +1,71 @@
+package cliex
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	defaultDialTimeout   = 30 * time.Second
+	defaultDialKeepAlive = 30 * time.Second
+)
+
+// setLocalAddr makes the transport of the client dial connections from the local address.
+func setLocalAddr(cli *resty.Client, localAddr string) error {
+	addr, err := resolveLocalAddr(localAddr)
+	if err != nil {
+		return err
+	}
+	transport, err := cli.Transport()
+	if err != nil {
+		return err
+	}
+	dialer := &net.Dialer{
+		Timeout:   defaultDialTimeout,
+		KeepAlive: defaultDialKeepAlive,
+		LocalAddr: addr,
+	}
+	transport.DialContext = dialer.DialContext
+	return nil
+}
+
+// resolveLocalAddr returns the TCP address from IP, IP with port or the name of the network interface.
+// The first IPv4 address of the interface is used, IPv6 address is used if the interface has no IPv4 addresses.
+func resolveLocalAddr(localAddr string) (*net.TCPAddr, error) {
+	if ip := net.ParseIP(localAddr); ip != nil {
+		return &net.TCPAddr{IP: ip}, nil
+	}
+	if host, port, err := net.SplitHostPort(localAddr); err == nil && net.ParseIP(host) != nil {
+		return net.ResolveTCPAddr("tcp", net.JoinHostPort(host, port))
+	}
+
+	iface, err := net.InterfaceByName(localAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local address=%s: %w", localAddr, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("get addresses of interface %s: %w", localAddr, err)
+	}
+	var ipv6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return &net.TCPAddr{IP: ipNet.IP}, nil
+		}
+		if ipv6 == nil {
+			ipv6 = ipNet.IP
+		}
+	}
+	if ipv6 != nil {
+		return &net.TCPAddr{IP: ipv6}, nil
+	}
+	return nil, errors.New("no addresses of interface " + localAddr)
+}
@@ -0,0 +1,44 @@
+package cliex_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_LocalAddr(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		w.Write([]byte(host))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithLocalAddr("127.0.0.1"))
+	require.NoError(t, err)
+	resp, err := client.Get(context.Background(), "/")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", resp.String())
+
+	ifaces, err := net.Interfaces()
+	require.NoError(t, err)
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+		client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithLocalAddr(iface.Name))
+		require.NoError(t, err)
+		resp, err := client.Get(context.Background(), "/")
+		require.NoError(t, err)
+		assert.Equal(t, "127.0.0.1", resp.String())
+		break
+	}
+
+	_, err = cliex.New(cliex.WithLocalAddr("no-such-interface"))
+	require.Error(t, err)
+}
@@ -0,0 +1,158 @@
+package cliex
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/lang"
+)
+
+// memoSweepSize is the number of entries after which expired entries are deleted on every store.
+const memoSweepSize = 1024
+
+// memoCache memoizes successful GET responses for RequestOpts.CacheTTL.
+type memoCache struct {
+	mu      sync.Mutex
+	entries map[string]memoEntry
+}
+
+type memoEntry struct {
+	resp    *resty.Response
+	expires time.Time
+}
+
+func newMemoCache() *memoCache {
+	return &memoCache{entries: make(map[string]memoEntry)}
+}
+
+func (m *memoCache) get(key string) (*resty.Response, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.resp, true
+}
+
+func (m *memoCache) set(key string, resp *resty.Response, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if len(m.entries) >= memoSweepSize {
+		for k, entry := range m.entries {
+			if now.After(entry.expires) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	m.entries[key] = memoEntry{resp: resp, expires: now.Add(ttl)}
+}
+
+// memoKey returns the key of the request by URL, path params, query, credentials and values of Config.ContextHeaders,
+// so the response memoized for one caller is not returned to another one. It returns false if the request cannot be memoized.
+func memoKey(ctx context.Context, url string, opts RequestOpts, contextHeaders []ContextHeader) (string, bool) {
+	if opts.CacheTTL <= 0 || (opts.Method != "" && opts.Method != http.MethodGet) ||
+		opts.OutputPath != "" || opts.StreamResponse || opts.BodyReader != nil || opts.GetBody != nil {
+		return "", false
+	}
+	return requestURLKey(url, opts) + credentialsKey(ctx, opts, contextHeaders), true
+}
+
+// requestURLKey returns the URL with applied path params and sorted query.
+func requestURLKey(url string, opts RequestOpts) string {
+	for k, v := range opts.PathParams {
+		url = strings.ReplaceAll(url, "{"+k+"}", v)
+	}
+	if len(opts.Query) == 0 && len(opts.QueryValues) == 0 {
+		return url
+	}
+	query := make(neturl.Values, len(opts.Query)+len(opts.QueryValues))
+	for k, v := range opts.Query {
+		query.Set(k, v)
+	}
+	for k, values := range opts.QueryValues {
+		query[k] = append(query[k], values...)
+	}
+	// Encode sorts the query by keys
+	return url + "?" + query.Encode()
+}
+
+// resultDecoder decodes bodies of memoized, cached and shared responses into RequestOpts.Result
+// the same way as bodies of live responses: with the JSON decoding options and decoders of the client.
+type resultDecoder struct {
+	json      JSONDecoding
+	decoders  map[string]Decoder
+	isSuccess func(*resty.Response) bool
+}
+
+func newResultDecoder(cfg Config) resultDecoder {
+	decoders := make(map[string]Decoder, len(cfg.Decoders))
+	for mediaType, decoder := range cfg.Decoders {
+		decoders[strings.ToLower(mediaType)] = decoder
+	}
+	return resultDecoder{json: cfg.JSONDecoding, decoders: decoders, isSuccess: cfg.IsSuccess}
+}
+
+// copyResponse returns the copy of the response with the copy of its request that holds the result of the caller,
+// so callers of the memoized or shared response do not get the result of another caller.
+func copyResponse(resp *resty.Response, result any) *resty.Response {
+	if resp == nil || resp.Request == nil {
+		return resp
+	}
+	out, req := *resp, *resp.Request
+	out.Request, req.Result = &req, result
+	return &out
+}
+
+// useMemoized decodes the body of the successful memoized response into opts.Result and writes it to opts.TeeWriter.
+func (d resultDecoder) useMemoized(resp *resty.Response, opts RequestOpts) (*resty.Response, error) {
+	body := resp.Body()
+	if opts.Result != nil && len(body) > 0 && d.isSuccess(resp) {
+		if err := d.decode(resp, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.TeeWriter != nil && len(body) > 0 {
+		if _, err := opts.TeeWriter.Write(body); err != nil {
+			return resp, fmt.Errorf("write response to tee: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// decode decodes JSON and XML by Content-Type (or RequestOpts.ForceContentType) like resty does
+// and other content types with the registered decoders.
+func (d resultDecoder) decode(resp *resty.Response, opts RequestOpts) error {
+	contentType := lang.Check(opts.ForceContentType, resp.Header().Get("Content-Type"))
+	switch {
+	case resty.IsJSONType(contentType):
+		decoding := d.json
+		if opts.JSONDecoding != nil {
+			decoding = *opts.JSONDecoding
+		}
+		if err := decoding.api().Unmarshal(resp.Body(), opts.Result); err != nil {
+			return fmt.Errorf("unmarshal cached response: %w", err)
+		}
+	case resty.IsXMLType(contentType):
+		if err := xml.Unmarshal(resp.Body(), opts.Result); err != nil {
+			return fmt.Errorf("unmarshal cached response: %w", err)
+		}
+	default:
+		return decodeResult(d.decoders, d.isSuccess, resp, opts.Result)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_CacheTTL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var version atomic.Int64
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/config": func(ctx context.Context, req *http.Request) (any, error) {
+			return map[string]any{"version": version.Add(1), "env": req.URL.Query().Get("env")}, nil
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	type config struct {
+		Version int    `json:"version"`
+		Env     string `json:"env"`
+	}
+
+	get := func(env string) config {
+		var out config
+		_, err := client.Request(ctx, "/config", cliex.RequestOpts{
+			Query:    map[string]string{"env": env},
+			Result:   &out,
+			CacheTTL: 100 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		return out
+	}
+
+	assert.Equal(t, config{Version: 1, Env: "prod"}, get("prod"))
+	assert.Equal(t, config{Version: 1, Env: "prod"}, get("prod"))
+	assert.Equal(t, config{Version: 2, Env: "dev"}, get("dev"))
+	assert.Equal(t, int64(2), requestCounter.Load())
+
+	// Requests without TTL are not memoized
+	_, err = client.Get(ctx, "/config?env=prod")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), requestCounter.Load())
+
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, config{Version: 4, Env: "prod"}, get("prod"))
+	assert.Equal(t, int64(4), requestCounter.Load())
+
+	// Every caller of the memoized response gets its own response with its own result
+	var first, second config
+	opts := cliex.RequestOpts{Query: map[string]string{"env": "prod"}, CacheTTL: time.Minute}
+	opts.Result = &first
+	firstResp, err := client.Request(ctx, "/config", opts)
+	require.NoError(t, err)
+	opts.Result = &second
+	secondResp, err := client.Request(ctx, "/config", opts)
+	require.NoError(t, err)
+	assert.NotSame(t, firstResp, secondResp)
+	assert.Same(t, &first, firstResp.Result())
+	assert.Same(t, &second, secondResp.Result())
+	assert.Equal(t, first, second)
+}
+
+func TestHTTP_CacheTTLDecoding(t *testing.T) {
+	var requestCounter atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCounter.Add(1)
+		switch r.URL.Path {
+		case "/kv":
+			w.Header().Set("Content-Type", "application/x-kv")
+			w.Write([]byte("name=kv"))
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"json","extra":1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(
+		cliex.WithBaseURL(srv.URL),
+		cliex.WithDecoder("application/x-kv", func(data []byte, v any) error {
+			_, name, _ := strings.Cut(string(data), "=")
+			v.(*negotiatedUser).Name = name
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Memoized responses are decoded with registered decoders
+	for range 2 {
+		var user negotiatedUser
+		_, err := client.Request(ctx, "/kv", cliex.RequestOpts{Result: &user, CacheTTL: time.Minute})
+		require.NoError(t, err)
+		assert.Equal(t, "kv", user.Name)
+	}
+	assert.Equal(t, int64(1), requestCounter.Load())
+
+	// Memoized responses are decoded with JSONDecoding of the request
+	var user negotiatedUser
+	_, err = client.Request(ctx, "/json", cliex.RequestOpts{Result: &user, CacheTTL: time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, "json", user.Name)
+	_, err = client.Request(ctx, "/json", cliex.RequestOpts{
+		Result:       &negotiatedUser{},
+		CacheTTL:     time.Minute,
+		JSONDecoding: &cliex.JSONDecoding{DisallowUnknownFields: true},
+	})
+	require.Error(t, err)
+	assert.Equal(t, int64(2), requestCounter.Load())
+
+	// 404 responses of NilOn404 requests are not memoized
+	for range 2 {
+		resp, err := client.Request(ctx, "/missing", cliex.RequestOpts{NilOn404: true, CacheTTL: time.Minute})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+	}
+	assert.Equal(t, int64(4), requestCounter.Load())
+}
+
+func TestHTTP_CacheTTLCredentials(t *testing.T) {
+	var requestCounter atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCounter.Add(1)
+		w.Write([]byte(r.Header.Get("Authorization") + ";" + r.Header.Get("X-Subject")))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithContextHeader("X-Subject", subjectKey{}))
+	require.NoError(t, err)
+
+	get := func(token, subject string) string {
+		ctx := context.WithValue(context.Background(), subjectKey{}, subject)
+		resp, err := client.Request(ctx, "/me", cliex.RequestOpts{AuthToken: token, CacheTTL: time.Minute})
+		require.NoError(t, err)
+		return resp.String()
+	}
+
+	// Responses are memoized per credentials and values of context headers
+	assert.Equal(t, "Bearer a;alice", get("a", "alice"))
+	assert.Equal(t, "Bearer b;alice", get("b", "alice"))
+	assert.Equal(t, "Bearer a;bob", get("a", "bob"))
+	assert.Equal(t, "Bearer a;alice", get("a", "alice"))
+	assert.Equal(t, int64(3), requestCounter.Load())
+}
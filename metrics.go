@@ -0,0 +1,118 @@
+package cliex
+
+import (
+	"context"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+)
+
+// RequestInfo describes the request in metrics hooks. All fields except Meta have low cardinality.
+type RequestInfo struct {
+	// Method is the HTTP method of the request.
+	Method string
+	// Route is the URL template of the request, see RequestOpts.Route.
+	Route string
+	// Name is the name of the request from RequestOpts.RequestName.
+	Name string
+	// Labels is the low-cardinality labels of the request from RequestOpts.RequestLabels, it must not be modified.
+	Labels map[string]string
+	// Host is the host of the request with port if it is provided.
+	Host string
+	// Meta is the request-scoped values from RequestOpts.Meta, it is shared between hooks and must not be modified.
+	Meta map[string]any
+}
+
+type metaKey struct{}
+
+// MetaFromContext returns RequestOpts.Meta of the request from the context of hooks, nil if it is not set.
+func MetaFromContext(ctx context.Context) map[string]any {
+	if ctx == nil {
+		return nil
+	}
+	meta, _ := ctx.Value(metaKey{}).(map[string]any)
+	return meta
+}
+
+// MetricsHook receives events of the client to bridge them to statsd, OpenCensus, Prometheus or custom telemetry.
+// Start and end events are sent for every attempt of the request. Methods are called synchronously,
+// so they should not block. Embed NoopMetricsHook to implement only needed methods.
+type MetricsHook interface {
+	// OnRequestStart is called before the request is sent.
+	OnRequestStart(ctx context.Context, info RequestInfo)
+	// OnRequestEnd is called after the response is received, statusCode is zero if there is no response.
+	OnRequestEnd(ctx context.Context, info RequestInfo, statusCode int, duration time.Duration, err error)
+	// OnRetry is called before waiting for the retry, attempt is the number of the next attempt starting from 2.
+	OnRetry(ctx context.Context, info RequestInfo, attempt int, wait time.Duration, err error)
+	// OnBreakerTrip is called when the circuit breaker of the route turns to the open state.
+	OnBreakerTrip(route string)
+	// OnSlowRequest is called after the attempt of the request that took longer than Config.SlowRequestThreshold.
+	OnSlowRequest(ctx context.Context, info RequestInfo, duration time.Duration)
+}
+
+// NoopMetricsHook is the MetricsHook that does nothing.
+type NoopMetricsHook struct{}
+
+// OnRequestStart does nothing.
+func (NoopMetricsHook) OnRequestStart(context.Context, RequestInfo) {}
+
+// OnRequestEnd does nothing.
+func (NoopMetricsHook) OnRequestEnd(context.Context, RequestInfo, int, time.Duration, error) {}
+
+// OnRetry does nothing.
+func (NoopMetricsHook) OnRetry(context.Context, RequestInfo, int, time.Duration, error) {}
+
+// OnBreakerTrip does nothing.
+func (NoopMetricsHook) OnBreakerTrip(string) {}
+
+// OnSlowRequest does nothing.
+func (NoopMetricsHook) OnSlowRequest(context.Context, RequestInfo, time.Duration) {}
+
+// metricsHooks sends events to every hook in order.
+type metricsHooks []MetricsHook
+
+func (hooks metricsHooks) OnRequestStart(ctx context.Context, info RequestInfo) {
+	for _, h := range hooks {
+		h.OnRequestStart(ctx, info)
+	}
+}
+
+func (hooks metricsHooks) OnRequestEnd(ctx context.Context, info RequestInfo, statusCode int, duration time.Duration, err error) {
+	for _, h := range hooks {
+		h.OnRequestEnd(ctx, info, statusCode, duration, err)
+	}
+}
+
+func (hooks metricsHooks) OnRetry(ctx context.Context, info RequestInfo, attempt int, wait time.Duration, err error) {
+	for _, h := range hooks {
+		h.OnRetry(ctx, info, attempt, wait, err)
+	}
+}
+
+func (hooks metricsHooks) OnBreakerTrip(route string) {
+	for _, h := range hooks {
+		h.OnBreakerTrip(route)
+	}
+}
+
+func (hooks metricsHooks) OnSlowRequest(ctx context.Context, info RequestInfo, duration time.Duration) {
+	for _, h := range hooks {
+		h.OnSlowRequest(ctx, info, duration)
+	}
+}
+
+func (hooks metricsHooks) OnBreakerStateChange(key string, from, to gobreaker.State) {
+	for _, h := range hooks {
+		if hook, ok := h.(BreakerStateHook); ok {
+			hook.OnBreakerStateChange(key, from, to)
+		}
+	}
+}
+
+func (hooks metricsHooks) OnBreakerRemove(key string) {
+	for _, h := range hooks {
+		if hook, ok := h.(BreakerStateHook); ok {
+			hook.OnBreakerRemove(key)
+		}
+	}
+}
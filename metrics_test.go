@@ -0,0 +1,162 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type metricsHookForTest struct {
+	cliex.NoopMetricsHook
+
+	mu      sync.Mutex
+	starts  []cliex.RequestInfo
+	codes   []int
+	retries []int
+	trips   []string
+	metas   []map[string]any
+	slow    []time.Duration
+}
+
+func (h *metricsHookForTest) OnRequestStart(ctx context.Context, info cliex.RequestInfo) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.starts = append(h.starts, info)
+	h.metas = append(h.metas, cliex.MetaFromContext(ctx))
+}
+
+func (h *metricsHookForTest) OnRequestEnd(_ context.Context, _ cliex.RequestInfo, statusCode int, _ time.Duration, _ error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.codes = append(h.codes, statusCode)
+}
+
+func (h *metricsHookForTest) OnRetry(_ context.Context, _ cliex.RequestInfo, attempt int, _ time.Duration, _ error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.retries = append(h.retries, attempt)
+}
+
+func (h *metricsHookForTest) OnBreakerTrip(route string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.trips = append(h.trips, route)
+}
+
+func (h *metricsHookForTest) OnSlowRequest(_ context.Context, _ cliex.RequestInfo, duration time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.slow = append(h.slow, duration)
+}
+
+func TestHTTP_MetricsHook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	hook := &metricsHookForTest{}
+	client, err := cliex.New(
+		cliex.WithBaseURL(srv.URL),
+		cliex.WithMetricsHook(hook),
+		func(cfg *cliex.Config) {
+			cfg.CircuitBreaker = true
+			cfg.CircuitBreakerFailures = 1
+		},
+	)
+	require.NoError(t, err)
+
+	_, err = client.Request(context.Background(), "/users/1", cliex.RequestOpts{
+		Method:           http.MethodPost,
+		Route:            "/users/{id}",
+		RequestName:      "create",
+		RequestLabels:    map[string]string{"tenant": "acme"},
+		RetryCount:       3,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	require.Error(t, err)
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+
+	require.Len(t, hook.starts, 3)
+	assert.Equal(t, cliex.RequestInfo{
+		Method: http.MethodPost,
+		Route:  "/users/{id}",
+		Name:   "create",
+		Labels: map[string]string{"tenant": "acme"},
+		Host:   srv.Listener.Addr().String(),
+	}, hook.starts[0])
+	assert.Equal(t, []int{503, 503, 503}, hook.codes)
+	assert.Equal(t, []int{2, 3}, hook.retries)
+	assert.Equal(t, []string{"/users/{id}"}, hook.trips)
+}
+
+func TestHTTP_RequestMeta(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var (
+		hook    = &metricsHookForTest{}
+		records []cliex.AuditRecord
+	)
+	client, err := cliex.New(
+		cliex.WithBaseURL(srv.URL),
+		cliex.WithMetricsHook(hook),
+		cliex.WithAuditSink(cliex.AuditFunc(func(record cliex.AuditRecord) {
+			records = append(records, record)
+		})),
+	)
+	require.NoError(t, err)
+
+	meta := map[string]any{"tenant": "acme", "job_id": 42}
+	_, err = client.Request(context.Background(), "/jobs", cliex.RequestOpts{Meta: meta})
+	require.NoError(t, err)
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+
+	require.Len(t, hook.starts, 1)
+	assert.Equal(t, meta, hook.starts[0].Meta)
+	assert.Equal(t, meta, hook.metas[0])
+	require.Len(t, records, 1)
+	assert.Equal(t, meta, records[0].Meta)
+
+	assert.Nil(t, cliex.MetaFromContext(context.Background()))
+}
+
+func TestHTTP_SlowRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(30 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	hook := &metricsHookForTest{}
+	client, err := cliex.New(
+		cliex.WithBaseURL(srv.URL),
+		cliex.WithMetricsHook(hook),
+		cliex.WithSlowRequestThreshold(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = client.Get(ctx, "/fast")
+	require.NoError(t, err)
+	resp, err := client.Get(ctx, "/slow")
+	require.NoError(t, err)
+
+	require.Len(t, hook.slow, 1)
+	assert.GreaterOrEqual(t, hook.slow[0], 30*time.Millisecond)
+	assert.GreaterOrEqual(t, resp.Request.TraceInfo().ServerTime, 30*time.Millisecond)
+}
@@ -0,0 +1,102 @@
+package cliex
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// RoundTripFunc sends the HTTP request and returns the response, it implements http.RoundTripper.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls the function.
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the next RoundTripFunc to inject logging, header mutation, metrics or custom auth.
+// It is called for every attempt of the request and for every followed redirect.
+// The request is created for every attempt, so a middleware may set its headers.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// OnBeforeRequest returns the middleware that calls the function before sending the request,
+// the request is not sent if the function returns an error.
+func OnBeforeRequest(f func(req *http.Request) error) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if err := f(req); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	}
+}
+
+// OnAfterResponse returns the middleware that calls the function with the received response,
+// the error of the function is returned as the error of the request.
+func OnAfterResponse(f func(resp *http.Response) error) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if err != nil {
+				return resp, err
+			}
+			if err := f(resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+	}
+}
+
+// Use adds middlewares to the transport of the client. Middlewares are applied in order of adding:
+// the first one is the outermost and sees the request first. They are run after Config.OAuth2TokenURL
+// and client certificates are set up, so a middleware that sets Authorization header overrides OAuth2.
+// Middlewares should be added before making requests, the transport of resty client is replaced on the first call.
+func (c *HTTP) Use(middlewares ...Middleware) {
+	c.chain.add(c.cli, middlewares)
+}
+
+// middlewareChain is the transport that sends requests through middlewares to the base transport.
+type middlewareChain struct {
+	mu          sync.Mutex
+	base        http.RoundTripper
+	middlewares []Middleware
+	rt          atomic.Value // RoundTripFunc
+}
+
+func (m *middlewareChain) add(cli *resty.Client, middlewares []Middleware) {
+	if len(middlewares) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.base == nil {
+		m.base = cli.GetClient().Transport
+		if m.base == nil {
+			m.base = http.DefaultTransport
+		}
+		defer cli.SetTransport(m)
+	}
+	m.middlewares = append(m.middlewares, middlewares...)
+
+	rt := RoundTripFunc(m.base.RoundTrip)
+	for i := len(m.middlewares) - 1; i >= 0; i-- {
+		rt = m.middlewares[i](rt)
+	}
+	m.rt.Store(rt)
+}
+
+func (m *middlewareChain) RoundTrip(req *http.Request) (*http.Response, error) {
+	return m.rt.Load().(RoundTripFunc)(req)
+}
+
+func (m *middlewareChain) CloseIdleConnections() {
+	if closer, ok := m.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,97 @@
+package cliex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Use(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Trace")))
+	}))
+	defer srv.Close()
+
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	record := func(name string) cliex.Middleware {
+		return func(next cliex.RoundTripFunc) cliex.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				calls = append(calls, name)
+				mu.Unlock()
+				return next(req)
+			}
+		}
+	}
+	setHeader := func(next cliex.RoundTripFunc) cliex.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Trace", "trace-1")
+			return next(req)
+		}
+	}
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithMiddlewares(record("config")))
+	require.NoError(t, err)
+	client.Use(record("first"), setHeader)
+	client.Use(record("second"))
+
+	ctx := context.Background()
+	resp, err := client.Get(ctx, "/")
+	require.NoError(t, err)
+	assert.Equal(t, "trace-1", resp.String())
+	assert.Equal(t, []string{"config", "first", "second"}, calls)
+
+	errDenied := errors.New("denied")
+	client.Use(cliex.OnBeforeRequest(func(req *http.Request) error {
+		if req.URL.Path == "/admin" {
+			return errDenied
+		}
+		return nil
+	}))
+	_, err = client.Get(ctx, "/admin")
+	require.ErrorIs(t, err, errDenied)
+
+	var status int
+	client.Use(cliex.OnAfterResponse(func(resp *http.Response) error {
+		status = resp.StatusCode
+		return nil
+	}))
+	_, err = client.Get(ctx, "/")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestHTTPSet_Use(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	set, err := cliex.NewSetFromConfigs(cliex.Config{BaseURL: srv.URL})
+	require.NoError(t, err)
+
+	var (
+		mu    sync.Mutex
+		hosts []string
+	)
+	set.Use(cliex.OnBeforeRequest(func(req *http.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		hosts = append(hosts, req.URL.Host)
+		return nil
+	}))
+	require.NoError(t, set.Add(cliex.Config{BaseURL: srv.URL}))
+
+	_, err = set.Request(context.Background(), "/", cliex.RequestOpts{})
+	require.NoError(t, err)
+	assert.Len(t, hosts, 2)
+}
@@ -0,0 +1,167 @@
+package cliex
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// mimeTypesByExtension maps file extensions to MIME types from the MIMEType constants.
+var mimeTypesByExtension = map[string]string{
+	".aac":    MIMETypeAAC,
+	".abw":    MIMETypeABW,
+	".apng":   MIMETypeAPNG,
+	".arc":    MIMETypeARC,
+	".avif":   MIMETypeAVIF,
+	".avi":    MIMETypeAVI,
+	".azw":    MIMETypeAZW,
+	".bin":    MIMETypeBIN,
+	".bmp":    MIMETypeBMP,
+	".bz":     MIMETypeBZ,
+	".bz2":    MIMETypeBZ2,
+	".cda":    MIMETypeCDA,
+	".csh":    MIMETypeCSH,
+	".css":    MIMETypeCSS,
+	".csv":    MIMETypeCSV,
+	".doc":    MIMETypeDOC,
+	".docx":   MIMETypeDOCX,
+	".eot":    MIMETypeEOT,
+	".epub":   MIMETypeEPUB,
+	".gz":     MIMETypeGZ,
+	".gif":    MIMETypeGIF,
+	".html":   MIMETypeHTML,
+	".htm":    MIMETypeHTML,
+	".ico":    MIMETypeICO,
+	".ics":    MIMETypeICS,
+	".jar":    MIMETypeJAR,
+	".jpeg":   MIMETypeJPEG,
+	".jpg":    MIMETypeJPEG,
+	".js":     MIMETypeJS,
+	".json":   MIMETypeJSON,
+	".jsonld": MIMETypeJSONLD,
+	".mid":    MIMETypeMIDI,
+	".midi":   MIMETypeMIDI,
+	".mjs":    MIMETypeMJS,
+	".mp3":    MIMETypeMP3,
+	".mp4":    MIMETypeMP4,
+	".mpeg":   MIMETypeMPEG,
+	".mpkg":   MIMETypeMPKG,
+	".odp":    MIMETypeODP,
+	".ods":    MIMETypeODS,
+	".odt":    MIMETypeODT,
+	".oga":    MIMETypeOGA,
+	".ogv":    MIMETypeOGV,
+	".ogx":    MIMETypeOGX,
+	".opus":   MIMETypeOPUS,
+	".otf":    MIMETypeOTF,
+	".png":    MIMETypePNG,
+	".pdf":    MIMETypePDF,
+	".php":    MIMETypePHP,
+	".ppt":    MIMETypePPT,
+	".pptx":   MIMETypePPTX,
+	".rar":    MIMETypeRAR,
+	".rtf":    MIMETypeRTF,
+	".sh":     MIMETypeSH,
+	".svg":    MIMETypeSVG,
+	".tar":    MIMETypeTAR,
+	".tiff":   MIMETypeTIFF,
+	".tif":    MIMETypeTIFF,
+	".ts":     MIMETypeTS,
+	".ttf":    MIMETypeTTF,
+	".txt":    MIMETypeTXT,
+	".vsd":    MIMETypeVSD,
+	".wav":    MIMETypeWAV,
+	".weba":   MIMETypeWEBA,
+	".webm":   MIMETypeWEBM,
+	".webp":   MIMETypeWEBP,
+	".woff":   MIMETypeWOFF,
+	".woff2":  MIMETypeWOFF2,
+	".xhtml":  MIMETypeXHTML,
+	".xls":    MIMETypeXLS,
+	".xlsx":   MIMETypeXLSX,
+	".xml":    MIMETypeXML,
+	".xul":    MIMETypeXUL,
+	".zip":    MIMETypeZIP,
+	".3gp":    MIMEType3GP,
+	".3g2":    MIMEType3G2,
+	".7z":     MIMEType7Z,
+}
+
+// preferredExtensions is used for MIME types that have several extensions.
+var preferredExtensions = map[string]string{
+	MIMETypeHTML: ".html",
+	MIMETypeJPEG: ".jpg",
+	MIMETypeJS:   ".js",
+	MIMETypeMIDI: ".midi",
+	MIMETypeOGA:  ".oga",
+	MIMETypeTIFF: ".tiff",
+}
+
+var extensionsByMIMEType = func() map[string]string {
+	out := make(map[string]string, len(mimeTypesByExtension))
+	for ext, mimeType := range mimeTypesByExtension {
+		out[mimeType] = ext
+	}
+	for mimeType, ext := range preferredExtensions {
+		out[mimeType] = ext
+	}
+	return out
+}()
+
+// MIMETypeByExtension returns the MIME type associated with the file extension (e.g. ".xlsx" or "xlsx").
+// It also accepts a file name or a path. It uses MIMEType constants first and falls back to the system MIME database.
+// It returns MIMETypeBIN if the type is unknown.
+func MIMETypeByExtension(ext string) string {
+	if ext = normalizeExtension(ext); ext == "" {
+		return MIMETypeBIN
+	}
+	if mimeType, ok := mimeTypesByExtension[ext]; ok {
+		return mimeType
+	}
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		return mimeType
+	}
+	return MIMETypeBIN
+}
+
+// ExtensionByMIMEType returns the file extension with a leading dot associated with the MIME type.
+// Parameters of the MIME type (e.g. "; charset=utf-8") are ignored. It returns an empty string if the type is unknown.
+func ExtensionByMIMEType(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(mimeType))
+	}
+	if ext, ok := extensionsByMIMEType[mediaType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// DetectMIMEType detects the MIME type of the content by sniffing up to the first 512 bytes of the reader.
+// The returned reader yields the whole content including sniffed bytes, so use it instead of the provided one.
+func DetectMIMEType(r io.Reader) (string, io.Reader, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	buf = buf[:n]
+	return http.DetectContentType(buf), io.MultiReader(bytes.NewReader(buf), r), nil
+}
+
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if strings.ContainsAny(ext, `/\`) || strings.Count(ext, ".") > 1 || (strings.Contains(ext, ".") && !strings.HasPrefix(ext, ".")) {
+		ext = filepath.Ext(ext)
+	}
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
@@ -0,0 +1,48 @@
+package cliex_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMIMETypeByExtension(t *testing.T) {
+	cases := map[string]string{
+		".xlsx":         cliex.MIMETypeXLSX,
+		"xlsx":          cliex.MIMETypeXLSX,
+		".JPG":          cliex.MIMETypeJPEG,
+		"report.pdf":    cliex.MIMETypePDF,
+		"/tmp/a.tar.gz": cliex.MIMETypeGZ,
+		".unknown-ext":  cliex.MIMETypeBIN,
+		"":              cliex.MIMETypeBIN,
+	}
+	for ext, expected := range cases {
+		assert.Equal(t, expected, cliex.MIMETypeByExtension(ext), ext)
+	}
+}
+
+func TestExtensionByMIMEType(t *testing.T) {
+	assert.Equal(t, ".xlsx", cliex.ExtensionByMIMEType(cliex.MIMETypeXLSX))
+	assert.Equal(t, ".jpg", cliex.ExtensionByMIMEType(cliex.MIMETypeJPEG))
+	assert.Equal(t, ".json", cliex.ExtensionByMIMEType("application/json; charset=utf-8"))
+	assert.Equal(t, "", cliex.ExtensionByMIMEType("application/x-unknown-type"))
+}
+
+func TestDetectMIMEType(t *testing.T) {
+	content := "%PDF-1.4 some pdf content"
+	mimeType, r, err := cliex.DetectMIMEType(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, cliex.MIMETypePDF, mimeType)
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	mimeType, _, err = cliex.DetectMIMEType(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", mimeType)
+}
@@ -0,0 +1,255 @@
+package cliex
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/maxbolgarin/lang"
+)
+
+// MultipartField is the part of the multipart form that is streamed from the reader without buffering in memory.
+type MultipartField struct {
+	// Name is the name of the form field.
+	Name string
+
+	// FileName is the file name of the part, the part is sent as a form value if it is empty.
+	FileName string
+
+	// ContentType is the content type of the part. Default is application/octet-stream for files.
+	ContentType string
+
+	// Reader is the content of the part. It is sent again during retries only if it implements io.Seeker
+	// or GetReader is provided, otherwise the request is not retried (see ErrBodyNotReplayable).
+	Reader io.Reader
+
+	// GetReader returns a new reader of the content for every retry, or for the first attempt if Reader is nil,
+	// e.g. to reopen the file or the S3 object. The reader is closed after it is sent if it implements io.Closer.
+	GetReader func() (io.Reader, error)
+
+	// Size is the size of the content in bytes. If sizes of all parts are known, the request is sent
+	// with Content-Length header, otherwise with chunked encoding. Default is 0, means unknown size.
+	Size int64
+
+	// FS is the file system with the content of the part at Path, e.g. embed.FS or fstest.MapFS.
+	// The file is opened for every attempt if Reader and GetReader are nil,
+	// FileName and Size default to the base name and the size of the file.
+	FS fs.FS
+
+	// Path is the slash-separated path of the file in FS.
+	Path string
+}
+
+// resolveFS fills the reader, the file name and the size of the part from the file at Path in FS.
+func (f MultipartField) resolveFS() (MultipartField, error) {
+	if f.FS == nil || f.Reader != nil || f.GetReader != nil {
+		return f, nil
+	}
+	info, err := fs.Stat(f.FS, f.Path)
+	if err != nil {
+		return f, fmt.Errorf("stat file: %w", err)
+	}
+	fsys, name := f.FS, f.Path
+	f.GetReader = func() (io.Reader, error) { return fsys.Open(name) }
+	f.FileName = lang.Check(f.FileName, path.Base(name))
+	f.Size = lang.Check(f.Size, info.Size())
+	return f, nil
+}
+
+// streamsForm returns true if the multipart form of the request is streamed by multipartBody instead of resty.
+func (o RequestOpts) streamsForm() bool {
+	return len(o.Multipart) > 0 || (o.FilesFS != nil && len(o.Files) > 0)
+}
+
+// multipartBody streams the multipart form of RequestOpts.Multipart, FormData and Files for every attempt.
+type multipartBody struct {
+	boundary string
+	parts    []multipartPart
+	length   int64
+}
+
+type multipartPart struct {
+	field  MultipartField
+	source *bodySource
+}
+
+func newMultipartBody(opts RequestOpts) (*multipartBody, error) {
+	if !opts.streamsForm() {
+		return nil, nil
+	}
+	fields := make([]MultipartField, 0, len(opts.FormData)+len(opts.Files)+len(opts.Multipart))
+	for _, name := range sortedKeys(opts.FormData) {
+		value := opts.FormData[name]
+		fields = append(fields, MultipartField{Name: name, Reader: strings.NewReader(value), Size: int64(len(value))})
+	}
+	for _, name := range sortedKeys(opts.Files) {
+		path := opts.Files[name]
+		if opts.FilesFS != nil {
+			fields = append(fields, MultipartField{Name: name, FS: opts.FilesFS, Path: path})
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("stat file: %w", err)
+		}
+		fields = append(fields, MultipartField{
+			Name:      name,
+			FileName:  filepath.Base(path),
+			GetReader: func() (io.Reader, error) { return os.Open(path) },
+			Size:      info.Size(),
+		})
+	}
+	fields = append(fields, opts.Multipart...)
+
+	b := &multipartBody{boundary: multipart.NewWriter(nil).Boundary(), length: -1}
+	known := true
+	for _, field := range fields {
+		field, err := field.resolveFS()
+		if err != nil {
+			return nil, fmt.Errorf("multipart field %s: %w", field.Name, err)
+		}
+		if field.Reader == nil && field.GetReader == nil {
+			return nil, fmt.Errorf("multipart field %s has no reader", field.Name)
+		}
+		source, err := newBodySource(RequestOpts{BodyReader: field.Reader, GetBody: field.GetReader})
+		if err != nil {
+			return nil, err
+		}
+		b.parts = append(b.parts, multipartPart{field: field, source: source})
+		known = known && field.Size > 0
+	}
+	if known {
+		b.length = b.size()
+	}
+	return b, nil
+}
+
+// contentType returns Content-Type header of the body with the boundary.
+func (b *multipartBody) contentType() string {
+	return "multipart/form-data; boundary=" + b.boundary
+}
+
+// replayable returns true if all parts can be sent again.
+func (b *multipartBody) replayable() bool {
+	if b == nil {
+		return true
+	}
+	for _, part := range b.parts {
+		if !part.source.replayable() {
+			return false
+		}
+	}
+	return true
+}
+
+// open returns the body for the next attempt. Parts are written to the pipe while the transport reads it,
+// the body must be closed to stop writing if the request fails before the body is read.
+func (b *multipartBody) open() io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(b.write(pw))
+	}()
+	return pr
+}
+
+func (b *multipartBody) write(w io.Writer) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+	for _, part := range b.parts {
+		// Readers from GetReader are created for the request, so they are closed after sending
+		owned := part.source.getBody != nil && (part.source.used || part.source.reader == nil)
+		r, err := part.source.next()
+		if err != nil {
+			return fmt.Errorf("multipart field %s: %w", part.field.Name, err)
+		}
+		pw, err := mw.CreatePart(part.header())
+		if err == nil {
+			_, err = io.Copy(pw, r)
+		}
+		if closer, ok := r.(io.Closer); ok && owned {
+			closer.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("multipart field %s: %w", part.field.Name, err)
+		}
+	}
+	return mw.Close()
+}
+
+// size returns the size of the body with known sizes of parts.
+func (b *multipartBody) size() int64 {
+	var counter countingWriter
+	counter.w = io.Discard
+	mw := multipart.NewWriter(&counter)
+	mw.SetBoundary(b.boundary)
+	for _, part := range b.parts {
+		mw.CreatePart(part.header())
+		counter.n += part.field.Size
+	}
+	mw.Close()
+	return counter.n
+}
+
+func (p multipartPart) header() textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader, 2)
+	disposition := `form-data; name="` + escapeQuotes(p.field.Name) + `"`
+	if p.field.FileName != "" {
+		disposition += `; filename="` + escapeQuotes(p.field.FileName) + `"`
+	}
+	h.Set("Content-Disposition", disposition)
+	contentType := p.field.ContentType
+	if contentType == "" && p.field.FileName != "" {
+		contentType = "application/octet-stream"
+	}
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	return h
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// applyStreamBody replaces the body of the request with the streamed body of the attempt,
+// so it is not buffered in memory by resty.
+func applyStreamBody(req *http.Request) {
+	state := getRequestState(req.Context())
+	if state == nil || state.streamBody == nil {
+		return
+	}
+	req.Body, req.GetBody = state.streamBody, nil
+	req.ContentLength = 0
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
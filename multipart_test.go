@@ -0,0 +1,183 @@
+package cliex_test
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Multipart(t *testing.T) {
+	var (
+		attempts int
+		lengths  []int64
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		lengths = append(lengths, r.ContentLength)
+		if r.URL.Path == "/flaky" && attempts == 1 {
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mr, err := r.MultipartReader()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var parts []string
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(part)
+			parts = append(parts, strings.Join([]string{part.FormName(), part.FileName(), part.Header.Get("Content-Type"), string(data)}, ","))
+		}
+		w.Write([]byte(strings.Join(parts, "|")))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	require.NoError(t, os.WriteFile(path, []byte("from disk"), 0o600))
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("generated"))
+		pw.Close()
+	}()
+	resp, err := client.Request(ctx, "/", cliex.RequestOpts{
+		Method:   http.MethodPost,
+		FormData: map[string]string{"title": "report"},
+		Files:    map[string]string{"attachment": path},
+		Multipart: []cliex.MultipartField{
+			{Name: "data", FileName: "data.csv", ContentType: "text/csv", Reader: pr},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "title,,,report|attachment,report.txt,application/octet-stream,from disk|data,data.csv,text/csv,generated", resp.String())
+	assert.EqualValues(t, -1, lengths[0])
+
+	// Parts with known sizes are sent with Content-Length and are sent again during retries
+	attempts, lengths = 0, nil
+	resp, err = client.Request(ctx, "/flaky", cliex.RequestOpts{
+		Method: http.MethodPost,
+		Multipart: []cliex.MultipartField{
+			{Name: "data", FileName: "data.bin", Reader: strings.NewReader("content"), Size: 7},
+		},
+		RetryCount:      2,
+		RetryWaitTime:   time.Millisecond,
+		NoLogRetryError: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "data,data.bin,application/octet-stream,content", resp.String())
+	assert.Equal(t, 2, attempts)
+	assert.Positive(t, lengths[1])
+
+	// Readers without Seek cannot be sent again
+	attempts = 0
+	_, err = client.Request(ctx, "/flaky", cliex.RequestOpts{
+		Method: http.MethodPost,
+		Multipart: []cliex.MultipartField{
+			{Name: "data", Reader: io.MultiReader(strings.NewReader("content"))},
+		},
+		RetryCount:      2,
+		RetryWaitTime:   time.Millisecond,
+		NoLogRetryError: true,
+	})
+	require.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	assert.Equal(t, 1, attempts)
+
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{Method: http.MethodPost, Multipart: []cliex.MultipartField{{Name: "empty"}}})
+	require.Error(t, err)
+}
+
+func TestHTTP_MultipartFS(t *testing.T) {
+	var attempts atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flaky" && attempts.Add(1) == 1 {
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == http.MethodPut {
+			data, _ := io.ReadAll(r.Body)
+			w.Write(data)
+			return
+		}
+		mr, err := r.MultipartReader()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var parts []string
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(part)
+			parts = append(parts, part.FormName()+","+part.FileName()+","+string(data))
+		}
+		w.Write([]byte(strings.Join(parts, "|")))
+	}))
+	defer srv.Close()
+
+	fsys := fstest.MapFS{
+		"assets/logo.svg":  {Data: []byte("<svg/>")},
+		"assets/style.css": {Data: []byte("body{}")},
+	}
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	resp, err := client.Request(ctx, "/flaky", cliex.RequestOpts{
+		Method:  http.MethodPost,
+		Files:   map[string]string{"logo": "assets/logo.svg"},
+		FilesFS: fsys,
+		Multipart: []cliex.MultipartField{
+			{Name: "style", FS: fsys, Path: "assets/style.css"},
+		},
+		RetryCount:      2,
+		RetryWaitTime:   time.Millisecond,
+		NoLogRetryError: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "logo,logo.svg,<svg/>|style,style.css,body{}", resp.String())
+	assert.EqualValues(t, 2, attempts.Load())
+	assert.Positive(t, resp.Request.RawRequest.ContentLength)
+
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{
+		Method:  http.MethodPost,
+		Files:   map[string]string{"missing": "assets/missing.txt"},
+		FilesFS: fsys,
+	})
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	report, err := client.UploadFiles(ctx, "/files/{name}", map[string]string{"logo.svg": "assets/logo.svg"}, 1, cliex.UploadOpts{
+		RequestOpts: cliex.RequestOpts{Method: http.MethodPut, FilesFS: fsys},
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, report.Bytes)
+	assert.Equal(t, "<svg/>", report.Results[0].Response.String())
+}
@@ -0,0 +1,56 @@
+package cliex
+
+import (
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Decoder decodes the response body of the registered content type into v.
+type Decoder func(data []byte, v any) error
+
+// acceptHeader returns the value of Accept header for the list of media types in order of preference.
+// Media types without explicit quality get descending quality values by their position: 1, 0.9, 0.8...
+func acceptHeader(accept []string) string {
+	parts := make([]string, 0, len(accept))
+	for i, mediaType := range accept {
+		mediaType = strings.TrimSpace(mediaType)
+		if mediaType == "" {
+			continue
+		}
+		if i == 0 || strings.Contains(mediaType, ";q=") || strings.Contains(mediaType, "; q=") {
+			parts = append(parts, mediaType)
+			continue
+		}
+		q := max(10-i, 1)
+		parts = append(parts, mediaType+";q=0."+strconv.Itoa(q))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// decodeResult decodes the body of the response that is successful by Config.IsSuccess into result
+// with the decoder registered for its Content-Type. JSON and XML responses are decoded by resty, so they are skipped.
+func decodeResult(decoders map[string]Decoder, isSuccess func(*resty.Response) bool, resp *resty.Response, result any) error {
+	if len(decoders) == 0 || result == nil || len(resp.Body()) == 0 || !isSuccess(resp) {
+		return nil
+	}
+	contentType := resp.Header().Get("Content-Type")
+	if contentType == "" || resty.IsJSONType(contentType) || resty.IsXMLType(contentType) {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	decode, ok := decoders[mediaType]
+	if !ok {
+		return nil
+	}
+	if err := decode(resp.Body(), result); err != nil {
+		return fmt.Errorf("decode %s response: %w", mediaType, err)
+	}
+	return nil
+}
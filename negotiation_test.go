@@ -0,0 +1,91 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type negotiatedUser struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func TestHTTP_Accept(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header.Get("Accept")
+		switch {
+		case strings.HasPrefix(accept, "application/x-kv"):
+			w.Header().Set("Content-Type", "application/x-kv; charset=utf-8")
+			w.Write([]byte("name=kv"))
+		case strings.HasPrefix(accept, "application/xml"):
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte("<user><name>xml</name></user>"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"json"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(
+		cliex.WithBaseURL(srv.URL),
+		cliex.WithDecoder("application/x-kv", func(data []byte, v any) error {
+			_, name, _ := strings.Cut(string(data), "=")
+			v.(*negotiatedUser).Name = name
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		accept   []string
+		header   string
+		expected string
+	}{
+		{[]string{"application/x-kv", "application/json"}, "application/x-kv, application/json;q=0.9", "kv"},
+		{[]string{"application/xml", "application/json;q=0.5"}, "application/xml, application/json;q=0.5", "xml"},
+		{[]string{"application/json", "application/xml", "*/*"}, "application/json, application/xml;q=0.9, */*;q=0.8", "json"},
+	} {
+		var user negotiatedUser
+		resp, err := client.Request(ctx, "/", cliex.RequestOpts{Accept: tc.accept, Result: &user})
+		require.NoError(t, err)
+		assert.Equal(t, tc.header, resp.Request.Header.Get("Accept"))
+		assert.Equal(t, tc.expected, user.Name)
+	}
+}
+
+func TestHTTP_DecoderIsSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-kv")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("name=kv"))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(
+		cliex.WithBaseURL(srv.URL),
+		cliex.WithIsSuccess(func(resp *resty.Response) bool {
+			return resp.StatusCode() < 300 || resp.StatusCode() == http.StatusConflict
+		}),
+		cliex.WithDecoder("application/x-kv", func(data []byte, v any) error {
+			_, name, _ := strings.Cut(string(data), "=")
+			v.(*negotiatedUser).Name = name
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	// Responses that are successful by Config.IsSuccess are decoded with registered decoders
+	var user negotiatedUser
+	_, err = client.Request(context.Background(), "/", cliex.RequestOpts{Result: &user})
+	require.NoError(t, err)
+	assert.Equal(t, "kv", user.Name)
+}
@@ -0,0 +1,175 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const defaultOAuth2ExpiryDelta = 30 * time.Second
+
+// ErrOAuth2Token is returned when the client cannot get the OAuth2 token from Config.OAuth2TokenURL.
+var ErrOAuth2Token = errors.New("cannot get oauth2 token")
+
+// oauth2Transport sets the Bearer token of the OAuth2 client credentials flow to requests without
+// Authorization header. The token is cached until it is about to expire or the server responds with 401.
+type oauth2Transport struct {
+	base         http.RoundTripper
+	client       *http.Client
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        any    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func newOAuth2Transport(base http.RoundTripper, cfg Config) *oauth2Transport {
+	return &oauth2Transport{
+		base:         base,
+		client:       &http.Client{Transport: base, Timeout: cfg.RequestTimeout},
+		tokenURL:     cfg.OAuth2TokenURL,
+		clientID:     cfg.OAuth2ClientID,
+		clientSecret: cfg.OAuth2ClientSecret,
+		scopes:       cfg.OAuth2Scopes,
+	}
+}
+
+func (t *oauth2Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if getHeader(req.Header, "Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+	token, err := t.getToken(req.Context(), "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(withBearerToken(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	// The token may be revoked before expiry, retry once with a fresh one
+	token, err = t.getToken(req.Context(), token)
+	if err != nil {
+		return resp, nil
+	}
+	retryReq := withBearerToken(req, token)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retryReq.Body = body
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return t.base.RoundTrip(retryReq)
+}
+
+func (t *oauth2Transport) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// getToken returns the cached token if it is not expired and differs from the stale one,
+// otherwise it fetches a new token. The lock is held during the fetch, so concurrent requests wait for one token.
+func (t *oauth2Transport) getToken(ctx context.Context, stale string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && t.token != stale && (t.expiry.IsZero() || time.Now().Before(t.expiry)) {
+		return t.token, nil
+	}
+	token, expiresIn, err := t.fetchToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	t.token = token
+	t.expiry = time.Time{}
+	if expiresIn > 0 {
+		t.expiry = time.Now().Add(expiresIn - min(defaultOAuth2ExpiryDelta, expiresIn/2))
+	}
+	return token, nil
+}
+
+func (t *oauth2Transport) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(t.scopes) > 0 {
+		form.Set("scope", strings.Join(t.scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %w", ErrOAuth2Token, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(t.clientID), url.QueryEscape(t.clientSecret))
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %w", ErrOAuth2Token, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: read response: %w", ErrOAuth2Token, err)
+	}
+	var out oauth2TokenResponse
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(body, &out); err != nil && resp.StatusCode < 400 {
+		return "", 0, fmt.Errorf("%w: decode response: %w", ErrOAuth2Token, err)
+	}
+	switch {
+	case out.Error != "":
+		return "", 0, fmt.Errorf("%w: code %d: %s %s", ErrOAuth2Token, resp.StatusCode, out.Error, out.ErrorDescription)
+	case resp.StatusCode >= 400:
+		return "", 0, fmt.Errorf("%w: code %d", ErrOAuth2Token, resp.StatusCode)
+	case out.AccessToken == "":
+		return "", 0, fmt.Errorf("%w: empty access token", ErrOAuth2Token)
+	}
+	return out.AccessToken, parseExpiresIn(out.ExpiresIn), nil
+}
+
+// parseExpiresIn returns the lifetime of the token from expires_in field that is a number or a string of seconds,
+// zero means the token doesn't expire.
+func parseExpiresIn(v any) time.Duration {
+	switch n := v.(type) {
+	case float64:
+		return time.Duration(n * float64(time.Second))
+	case string:
+		seconds, err := strconv.ParseInt(n, 10, 64)
+		if err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 0
+}
+
+func withBearerToken(req *http.Request, token string) *http.Request {
+	out := req.Clone(req.Context())
+	out.Header.Set("Authorization", "Bearer "+token)
+	return out
+}
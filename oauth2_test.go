@@ -0,0 +1,75 @@
+package cliex_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_OAuth2(t *testing.T) {
+	var (
+		fetches atomic.Int64
+		valid   atomic.Value
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		r.ParseForm()
+		if user != "id" || pass != "secret" || r.Form.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		assert.Equal(t, "read write", r.Form.Get("scope"))
+
+		token := "token-" + strconv.Itoa(int(fetches.Add(1)))
+		valid.Store(token)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"` + token + `","token_type":"Bearer","expires_in":3600}`))
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+valid.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithOAuth2(srv.URL+"/token", "id", "secret", "read", "write"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for range 3 {
+		resp, err := client.Post(ctx, "/api", "abc")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", resp.String())
+	}
+	assert.EqualValues(t, 1, fetches.Load())
+
+	// Revoked token is refreshed and the request is retried with the same body
+	valid.Store("revoked")
+	resp, err := client.Post(ctx, "/api", "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", resp.String())
+	assert.EqualValues(t, 2, fetches.Load())
+
+	client, err = cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithOAuth2(srv.URL+"/token", "id", "wrong"))
+	require.NoError(t, err)
+	_, err = client.Get(ctx, "/api")
+	require.ErrorIs(t, err, cliex.ErrOAuth2Token)
+	assert.ErrorContains(t, err, "invalid_client")
+
+	_, err = cliex.New(cliex.WithOAuth2(srv.URL+"/token", "", "secret"))
+	require.Error(t, err)
+}
@@ -0,0 +1,597 @@
+package cliex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrContractViolation is returned when a request or a response doesn't match the OpenAPI spec.
+var ErrContractViolation = errors.New("openapi contract violation")
+
+// maxViolations is the maximum number of violations reported in one error.
+const maxViolations = 10
+
+// OpenAPIValidator checks requests and responses against OpenAPI 3 spec.
+// It supports the common subset of the spec: paths with path, query and header parameters,
+// JSON request and response bodies, local $ref and JSON schema keywords
+// type, nullable, enum, required, properties, additionalProperties, items, allOf, anyOf, oneOf,
+// minimum, maximum, minLength, maxLength, pattern, minItems and maxItems.
+// It is intended for development and test environments.
+type OpenAPIValidator struct {
+	spec     map[string]any
+	basePath string
+	routes   []openAPIRoute
+}
+
+type openAPIRoute struct {
+	method   string
+	path     string
+	segments []string
+	literals int
+	params   []any
+	op       map[string]any
+}
+
+// LoadOpenAPISpec parses OpenAPI 3 spec in JSON or YAML format.
+func LoadOpenAPISpec(data []byte) (*OpenAPIValidator, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse spec: %w", err)
+	}
+	spec, ok := normalizeYAML(raw).(map[string]any)
+	if !ok {
+		return nil, errors.New("spec is not an object")
+	}
+	paths, ok := spec["paths"].(map[string]any)
+	if !ok {
+		return nil, errors.New("spec has no paths")
+	}
+
+	v := &OpenAPIValidator{spec: spec}
+	if servers, ok := spec["servers"].([]any); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]any); ok {
+			if u, err := neturl.Parse(asString(server["url"])); err == nil {
+				v.basePath = strings.TrimSuffix(u.Path, "/")
+			}
+		}
+	}
+
+	for path, item := range paths {
+		pathItem, ok := v.resolve(item).(map[string]any)
+		if !ok {
+			continue
+		}
+		pathParams, _ := pathItem["parameters"].([]any)
+		for method, op := range pathItem {
+			opMap, ok := op.(map[string]any)
+			if !ok || method == "parameters" {
+				continue
+			}
+			route := openAPIRoute{
+				method:   strings.ToUpper(method),
+				path:     path,
+				segments: strings.Split(strings.Trim(path, "/"), "/"),
+				op:       opMap,
+			}
+			for _, s := range route.segments {
+				if !strings.HasPrefix(s, "{") {
+					route.literals++
+				}
+			}
+			opParams, _ := opMap["parameters"].([]any)
+			route.params = v.mergeParams(pathParams, opParams)
+			v.routes = append(v.routes, route)
+		}
+	}
+	// Paths with more literal segments take precedence, e.g. /users/me over /users/{id}
+	sort.SliceStable(v.routes, func(i, j int) bool {
+		if v.routes[i].literals != v.routes[j].literals {
+			return v.routes[i].literals > v.routes[j].literals
+		}
+		return v.routes[i].path < v.routes[j].path
+	})
+
+	return v, nil
+}
+
+// LoadOpenAPISpecFile reads OpenAPI 3 spec in JSON or YAML format from the file.
+func LoadOpenAPISpecFile(path string) (*OpenAPIValidator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read spec: %w", err)
+	}
+	return LoadOpenAPISpec(data)
+}
+
+// ValidateRequest checks method, path, parameters and body of the request.
+// The body is read with req.GetBody if it is set, otherwise the body is not checked.
+func (v *OpenAPIValidator) ValidateRequest(req *http.Request) error {
+	route, pathValues, err := v.findRoute(req)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, p := range route.params {
+		param, ok := v.resolve(p).(map[string]any)
+		if !ok {
+			continue
+		}
+		name, in := asString(param["name"]), asString(param["in"])
+
+		var (
+			value   string
+			present bool
+		)
+		switch in {
+		case "path":
+			value, present = pathValues[name]
+		case "query":
+			present = req.URL.Query().Has(name)
+			value = req.URL.Query().Get(name)
+		case "header":
+			value = getHeader(req.Header, name)
+			present = value != ""
+		default:
+			continue
+		}
+		if !present {
+			if required, _ := param["required"].(bool); required || in == "path" {
+				errs = append(errs, fmt.Sprintf("missing required %s parameter %q", in, name))
+			}
+			continue
+		}
+		if schema, ok := param["schema"].(map[string]any); ok {
+			v.validateParam(schema, value, in+" parameter "+strconv.Quote(name), &errs)
+		}
+	}
+
+	if body, ok := v.resolve(route.op["requestBody"]).(map[string]any); ok {
+		data, readable := readRequestBody(req)
+		required, _ := body["required"].(bool)
+		switch {
+		case !readable:
+		case len(data) == 0 && required:
+			errs = append(errs, "missing required request body")
+		case len(data) > 0:
+			v.validateContent(body["content"], getHeader(req.Header, "Content-Type"), data, "request body", &errs)
+		}
+	}
+
+	return violationError(req.Method+" "+route.path, errs)
+}
+
+// ValidateResponse checks the status code and the body of the response to the request.
+func (v *OpenAPIValidator) ValidateResponse(req *http.Request, statusCode int, header http.Header, body []byte) error {
+	route, _, err := v.findRoute(req)
+	if err != nil {
+		return err
+	}
+
+	responses, _ := route.op["responses"].(map[string]any)
+	code := strconv.Itoa(statusCode)
+	spec, ok := responses[code]
+	if !ok {
+		spec, ok = responses[code[:1]+"XX"]
+	}
+	if !ok {
+		spec, ok = responses["default"]
+	}
+	if !ok {
+		return violationError(req.Method+" "+route.path, []string{"response status " + code + " is not documented"})
+	}
+
+	var errs []string
+	if resp, ok := v.resolve(spec).(map[string]any); ok && len(body) > 0 {
+		v.validateContent(resp["content"], header.Get("Content-Type"), body, "response body", &errs)
+	}
+	return violationError(req.Method+" "+route.path+" "+code, errs)
+}
+
+func (v *OpenAPIValidator) findRoute(req *http.Request) (openAPIRoute, map[string]string, error) {
+	path := req.URL.Path
+	if v.basePath != "" {
+		path = strings.TrimPrefix(path, v.basePath)
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	pathFound := false
+	for _, route := range v.routes {
+		values, ok := matchSegments(route.segments, segments)
+		if !ok {
+			continue
+		}
+		pathFound = true
+		if route.method == req.Method {
+			return route, values, nil
+		}
+	}
+	if pathFound {
+		return openAPIRoute{}, nil, fmt.Errorf("%w: %s %s: method is not defined in spec", ErrContractViolation, req.Method, req.URL.Path)
+	}
+	return openAPIRoute{}, nil, fmt.Errorf("%w: %s %s: path is not defined in spec", ErrContractViolation, req.Method, req.URL.Path)
+}
+
+func matchSegments(template, segments []string) (map[string]string, bool) {
+	if len(template) != len(segments) {
+		return nil, false
+	}
+	values := make(map[string]string)
+	for i, s := range template {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			value, err := neturl.PathUnescape(segments[i])
+			if err != nil {
+				value = segments[i]
+			}
+			values[s[1:len(s)-1]] = value
+			continue
+		}
+		if s != segments[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// mergeParams returns path level parameters overridden by operation level parameters with the same name and location.
+func (v *OpenAPIValidator) mergeParams(pathParams, opParams []any) []any {
+	key := func(p any) string {
+		param, _ := v.resolve(p).(map[string]any)
+		return asString(param["in"]) + ":" + asString(param["name"])
+	}
+	overridden := make(map[string]bool, len(opParams))
+	for _, p := range opParams {
+		overridden[key(p)] = true
+	}
+	out := make([]any, 0, len(pathParams)+len(opParams))
+	for _, p := range pathParams {
+		if !overridden[key(p)] {
+			out = append(out, p)
+		}
+	}
+	return append(out, opParams...)
+}
+
+func (v *OpenAPIValidator) validateContent(content any, contentType string, data []byte, name string, errs *[]string) {
+	media, ok := content.(map[string]any)
+	if !ok || len(media) == 0 {
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	spec, ok := media[mediaType]
+	if !ok {
+		spec, ok = media[strings.Split(mediaType, "/")[0]+"/*"]
+	}
+	if !ok {
+		spec, ok = media["*/*"]
+	}
+	if !ok {
+		*errs = append(*errs, fmt.Sprintf("%s content type %q is not allowed", name, contentType))
+		return
+	}
+	if !strings.Contains(mediaType, "json") {
+		return
+	}
+	specMap, _ := spec.(map[string]any)
+	schema, ok := specMap["schema"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	var value any
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &value); err != nil {
+		*errs = append(*errs, fmt.Sprintf("%s is not valid JSON: %s", name, err))
+		return
+	}
+	v.validateSchema(schema, value, name, errs)
+}
+
+// validateParam converts the string value of the parameter to the type of the schema and validates it.
+func (v *OpenAPIValidator) validateParam(schema map[string]any, raw, name string, errs *[]string) {
+	schema, _ = v.resolve(schema).(map[string]any)
+
+	var value any = raw
+	switch asString(schema["type"]) {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s must be integer, got %q", name, raw))
+			return
+		}
+		value = float64(n)
+	case "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s must be number, got %q", name, raw))
+			return
+		}
+		value = n
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s must be boolean, got %q", name, raw))
+			return
+		}
+		value = b
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range strings.Split(raw, ",") {
+			v.validateParam(items, item, name+"["+strconv.Itoa(i)+"]", errs)
+		}
+		return
+	}
+	v.validateSchema(schema, value, name, errs)
+}
+
+// validateSchema validates the decoded JSON value against the schema.
+func (v *OpenAPIValidator) validateSchema(schemaValue any, value any, name string, errs *[]string) {
+	schema, ok := v.resolve(schemaValue).(map[string]any)
+	if !ok || len(*errs) >= maxViolations {
+		return
+	}
+
+	if all, ok := schema["allOf"].([]any); ok {
+		for _, s := range all {
+			v.validateSchema(s, value, name, errs)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok && v.countMatches(anyOf, value) == 0 {
+		*errs = append(*errs, name+" doesn't match any schema of anyOf")
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok && v.countMatches(oneOf, value) != 1 {
+		*errs = append(*errs, name+" must match exactly one schema of oneOf")
+	}
+
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); !nullable && schema["type"] != nil {
+			*errs = append(*errs, name+" must not be null")
+		}
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !enumContains(enum, value) {
+		*errs = append(*errs, fmt.Sprintf("%s must be one of %v, got %v", name, enum, value))
+	}
+
+	typ := asString(schema["type"])
+	switch val := value.(type) {
+	case map[string]any:
+		if typ != "" && typ != "object" {
+			*errs = append(*errs, fmt.Sprintf("%s must be %s, got object", name, typ))
+			return
+		}
+		v.validateObject(schema, val, name, errs)
+
+	case []any:
+		if typ != "" && typ != "array" {
+			*errs = append(*errs, fmt.Sprintf("%s must be %s, got array", name, typ))
+			return
+		}
+		if n, ok := asFloat(schema["minItems"]); ok && float64(len(val)) < n {
+			*errs = append(*errs, fmt.Sprintf("%s must have at least %v items", name, n))
+		}
+		if n, ok := asFloat(schema["maxItems"]); ok && float64(len(val)) > n {
+			*errs = append(*errs, fmt.Sprintf("%s must have at most %v items", name, n))
+		}
+		for i, item := range val {
+			v.validateSchema(schema["items"], item, name+"["+strconv.Itoa(i)+"]", errs)
+		}
+
+	case string:
+		if typ != "" && typ != "string" {
+			*errs = append(*errs, fmt.Sprintf("%s must be %s, got string", name, typ))
+			return
+		}
+		length := float64(len([]rune(val)))
+		if n, ok := asFloat(schema["minLength"]); ok && length < n {
+			*errs = append(*errs, fmt.Sprintf("%s must be at least %v characters", name, n))
+		}
+		if n, ok := asFloat(schema["maxLength"]); ok && length > n {
+			*errs = append(*errs, fmt.Sprintf("%s must be at most %v characters", name, n))
+		}
+		if pattern := asString(schema["pattern"]); pattern != "" {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(val) {
+				*errs = append(*errs, fmt.Sprintf("%s must match pattern %q", name, pattern))
+			}
+		}
+
+	case float64:
+		if typ != "" && typ != "number" && typ != "integer" {
+			*errs = append(*errs, fmt.Sprintf("%s must be %s, got number", name, typ))
+			return
+		}
+		if typ == "integer" && val != float64(int64(val)) {
+			*errs = append(*errs, fmt.Sprintf("%s must be integer, got %v", name, val))
+		}
+		if n, ok := asFloat(schema["minimum"]); ok && val < n {
+			*errs = append(*errs, fmt.Sprintf("%s must be >= %v, got %v", name, n, val))
+		}
+		if n, ok := asFloat(schema["maximum"]); ok && val > n {
+			*errs = append(*errs, fmt.Sprintf("%s must be <= %v, got %v", name, n, val))
+		}
+
+	case bool:
+		if typ != "" && typ != "boolean" {
+			*errs = append(*errs, fmt.Sprintf("%s must be %s, got boolean", name, typ))
+		}
+	}
+}
+
+func (v *OpenAPIValidator) validateObject(schema map[string]any, obj map[string]any, name string, errs *[]string) {
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			if _, ok := obj[asString(r)]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s is missing required property %q", name, asString(r)))
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if prop, ok := properties[k]; ok {
+			v.validateSchema(prop, obj[k], name+"."+k, errs)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errs = append(*errs, fmt.Sprintf("%s has unknown property %q", name, k))
+			}
+		case map[string]any:
+			v.validateSchema(additional, obj[k], name+"."+k, errs)
+		}
+	}
+}
+
+func (v *OpenAPIValidator) countMatches(schemas []any, value any) int {
+	var n int
+	for _, s := range schemas {
+		var errs []string
+		v.validateSchema(s, value, "", &errs)
+		if len(errs) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// resolve follows local $ref of the value, e.g. "#/components/schemas/User".
+func (v *OpenAPIValidator) resolve(value any) any {
+	for range 32 {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return value
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil
+		}
+		var cur any = v.spec
+		for _, part := range strings.Split(ref[2:], "/") {
+			part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+			curMap, ok := cur.(map[string]any)
+			if !ok {
+				return nil
+			}
+			cur = curMap[part]
+		}
+		value = cur
+	}
+	return nil
+}
+
+func readRequestBody(req *http.Request) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer body.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, body); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+func violationError(operation string, errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) > maxViolations {
+		errs = append(errs[:maxViolations], "...")
+	}
+	return fmt.Errorf("%w: %s: %s", ErrContractViolation, operation, strings.Join(errs, "; "))
+}
+
+// normalizeYAML converts maps with non-string keys (e.g. response codes) to map[string]any.
+func normalizeYAML(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			val[k] = normalizeYAML(item)
+		}
+		return val
+	case map[any]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[fmt.Sprint(k)] = normalizeYAML(item)
+		}
+		return out
+	case []any:
+		for i, item := range val {
+			val[i] = normalizeYAML(item)
+		}
+		return val
+	}
+	return v
+}
+
+func enumContains(enum []any, value any) bool {
+	for _, e := range enum {
+		if f, ok := asFloat(e); ok {
+			if vf, ok := value.(float64); ok && f == vf {
+				return true
+			}
+			continue
+		}
+		if e == value {
+			return true
+		}
+	}
+	return false
+}
+
+func asString(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func asFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
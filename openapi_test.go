@@ -0,0 +1,116 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.3
+servers:
+  - url: http://localhost/v1
+paths:
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema: {type: integer}
+    get:
+      parameters:
+        - name: fields
+          in: query
+          schema: {type: string, enum: [name, email]}
+      responses:
+        200:
+          description: user
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/User'}
+  /users:
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/User'}
+      responses:
+        '201': {description: created}
+components:
+  schemas:
+    User:
+      type: object
+      required: [name]
+      additionalProperties: false
+      properties:
+        id: {type: integer}
+        name: {type: string, minLength: 1}
+`
+
+func TestHTTP_OpenAPIValidation(t *testing.T) {
+	var hits atomic.Int64
+	var userBody atomic.Value
+	userBody.Store(`{"id":1,"name":"Alice"}`)
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Write([]byte(userBody.Load().(string)))
+	}))
+	defer mockServer.Close()
+
+	specFile := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, os.WriteFile(specFile, []byte(testOpenAPISpec), 0o600))
+
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:         mockServer.URL + "/v1",
+		OpenAPISpecFile: specFile,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = client.Request(ctx, "/users/1", cliex.RequestOpts{Query: map[string]string{"fields": "name"}})
+	require.NoError(t, err)
+	_, err = client.Post(ctx, "/users", map[string]any{"name": "Bob"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), hits.Load())
+
+	// Invalid requests are not sent
+	_, err = client.Request(ctx, "/users/abc", cliex.RequestOpts{Query: map[string]string{"fields": "phone"}, RetryCount: 3})
+	assert.ErrorIs(t, err, cliex.ErrContractViolation)
+	assert.ErrorContains(t, err, `path parameter "id" must be integer`)
+	assert.ErrorContains(t, err, `query parameter "fields" must be one of`)
+
+	_, err = client.Post(ctx, "/users", map[string]any{"name": "", "role": "admin"})
+	assert.ErrorIs(t, err, cliex.ErrContractViolation)
+	assert.ErrorContains(t, err, "request body.name must be at least 1 characters")
+	assert.ErrorContains(t, err, `request body has unknown property "role"`)
+
+	_, err = client.Delete(ctx, "/users/1")
+	assert.ErrorContains(t, err, "method is not defined in spec")
+	_, err = client.Get(ctx, "/orders")
+	assert.ErrorContains(t, err, "path is not defined in spec")
+	assert.Equal(t, int64(2), hits.Load())
+
+	// Invalid responses are not retried
+	userBody.Store(`{"id":"1"}`)
+	_, err = client.Request(ctx, "/users/1", cliex.RequestOpts{RetryCount: 3})
+	assert.ErrorIs(t, err, cliex.ErrContractViolation)
+	assert.ErrorContains(t, err, `response body is missing required property "name"`)
+	assert.ErrorContains(t, err, "response body.id must be integer, got string")
+	assert.Equal(t, int64(3), hits.Load())
+}
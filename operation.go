@@ -0,0 +1,166 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/maxbolgarin/lang"
+)
+
+const defaultPollInterval = time.Second
+
+var (
+	// ErrNoOperationLocation is returned by AwaitOperation when 202 response has no status URL.
+	ErrNoOperationLocation = errors.New("no operation location")
+	// ErrOperationFailed is returned by AwaitOperation when the operation finished with failed or canceled status.
+	ErrOperationFailed = errors.New("operation failed")
+)
+
+// OperationOpts is the options for polling long-running operations.
+type OperationOpts struct {
+	// RequestOpts is the options of polling requests (headers, auth, retries). Method is always GET.
+	// Result receives the body of the terminal resource.
+	RequestOpts
+
+	// PollInterval is the wait time between polls if the server doesn't send Retry-After. Default is 1 second.
+	PollInterval time.Duration
+
+	// IsDone overrides the detection of the terminal state of the operation by the poll response.
+	// Return an error to stop polling with the failed operation.
+	// Default detects status 202 as running and "status" (Azure) or "done" (Google) fields of JSON body.
+	IsDone func(resp *resty.Response) (bool, error)
+}
+
+// AwaitOperation waits for the long-running operation started by the request with the response resp.
+// If resp is 202 Accepted, it polls the URL from Operation-Location, Azure-AsyncOperation or Location header
+// honoring Retry-After until the operation is finished and returns the response of the terminal resource.
+// If the finished operation has "resourceLocation" field, the resource is requested from it.
+// Responses other than 202 are returned as they are.
+func (c *HTTP) AwaitOperation(ctx context.Context, resp *resty.Response, opts OperationOpts) (*resty.Response, error) {
+	if resp == nil || resp.StatusCode() != http.StatusAccepted {
+		return resp, nil
+	}
+	statusURL, err := operationLocation(resp)
+	if err != nil {
+		return resp, err
+	}
+
+	isDone := lang.If(opts.IsDone != nil, opts.IsDone, isOperationDone)
+	pollOpts := opts.RequestOpts
+	pollOpts.Method = http.MethodGet
+	pollOpts.Result = nil
+	pollOpts.RequestName = lang.Check(pollOpts.RequestName, "operation status")
+
+	for {
+		wait := lang.Check(opts.PollInterval, defaultPollInterval)
+		if retryAfter, ok := parseRetryAfter(resp.Header().Get("Retry-After"), time.Now()); ok {
+			wait = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return resp, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		resp, err = c.Request(ctx, statusURL, pollOpts)
+		if err != nil {
+			return resp, fmt.Errorf("poll operation: %w", err)
+		}
+		if resp.StatusCode() == http.StatusAccepted {
+			// Status URL can be updated by the server
+			if next, err := operationLocation(resp); err == nil {
+				statusURL = next
+			}
+			continue
+		}
+
+		done, err := isDone(resp)
+		if err != nil {
+			return resp, err
+		}
+		if done {
+			break
+		}
+	}
+
+	var status struct {
+		ResourceLocation string `json:"resourceLocation"`
+	}
+	_ = jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(resp.Body(), &status)
+	if status.ResourceLocation != "" {
+		resp, err = c.Request(ctx, status.ResourceLocation, pollOpts)
+		if err != nil {
+			return resp, fmt.Errorf("get operation resource: %w", err)
+		}
+	}
+
+	if opts.Result != nil && len(resp.Body()) > 0 {
+		if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(resp.Body(), opts.Result); err != nil {
+			return resp, fmt.Errorf("unmarshal operation result: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// operationLocation returns the absolute URL of the operation status.
+func operationLocation(resp *resty.Response) (string, error) {
+	for _, h := range []string{"Operation-Location", "Azure-AsyncOperation", "Location"} {
+		location := resp.Header().Get(h)
+		if location == "" {
+			continue
+		}
+		u, err := url.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("parse %s: %w", h, err)
+		}
+		if resp.RawResponse != nil && resp.RawResponse.Request != nil {
+			u = resp.RawResponse.Request.URL.ResolveReference(u)
+		}
+		return u.String(), nil
+	}
+	return "", ErrNoOperationLocation
+}
+
+// isOperationDone detects the terminal state of Azure ("status") and Google ("done") operations.
+// Responses without these fields are terminal.
+func isOperationDone(resp *resty.Response) (bool, error) {
+	var op struct {
+		Status *string             `json:"status"`
+		Done   *bool               `json:"done"`
+		Error  jsoniter.RawMessage `json:"error"`
+	}
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(resp.Body(), &op); err != nil {
+		return true, nil
+	}
+	operationErr := func(status string) error {
+		if len(op.Error) > 0 && string(op.Error) != "null" {
+			return fmt.Errorf("%w: %s: %s", ErrOperationFailed, status, maxLen(string(op.Error), 200))
+		}
+		return fmt.Errorf("%w: %s", ErrOperationFailed, status)
+	}
+
+	switch {
+	case op.Done != nil:
+		if *op.Done && len(op.Error) > 0 && string(op.Error) != "null" {
+			return true, operationErr("done with error")
+		}
+		return *op.Done, nil
+
+	case op.Status != nil:
+		switch strings.ToLower(*op.Status) {
+		case "succeeded", "success", "completed", "done":
+			return true, nil
+		case "failed", "canceled", "cancelled":
+			return true, operationErr(*op.Status)
+		}
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,84 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_AwaitOperation(t *testing.T) {
+	var polls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Operation-Location", "/operations/1")
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/operations/1", func(w http.ResponseWriter, r *http.Request) {
+		if polls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.Write([]byte(`{"status":"Running"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"Succeeded","resourceLocation":"/resources/1"}`))
+	})
+	mux.HandleFunc("/resources/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"1","name":"job"}`))
+	})
+	mux.HandleFunc("/google", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/operations/2")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/operations/2", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"done":true,"error":{"code":3,"message":"bad input"}}`))
+	})
+	mux.HandleFunc("/nolocation", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	resp, err := client.Post(ctx, "/jobs", nil)
+	require.NoError(t, err)
+
+	var result struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	resp, err = client.AwaitOperation(ctx, resp, cliex.OperationOpts{
+		RequestOpts: cliex.RequestOpts{Result: &result},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, int32(3), polls.Load())
+	assert.Equal(t, "1", result.ID)
+	assert.Equal(t, "job", result.Name)
+
+	resp, err = client.Post(ctx, "/google", nil)
+	require.NoError(t, err)
+	_, err = client.AwaitOperation(ctx, resp, cliex.OperationOpts{PollInterval: time.Millisecond})
+	require.ErrorIs(t, err, cliex.ErrOperationFailed)
+	assert.Contains(t, err.Error(), "bad input")
+
+	resp, err = client.Post(ctx, "/nolocation", nil)
+	require.NoError(t, err)
+	_, err = client.AwaitOperation(ctx, resp, cliex.OperationOpts{})
+	require.ErrorIs(t, err, cliex.ErrNoOperationLocation)
+
+	resp, err = client.Get(ctx, "/resources/1")
+	require.NoError(t, err)
+	same, err := client.AwaitOperation(ctx, resp, cliex.OperationOpts{})
+	require.NoError(t, err)
+	assert.Same(t, resp, same)
+}
@@ -0,0 +1,170 @@
+package cliex
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// OutputEncoding is the mode of saving compressed responses to RequestOpts.OutputPath.
+type OutputEncoding string
+
+const (
+	// OutputEncodingAuto saves the content as it is returned by the transport: it is decompressed transparently
+	// if the request has no Accept-Encoding header, and saved as is otherwise.
+	OutputEncodingAuto OutputEncoding = ""
+	// OutputDecompress always saves the decompressed content, gzip and deflate bodies are decoded
+	// according to Content-Encoding even if Accept-Encoding is set in the request.
+	OutputDecompress OutputEncoding = "decompress"
+	// OutputRaw saves the raw compressed bytes as they are sent by the server.
+	// It requests gzip encoding if Accept-Encoding is not set in the request.
+	OutputRaw OutputEncoding = "raw"
+)
+
+// maxErrorBodySize is the maximum size of the error body that is read when the response is saved to a file.
+const maxErrorBodySize = 1 << 20
+
+// saveOutput writes the not parsed response body to opts.OutputPath, the body of unsuccessful response is returned
+// as error and the file is not touched. The body is written to a temporary file in the same directory that replaces
+// the output file on success and is removed on failure or cancellation.
+func saveOutput(resp *resty.Response, opts RequestOpts, success bool) (err error) {
+	raw := resp.RawBody()
+	if raw == nil {
+		return nil
+	}
+	defer raw.Close()
+
+	if !success {
+		return rawStatusError(resp)
+	}
+
+	var (
+		body  io.Reader = raw
+		total           = resp.RawResponse.ContentLength
+	)
+	if opts.OutputEncoding == OutputDecompress {
+		decoded, err := decodeContent(resp.Header().Get("Content-Encoding"), raw)
+		if err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		if decoded != nil {
+			defer decoded.Close()
+			// Size of decompressed content is unknown until it is read
+			body, total = decoded, -1
+		}
+	}
+
+	dir := filepath.Dir(opts.OutputPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+	file, err := os.CreateTemp(dir, "."+filepath.Base(opts.OutputPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+	defer func() {
+		file.Close()
+		if err != nil {
+			os.Remove(file.Name())
+		}
+	}()
+
+	var w io.Writer = file
+	if opts.TeeWriter != nil {
+		w = io.MultiWriter(w, opts.TeeWriter)
+	}
+	if opts.OnDownloadProgress != nil {
+		w = &progressWriter{w: w, total: total, f: opts.OnDownloadProgress}
+	}
+
+	if _, err := io.Copy(w, body); err != nil {
+		return fmt.Errorf("save response: %w", err)
+	}
+	return commitOutput(file, opts)
+}
+
+// commitOutput closes the temporary file and renames it to opts.OutputPath, so the file is replaced atomically
+// and readers never see a partially written file. The file keeps the mode of the replaced file.
+func commitOutput(file *os.File, opts RequestOpts) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(opts.OutputPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := file.Chmod(mode); err != nil {
+		return fmt.Errorf("chmod output file: %w", err)
+	}
+	if opts.OutputSync {
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("sync output file: %w", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close output file: %w", err)
+	}
+	if err := os.Rename(file.Name(), opts.OutputPath); err != nil {
+		return fmt.Errorf("rename output file: %w", err)
+	}
+	return nil
+}
+
+// rawStatusError reads the not parsed body of the unsuccessful response (up to 1 MB), closes it and returns APIError.
+func rawStatusError(resp *resty.Response) error {
+	var body []byte
+	if raw := resp.RawBody(); raw != nil {
+		body, _ = io.ReadAll(io.LimitReader(raw, maxErrorBodySize))
+		raw.Close()
+	}
+	return statusError(resp.StatusCode(), resp.Header(), decodeErrorBody(resp.Header().Get("Content-Encoding"), body))
+}
+
+// decodeContent returns a reader of decoded content, nil if the encoding is not supported.
+func decodeContent(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		return zlib.NewReader(r)
+	}
+	return nil, nil
+}
+
+// decodeErrorBody returns the error body decoded according to Content-Encoding, so it can be parsed
+// when transparent decompression of the transport is disabled. The original body is returned if it cannot be decoded.
+func decodeErrorBody(encoding string, body []byte) []byte {
+	if len(body) == 0 || encoding == "" {
+		return body
+	}
+	decoded, err := decodeContent(encoding, bytes.NewReader(body))
+	if err != nil || decoded == nil {
+		return body
+	}
+	defer decoded.Close()
+
+	out, err := io.ReadAll(io.LimitReader(decoded, maxErrorBodySize))
+	if err != nil && len(out) == 0 {
+		return body
+	}
+	return out
+}
+
+// progressWriter reports the number of written bytes after every write.
+type progressWriter struct {
+	w     io.Writer
+	done  int64
+	total int64
+	f     func(bytesDone, totalBytes int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.done += int64(n)
+	p.f(p.done, p.total)
+	return n, err
+}
@@ -0,0 +1,207 @@
+package cliex_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_OutputEncoding(t *testing.T) {
+	content := strings.Repeat("compressed content ", 1000)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			http.Error(w, "broken file", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Length", strconv.Itoa(compressed.Len()))
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// Transport decompresses content by itself
+	path := filepath.Join(dir, "auto.txt")
+	_, err = client.Request(ctx, "/file", cliex.RequestOpts{OutputPath: path})
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	// Raw compressed bytes
+	var done, total int64
+	path = filepath.Join(dir, "raw.gz")
+	_, err = client.Request(ctx, "/file", cliex.RequestOpts{
+		OutputPath:     path,
+		OutputEncoding: cliex.OutputRaw,
+		OnDownloadProgress: func(bytesDone, totalBytes int64) {
+			done, total = bytesDone, totalBytes
+		},
+	})
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, compressed.Bytes(), data)
+	assert.Equal(t, int64(compressed.Len()), done)
+	assert.Equal(t, int64(compressed.Len()), total)
+
+	// Decompress even with custom Accept-Encoding
+	var tee bytes.Buffer
+	path = filepath.Join(dir, "decompressed.txt")
+	_, err = client.Request(ctx, "/file", cliex.RequestOpts{
+		Headers:        map[string]string{"Accept-Encoding": "gzip"},
+		OutputPath:     path,
+		OutputEncoding: cliex.OutputDecompress,
+		TeeWriter:      &tee,
+		OnDownloadProgress: func(bytesDone, totalBytes int64) {
+			done, total = bytesDone, totalBytes
+		},
+	})
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+	assert.Equal(t, content, tee.String())
+	assert.Equal(t, int64(len(content)), done)
+	assert.Equal(t, int64(-1), total)
+
+	// Error body is returned in error
+	_, err = client.Request(ctx, "/error", cliex.RequestOpts{OutputPath: filepath.Join(dir, "error.txt")})
+	assert.ErrorIs(t, err, cliex.ErrInternalServerError)
+	assert.ErrorContains(t, err, "broken file")
+}
+
+func TestHTTP_Compression(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Encoding")))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	body, err := client.GetString(ctx, "/")
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", body)
+
+	resp, err := client.Request(ctx, "/", cliex.RequestOpts{IdentityEncoding: true})
+	require.NoError(t, err)
+	assert.Equal(t, "identity", string(resp.Body()))
+
+	client, err = cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithDisableCompression(true))
+	require.NoError(t, err)
+
+	body, err = client.GetString(ctx, "/")
+	require.NoError(t, err)
+	assert.Empty(t, body)
+}
+
+func TestHTTP_CompressedErrorBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write([]byte(`{"error":"quota exceeded"}`))
+		_ = zw.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	// Custom Accept-Encoding disables transparent decompression
+	opts := cliex.RequestOpts{Headers: map[string]string{"Accept-Encoding": "gzip"}}
+	_, err = client.Request(context.Background(), "/", opts)
+	assert.ErrorIs(t, err, cliex.ErrForbidden)
+	assert.ErrorContains(t, err, "quota exceeded")
+
+	opts.OutputPath = filepath.Join(t.TempDir(), "out.json")
+	_, err = client.Request(context.Background(), "/", opts)
+	assert.ErrorIs(t, err, cliex.ErrForbidden)
+	assert.ErrorContains(t, err, "quota exceeded")
+}
+
+func TestHTTP_OutputErrorBody(t *testing.T) {
+	errBody := strings.Repeat("quota exceeded ", 20)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(errBody))
+		case "/ok":
+			w.Write([]byte("ok"))
+		case "/partial":
+			w.Header().Set("Content-Length", "1000")
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "out.bin")
+	require.NoError(t, os.WriteFile(path, []byte("previous"), 0o600))
+
+	_, err = client.Request(ctx, "/error", cliex.RequestOpts{OutputPath: path})
+	require.ErrorIs(t, err, cliex.ErrForbidden)
+	assert.Equal(t, errBody, string(cliex.ErrorBody(err)))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "previous", string(data))
+
+	_, err = client.Request(ctx, "/partial", cliex.RequestOpts{OutputPath: path})
+	require.Error(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "previous", string(data))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	_, err = client.Request(ctx, "/ok", cliex.RequestOpts{OutputPath: path, OutputSync: true})
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	_, err = client.Request(ctx, "/error", cliex.RequestOpts{})
+	require.ErrorIs(t, err, cliex.ErrForbidden)
+	assert.Equal(t, errBody, string(cliex.ErrorBody(err)))
+}
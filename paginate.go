@@ -0,0 +1,277 @@
+package cliex
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/maxbolgarin/lang"
+)
+
+const defaultCursorParam = "cursor"
+
+// ErrSameCursor is returned by Paginate when the cursor or the link of the next page is the same
+// as the one of the requested page, the walk would never end.
+var ErrSameCursor = errors.New("next page has the same cursor")
+
+// PageMode is the way the position of the next page is passed to the list endpoint, see PageOpts.Mode.
+type PageMode string
+
+const (
+	// PageCursor passes the cursor from the body of the previous page in the query parameter.
+	PageCursor PageMode = ""
+	// PageLink requests the URL of the Link header (RFC 8288) with rel="next" of the previous page.
+	PageLink PageMode = "link"
+	// PageNumber passes the number of the page in the query parameter, the walk stops on the page without items.
+	PageNumber PageMode = "page"
+	// PageOffset passes the number of already received items in the query parameter,
+	// the walk stops on the page without items.
+	PageOffset PageMode = "offset"
+)
+
+// PageOpts is the options of the pagination.
+type PageOpts struct {
+	// Mode is the way the position of the next page is passed. Default is PageCursor.
+	Mode PageMode
+
+	// CursorParam is the query parameter with the cursor of the requested page.
+	// Default is "cursor", "page" for PageNumber and "offset" for PageOffset.
+	CursorParam string
+
+	// Cursor is the cursor of the first page: the number of the page for PageNumber, the offset for PageOffset
+	// or the URL for PageLink. Default is empty, means the first page is requested without cursor,
+	// its number is 1 for PageNumber and its offset is 0 for PageOffset.
+	Cursor string
+
+	// NextCursor returns the cursor of the next page from the response, empty cursor means the last page.
+	// Default depends on Mode, for PageCursor it is the "next_cursor", "nextCursor", "next_page_token"
+	// or "nextPageToken" field of JSON body.
+	NextCursor func(resp *resty.Response) (string, error)
+
+	// ItemsPath is the JSON path of the array of items in the page, e.g. "$.users". It is used to count items
+	// for PageNumber and PageOffset and to decode items in PaginateItems.
+	// Default is the array body or the "items", "data" or "results" field of JSON body.
+	ItemsPath string
+
+	// PageSize is the maximum number of items in the page. If it is set, the page with fewer items is the last one,
+	// so the empty page is not requested. It is not sent to the server, set the limit in RequestOpts.Query.
+	PageSize int
+
+	// LoadCheckpoint returns the cursor saved by SaveCheckpoint to resume the walk after restart.
+	// Empty cursor means the walk starts from Cursor.
+	LoadCheckpoint func(ctx context.Context) (string, error)
+
+	// SaveCheckpoint is called with the cursor of the next page after the page is handled without error,
+	// so the walk can be resumed from it. Empty cursor is saved when the walk is finished.
+	SaveCheckpoint func(ctx context.Context, cursor string) error
+
+	// PageInterval is the minimum wait time between page requests. If the server reports the exhausted
+	// rate limit quota in headers (see RateLimitInfo), the next page is requested after the quota reset.
+	// Default is 0, means pages are requested without waiting unless the quota is exhausted.
+	PageInterval time.Duration
+}
+
+// Paginate requests pages of the list endpoint one by one passing the position of the next page according
+// to PageOpts.Mode and calls onPage for every page, use resp.Result() or resp.Body() to get the items.
+// It stops on the last page, on the first error, when onPage returns an error or with ErrSameCursor
+// when the server returns the cursor or the link of the requested page as the next one.
+func (c *HTTP) Paginate(ctx context.Context, url string, opts RequestOpts, pageOpts PageOpts, onPage func(resp *resty.Response) error) error {
+	switch pageOpts.Mode {
+	case PageCursor, PageLink:
+		pageOpts.CursorParam = lang.Check(pageOpts.CursorParam, defaultCursorParam)
+	case PageNumber, PageOffset:
+		pageOpts.CursorParam = lang.Check(pageOpts.CursorParam, string(pageOpts.Mode))
+	default:
+		return fmt.Errorf("unknown page mode %q", pageOpts.Mode)
+	}
+
+	cursor := pageOpts.Cursor
+	if pageOpts.LoadCheckpoint != nil {
+		saved, err := pageOpts.LoadCheckpoint(ctx)
+		if err != nil {
+			return fmt.Errorf("load checkpoint: %w", err)
+		}
+		cursor = lang.Check(saved, cursor)
+	}
+
+	for page := 0; ; page++ {
+		pageURL, pageReqOpts := url, opts
+		switch {
+		case cursor == "":
+		case pageOpts.Mode == PageLink:
+			// Query of the next page is in the link
+			pageURL, pageReqOpts.Query, pageReqOpts.QueryValues = cursor, nil, nil
+		default:
+			pageReqOpts.Query = maps.Clone(opts.Query)
+			if pageReqOpts.Query == nil {
+				pageReqOpts.Query = make(map[string]string, 1)
+			}
+			pageReqOpts.Query[pageOpts.CursorParam] = cursor
+		}
+
+		resp, err := c.Request(ctx, pageURL, pageReqOpts)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", page, err)
+		}
+		if err := onPage(resp); err != nil {
+			return fmt.Errorf("page %d: %w", page, err)
+		}
+
+		next, err := pageOpts.nextCursor(resp, cursor)
+		if err != nil {
+			return fmt.Errorf("page %d: next cursor: %w", page, err)
+		}
+		if next != "" && (next == cursor || (pageOpts.Mode == PageLink && resp.Request != nil && next == resp.Request.URL)) {
+			return fmt.Errorf("page %d: %w: %s", page, ErrSameCursor, next)
+		}
+		cursor = next
+		if pageOpts.SaveCheckpoint != nil {
+			if err := pageOpts.SaveCheckpoint(ctx, cursor); err != nil {
+				return fmt.Errorf("save checkpoint: %w", err)
+			}
+		}
+		if cursor == "" {
+			return nil
+		}
+
+		wait := pageOpts.PageInterval
+		if status, ok := RateLimitInfo(resp); ok && status.Exhausted() {
+			wait = max(wait, status.WaitTime())
+		}
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+}
+
+// PaginateItems requests pages like Paginate and calls onItem for every item of pages decoded into T,
+// items are taken from the array of PageOpts.ItemsPath. It stops when onItem returns an error.
+func PaginateItems[T any](ctx context.Context, c *HTTP, url string, opts RequestOpts, pageOpts PageOpts, onItem func(item T) error) error {
+	return c.Paginate(ctx, url, opts, pageOpts, func(resp *resty.Response) error {
+		items, err := pageItems(resp, pageOpts.ItemsPath)
+		if err != nil {
+			return err
+		}
+		for i, item := range items {
+			var value T
+			if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(item, &value); err != nil {
+				return fmt.Errorf("decode item %d: %w", i, err)
+			}
+			if err := onItem(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// nextCursor returns the cursor of the page after the page with the cursor.
+func (o PageOpts) nextCursor(resp *resty.Response, cursor string) (string, error) {
+	if o.NextCursor != nil {
+		return o.NextCursor(resp)
+	}
+	switch o.Mode {
+	case PageLink:
+		next := parseLinks(resp.Header())["next"]
+		if next == "" || resp.Request == nil {
+			return next, nil
+		}
+		base, err := neturl.Parse(resp.Request.URL)
+		if err != nil {
+			return next, nil
+		}
+		ref, err := neturl.Parse(next)
+		if err != nil {
+			return "", fmt.Errorf("parse next link: %w", err)
+		}
+		return base.ResolveReference(ref).String(), nil
+
+	case PageNumber, PageOffset:
+		items, err := pageItems(resp, o.ItemsPath)
+		if err != nil {
+			return "", err
+		}
+		if len(items) == 0 || len(items) < o.PageSize {
+			return "", nil
+		}
+		position, err := strconv.Atoi(lang.Check(cursor, lang.If(o.Mode == PageNumber, "1", "0")))
+		if err != nil {
+			return "", fmt.Errorf("invalid cursor %q: %w", cursor, err)
+		}
+		return strconv.Itoa(position + lang.If(o.Mode == PageNumber, 1, len(items))), nil
+	}
+	return defaultNextCursor(resp)
+}
+
+// pageItems returns raw JSON items of the page from the array of the path.
+func pageItems(resp *resty.Response, path string) ([]jsoniter.RawMessage, error) {
+	if len(resp.Body()) == 0 {
+		return nil, nil
+	}
+	// Numbers are kept as they are, so large ids are not rounded when items are decoded again
+	var body any
+	decoder := jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(bytes.NewReader(resp.Body()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode page: %w", err)
+	}
+	var (
+		value any
+		ok    bool
+	)
+	if path != "" {
+		value, ok = lookupJSONPath(body, path)
+	} else if _, ok = body.([]any); ok {
+		value = body
+	} else {
+		for _, field := range []string{"items", "data", "results"} {
+			if value, ok = lookupJSONPath(body, "$."+field); ok {
+				break
+			}
+		}
+	}
+	if !ok || value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("items of the page is %T, not an array", value)
+	}
+	out := make([]jsoniter.RawMessage, 0, len(items))
+	for _, item := range items {
+		data, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return out, nil
+}
+
+func defaultNextCursor(resp *resty.Response) (string, error) {
+	var body struct {
+		NextCursor         string `json:"next_cursor"`
+		NextCursorCamel    string `json:"nextCursor"`
+		NextPageToken      string `json:"next_page_token"`
+		NextPageTokenCamel string `json:"nextPageToken"`
+	}
+	if len(resp.Body()) == 0 {
+		return "", nil
+	}
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(resp.Body(), &body); err != nil {
+		return "", err
+	}
+	return cmp.Or(body.NextCursor, body.NextCursorCamel, body.NextPageToken, body.NextPageTokenCamel), nil
+}
@@ -0,0 +1,164 @@
+package cliex_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Paginate(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"items":[1,2],"next_cursor":"c2"}`,
+		"c2": `{"items":[3,4],"next_cursor":"c3"}`,
+		"c3": `{"items":[5]}`,
+	}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("cursor")
+		requested = append(requested, cursor)
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		w.Write([]byte(pages[cursor]))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	type page struct {
+		Items []int `json:"items"`
+	}
+
+	var (
+		checkpoint string
+		items      []int
+		errCrash   = errors.New("crash")
+	)
+	pageOpts := cliex.PageOpts{
+		LoadCheckpoint: func(context.Context) (string, error) { return checkpoint, nil },
+		SaveCheckpoint: func(_ context.Context, cursor string) error {
+			checkpoint = cursor
+			return nil
+		},
+	}
+	opts := cliex.RequestOpts{Query: map[string]string{"limit": "10"}, Result: &page{}}
+
+	err = client.Paginate(ctx, "/items", opts, pageOpts, func(resp *resty.Response) error {
+		if len(items) == 2 {
+			return errCrash
+		}
+		items = append(items, resp.Result().(*page).Items...)
+		return nil
+	})
+	require.ErrorIs(t, err, errCrash)
+	assert.Equal(t, "c2", checkpoint)
+
+	err = client.Paginate(ctx, "/items", opts, pageOpts, func(resp *resty.Response) error {
+		items = append(items, resp.Result().(*page).Items...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, items)
+	assert.Equal(t, []string{"", "c2", "c2", "c3"}, requested)
+	assert.Empty(t, checkpoint)
+	assert.Equal(t, map[string]string{"limit": "10"}, opts.Query)
+}
+
+func TestHTTP_PaginateModes(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		query := r.URL.Query()
+		start := 0
+		switch r.URL.Path {
+		case "/link":
+			start, _ = strconv.Atoi(query.Get("from"))
+			if start+2 < len(items) {
+				w.Header().Set("Link", fmt.Sprintf(`</link?from=%d&limit=2>; rel="next", </link>; rel="first"`, start+2))
+			}
+		case "/page":
+			if page := query.Get("page"); page != "" {
+				n, _ := strconv.Atoi(page)
+				start = (n - 1) * 2
+			}
+		case "/offset":
+			start, _ = strconv.Atoi(query.Get("offset"))
+		}
+		end := min(start+2, len(items))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"data": items[min(start, end):end]})
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+	opts := cliex.RequestOpts{Query: map[string]string{"limit": "2"}}
+
+	for _, tc := range []struct {
+		mode     cliex.PageMode
+		pageSize int
+		expected []string
+	}{
+		{cliex.PageLink, 0, []string{"/link?limit=2", "/link?from=2&limit=2", "/link?from=4&limit=2"}},
+		{cliex.PageNumber, 0, []string{"/page?limit=2", "/page?limit=2&page=2", "/page?limit=2&page=3", "/page?limit=2&page=4"}},
+		{cliex.PageOffset, 2, []string{"/offset?limit=2", "/offset?limit=2&offset=2", "/offset?limit=2&offset=4"}},
+	} {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			requested = nil
+			var got []int
+			err := cliex.PaginateItems(ctx, client, "/"+string(tc.mode), opts, cliex.PageOpts{Mode: tc.mode, PageSize: tc.pageSize}, func(item int) error {
+				got = append(got, item)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, items, got)
+			assert.Equal(t, tc.expected, requested)
+		})
+	}
+
+	err = client.Paginate(ctx, "/", opts, cliex.PageOpts{Mode: "unknown"}, func(*resty.Response) error { return nil })
+	require.Error(t, err)
+}
+
+func TestHTTP_PaginateSameCursor(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/link" {
+			w.Header().Set("Link", `</link?limit=2>; rel="next"`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items":[1],"next_cursor":"c1"}`))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+	onPage := func(*resty.Response) error { return nil }
+
+	err = client.Paginate(ctx, "/cursor", cliex.RequestOpts{}, cliex.PageOpts{}, onPage)
+	require.ErrorIs(t, err, cliex.ErrSameCursor)
+	assert.Equal(t, 2, requests)
+
+	requests = 0
+	opts := cliex.RequestOpts{Query: map[string]string{"limit": "2"}}
+	err = client.Paginate(ctx, "/link", opts, cliex.PageOpts{Mode: cliex.PageLink}, onPage)
+	require.ErrorIs(t, err, cliex.ErrSameCursor)
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,44 @@
+package cliex
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrPanic is returned when a panic occurred during the request, e.g. in a hook or unmarshaling to Result.
+// The error is *PanicError, use errors.As to get the panic value and the stack trace.
+var ErrPanic = errors.New("panic during request")
+
+// PanicError is the error with the recovered panic value and the stack trace of the panicked goroutine.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrPanic, e.Value)
+}
+
+// Is makes errors.Is(err, ErrPanic) work.
+func (e *PanicError) Is(target error) bool {
+	return target == ErrPanic
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverPanic converts the panic into *PanicError in err and logs it with the stack trace.
+// It must be called directly with defer.
+func recoverPanic(log Logger, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	panicErr := &PanicError{Value: r, Stack: debug.Stack()}
+	log.Error("panic recovered", "panic", r, "stack", string(panicErr.Stack))
+	*err = panicErr
+}
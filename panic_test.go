@@ -0,0 +1,50 @@
+package cliex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type panicResult struct{}
+
+func (*panicResult) UnmarshalJSON([]byte) error {
+	panic("bad unmarshal target")
+}
+
+func TestHTTP_RecoverPanics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithRecoverPanics(true))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = client.Get(ctx, "/", &panicResult{})
+	require.ErrorIs(t, err, cliex.ErrPanic)
+
+	var panicErr *cliex.PanicError
+	require.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, "bad unmarshal target", panicErr.Value)
+	assert.Contains(t, string(panicErr.Stack), "UnmarshalJSON")
+
+	_, err = client.Get(ctx, "/")
+	require.NoError(t, err)
+
+	set := cliex.NewSet(cliex.MustNew(cliex.WithBaseURL(srv.URL)))
+	_, err = set.Get(ctx, "/", &panicResult{})
+	require.ErrorIs(t, err, cliex.ErrPanic)
+
+	_, err = set.WithRecoverPanics(true).RequestBalanced(ctx, "/", cliex.RequestOpts{Result: &panicResult{}})
+	require.ErrorIs(t, err, cliex.ErrPanic)
+	assert.Equal(t, []int{0}, set.GetBroken())
+}
@@ -0,0 +1,98 @@
+package cliex
+
+import (
+	"container/list"
+	"sync"
+)
+
+// ClientPool is a pool of clients keyed by tenant or base URL. Clients are created on demand from the template
+// config and the least recently used client is closed and evicted when the pool reaches the maximum size.
+type ClientPool struct {
+	mu        sync.Mutex
+	template  Config
+	configure func(key string, cfg *Config)
+	maxSize   int
+	clients   map[string]*list.Element
+	lru       *list.List
+}
+
+type poolEntry struct {
+	key string
+	cli *HTTP
+}
+
+// NewClientPool returns a new ClientPool that creates clients from the template config.
+// configure is called with a copy of the template for every new client, if it is nil the key is used as BaseURL.
+// maxSize is the maximum number of clients in the pool, zero or negative means no limit.
+func NewClientPool(template Config, maxSize int, configure func(key string, cfg *Config)) *ClientPool {
+	if configure == nil {
+		configure = func(key string, cfg *Config) {
+			cfg.BaseURL = key
+		}
+	}
+	return &ClientPool{
+		template:  template,
+		configure: configure,
+		maxSize:   maxSize,
+		clients:   make(map[string]*list.Element),
+		lru:       list.New(),
+	}
+}
+
+// Get returns the client for the key, it creates a new client if there is no one.
+func (p *ClientPool) Get(key string) (*HTTP, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if elem, ok := p.clients[key]; ok {
+		p.lru.MoveToFront(elem)
+		return elem.Value.(*poolEntry).cli, nil
+	}
+
+	cfg := p.template
+	p.configure(key, &cfg)
+	cli, err := NewWithConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	p.clients[key] = p.lru.PushFront(&poolEntry{key: key, cli: cli})
+	for p.maxSize > 0 && p.lru.Len() > p.maxSize {
+		p.removeElement(p.lru.Back())
+	}
+
+	return cli, nil
+}
+
+// Remove closes and removes the client for the key from the pool.
+func (p *ClientPool) Remove(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if elem, ok := p.clients[key]; ok {
+		p.removeElement(elem)
+	}
+}
+
+// Len returns the number of clients in the pool.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lru.Len()
+}
+
+// Close closes and removes all clients from the pool.
+func (p *ClientPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for p.lru.Len() > 0 {
+		p.removeElement(p.lru.Back())
+	}
+}
+
+func (p *ClientPool) removeElement(elem *list.Element) {
+	entry := p.lru.Remove(elem).(*poolEntry)
+	delete(p.clients, entry.key)
+	entry.cli.Close()
+}
@@ -0,0 +1,70 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/tenant": func(ctx context.Context, req *http.Request) (any, error) {
+			return map[string]string{"tenant": req.Header.Get("X-Tenant")}, nil
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	pool := cliex.NewClientPool(cfg, 2, func(key string, cfg *cliex.Config) {
+		cfg.HeaderOverrides = map[string]string{"X-Tenant": key}
+	})
+	defer pool.Close()
+
+	a, err := pool.Get("a")
+	require.NoError(t, err)
+
+	var result map[string]string
+	_, err = a.Get(ctx, "/tenant", &result)
+	require.NoError(t, err)
+	assert.Equal(t, "a", result["tenant"])
+
+	again, err := pool.Get("a")
+	require.NoError(t, err)
+	assert.Same(t, a, again)
+
+	b, err := pool.Get("b")
+	require.NoError(t, err)
+	assert.NotSame(t, a, b)
+
+	// "a" is used recently, so "b" is evicted
+	_, err = pool.Get("a")
+	require.NoError(t, err)
+	_, err = pool.Get("c")
+	require.NoError(t, err)
+	assert.Equal(t, 2, pool.Len())
+
+	newB, err := pool.Get("b")
+	require.NoError(t, err)
+	assert.NotSame(t, b, newB)
+
+	pool.Remove("b")
+	assert.Equal(t, 1, pool.Len())
+
+	// Key is used as BaseURL by default
+	pool = cliex.NewClientPool(cliex.Config{}, 0, nil)
+	cli, err := pool.Get(cfg.BaseURL)
+	require.NoError(t, err)
+	_, err = cli.Get(ctx, "/tenant")
+	require.NoError(t, err)
+
+	_, err = pool.Get("invalid url")
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/maxbolgarin/lang"
+)
+
+// Exists checks with HEAD request if the resource exists on the BaseURL + URL.
+// It returns false without error if the server responds with 404 or 410.
+func (c *HTTP) Exists(ctx context.Context, url string, opts ...RequestOpts) (bool, error) {
+	reqOpts := lang.First(opts)
+	reqOpts.Method = http.MethodHead
+	reqOpts.NilOn404 = true
+
+	resp, err := c.Request(ctx, url, reqOpts)
+	switch {
+	case errors.Is(err, ErrGone):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return resp.StatusCode() != http.StatusNotFound, nil
+}
+
+// ContentInfo returns the size, the content type and the ETag of the resource on the BaseURL + URL
+// using HEAD request, e.g. to plan downloads or validate caches. Size is -1 if the server doesn't send Content-Length.
+func (c *HTTP) ContentInfo(ctx context.Context, url string, opts ...RequestOpts) (size int64, contentType string, etag string, err error) {
+	reqOpts := lang.First(opts)
+	reqOpts.Method = http.MethodHead
+
+	resp, err := c.Request(ctx, url, reqOpts)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	size = -1
+	if v := resp.Header().Get("Content-Length"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			size = n
+		}
+	}
+	return size, resp.Header().Get("Content-Type"), resp.Header().Get("ETag"), nil
+}
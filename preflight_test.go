@@ -0,0 +1,52 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_ExistsAndContentInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		switch r.URL.Path {
+		case "/file.bin":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Length", "1024")
+			w.Header().Set("ETag", `"v1"`)
+		case "/deleted":
+			w.WriteHeader(http.StatusGone)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for path, expected := range map[string]bool{"/file.bin": true, "/missing": false, "/deleted": false} {
+		exists, err := client.Exists(ctx, path)
+		require.NoError(t, err)
+		assert.Equal(t, expected, exists, path)
+	}
+	_, err = client.Exists(ctx, "/broken")
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+
+	size, contentType, etag, err := client.ContentInfo(ctx, "/file.bin")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1024), size)
+	assert.Equal(t, "application/octet-stream", contentType)
+	assert.Equal(t, `"v1"`, etag)
+
+	_, _, _, err = client.ContentInfo(ctx, "/missing")
+	require.ErrorIs(t, err, cliex.ErrNotFound)
+}
@@ -0,0 +1,168 @@
+package cliex
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// maxProfileSamples is the maximum number of samples of one phase per host that are kept in a profile.
+const maxProfileSamples = 10000
+
+// ProfileReport is the aggregated report of request timings collected by HTTP.EnableProfiling.
+type ProfileReport struct {
+	// Start is the time when profiling was started.
+	Start time.Time
+	// End is the time when profiling was finished.
+	End time.Time
+	// Hosts contains timings per host of requests.
+	Hosts map[string]HostProfile
+}
+
+// HostProfile contains timings of requests to a single host.
+type HostProfile struct {
+	// Requests is the number of requests that got a response.
+	Requests int
+	// DNS is the time of DNS lookups.
+	DNS PhaseTimings
+	// Connect is the time of establishing TCP connections.
+	Connect PhaseTimings
+	// TLS is the time of TLS handshakes.
+	TLS PhaseTimings
+	// TTFB is the time from getting the connection to the first byte of the response.
+	TTFB PhaseTimings
+}
+
+// PhaseTimings contains percentiles of a request phase.
+// Requests that skipped the phase (e.g. reused connections for DNS, Connect and TLS) are not counted.
+type PhaseTimings struct {
+	Count int
+	P50   time.Duration
+	P95   time.Duration
+}
+
+// String returns the report as a table with a line per host.
+func (r ProfileReport) String() string {
+	hosts := make([]string, 0, len(r.Hosts))
+	for host := range r.Hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "profile %s - %s (%s)\n", r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339), r.End.Sub(r.Start).Round(time.Millisecond))
+	for _, host := range hosts {
+		p := r.Hosts[host]
+		fmt.Fprintf(&b, "%s: requests=%d dns=%s connect=%s tls=%s ttfb=%s\n", host, p.Requests, p.DNS, p.Connect, p.TLS, p.TTFB)
+	}
+	return b.String()
+}
+
+// String returns percentiles in format "p50/p95 (count)".
+func (p PhaseTimings) String() string {
+	return fmt.Sprintf("%s/%s (%d)", p.P50, p.P95, p.Count)
+}
+
+// EnableProfiling collects detailed timings of all requests for the duration d.
+// The returned channel receives the aggregated report when the duration passes, then it is closed.
+// A new call finishes the current profiling immediately.
+func (c *HTTP) EnableProfiling(d time.Duration) <-chan ProfileReport {
+	p := &profile{
+		start: time.Now(),
+		hosts: make(map[string]*hostSamples),
+		out:   make(chan ProfileReport, 1),
+	}
+	if prev := c.profile.Swap(p); prev != nil {
+		prev.finish()
+	}
+	time.AfterFunc(d, func() {
+		c.profile.CompareAndSwap(p, nil)
+		p.finish()
+	})
+	return p.out
+}
+
+// profile collects samples of request timings.
+type profile struct {
+	start time.Time
+	once  sync.Once
+	out   chan ProfileReport
+
+	mu    sync.Mutex
+	hosts map[string]*hostSamples
+}
+
+type hostSamples struct {
+	requests int
+	dns      []time.Duration
+	connect  []time.Duration
+	tls      []time.Duration
+	ttfb     []time.Duration
+}
+
+func (p *profile) record(host string, req *resty.Request) {
+	if p == nil {
+		return
+	}
+	info := req.TraceInfo()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.hosts[host]
+	if !ok {
+		s = &hostSamples{}
+		p.hosts[host] = s
+	}
+	s.requests++
+	s.dns = addSample(s.dns, info.DNSLookup)
+	s.connect = addSample(s.connect, info.TCPConnTime)
+	s.tls = addSample(s.tls, info.TLSHandshake)
+	s.ttfb = addSample(s.ttfb, info.ServerTime)
+}
+
+func (p *profile) finish() {
+	p.once.Do(func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		report := ProfileReport{
+			Start: p.start,
+			End:   time.Now(),
+			Hosts: make(map[string]HostProfile, len(p.hosts)),
+		}
+		for host, s := range p.hosts {
+			report.Hosts[host] = HostProfile{
+				Requests: s.requests,
+				DNS:      percentiles(s.dns),
+				Connect:  percentiles(s.connect),
+				TLS:      percentiles(s.tls),
+				TTFB:     percentiles(s.ttfb),
+			}
+		}
+		p.out <- report
+		close(p.out)
+	})
+}
+
+func addSample(samples []time.Duration, d time.Duration) []time.Duration {
+	if d <= 0 || len(samples) >= maxProfileSamples {
+		return samples
+	}
+	return append(samples, d)
+}
+
+func percentiles(samples []time.Duration) PhaseTimings {
+	if len(samples) == 0 {
+		return PhaseTimings{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	at := func(q float64) time.Duration {
+		return samples[int(q*float64(len(samples)-1))]
+	}
+	return PhaseTimings{Count: len(samples), P50: at(0.5), P95: at(0.95)}
+}
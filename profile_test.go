@@ -0,0 +1,54 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_EnableProfiling(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL})
+	require.NoError(t, err)
+
+	reportCh := client.EnableProfiling(200 * time.Millisecond)
+	for range 5 {
+		_, err = client.Get(context.Background(), "/")
+		require.NoError(t, err)
+	}
+
+	select {
+	case report := <-reportCh:
+		host := strings.TrimPrefix(mockServer.URL, "http://")
+		require.Contains(t, report.Hosts, host)
+
+		p := report.Hosts[host]
+		assert.Equal(t, 5, p.Requests)
+		assert.Equal(t, 5, p.TTFB.Count)
+		assert.GreaterOrEqual(t, p.TTFB.P50, 5*time.Millisecond)
+		assert.GreaterOrEqual(t, p.TTFB.P95, p.TTFB.P50)
+		// The connection is reused after the first request
+		assert.Equal(t, 1, p.Connect.Count)
+		assert.Zero(t, p.TLS.Count)
+		assert.Contains(t, report.String(), host+": requests=5")
+	case <-time.After(time.Second):
+		t.Fatal("no profile report")
+	}
+
+	// Requests after the profiling are not collected
+	_, err = client.Get(context.Background(), "/")
+	require.NoError(t, err)
+	_, ok := <-reportCh
+	assert.False(t, ok)
+}
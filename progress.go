@@ -0,0 +1,44 @@
+package cliex
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/maxbolgarin/lang"
+)
+
+// applyUploadProgress wraps the request body to report the number of sent bytes to RequestOpts.OnUploadProgress.
+func applyUploadProgress(req *http.Request) {
+	state := getRequestState(req.Context())
+	if state == nil || state.onUploadProgress == nil || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	total := lang.If(req.ContentLength > 0, req.ContentLength, -1)
+	req.Body = &progressReader{ReadCloser: req.Body, total: total, f: state.onUploadProgress}
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return &progressReader{ReadCloser: body, total: total, f: state.onUploadProgress}, nil
+		}
+	}
+}
+
+// progressReader reports the number of read bytes after every read.
+type progressReader struct {
+	io.ReadCloser
+	done  int64
+	total int64
+	f     func(bytesDone, totalBytes int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	if n > 0 {
+		p.done += int64(n)
+		p.f(p.done, p.total)
+	}
+	return n, err
+}
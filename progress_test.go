@@ -0,0 +1,76 @@
+package cliex_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_UploadProgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		w.Write([]byte(strconv.FormatInt(n, 10)))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var done, total int64
+	onProgress := func(bytesDone, totalBytes int64) {
+		done, total = bytesDone, totalBytes
+	}
+
+	payload := bytes.Repeat([]byte("a"), 100000)
+	resp, err := client.Request(ctx, "/", cliex.RequestOpts{Method: http.MethodPost, Body: payload, OnUploadProgress: onProgress})
+	require.NoError(t, err)
+	assert.Equal(t, "100000", resp.String())
+	assert.EqualValues(t, 100000, done)
+	assert.EqualValues(t, 100000, total)
+
+	resp, err = client.Request(ctx, "/", cliex.RequestOpts{
+		Method:           http.MethodPost,
+		BodyReader:       io.MultiReader(bytes.NewReader(payload)),
+		OnUploadProgress: onProgress,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "100000", resp.String())
+	assert.EqualValues(t, 100000, done)
+	assert.EqualValues(t, -1, total)
+}
+
+func TestHTTP_StreamDownloadProgress(t *testing.T) {
+	content := strings.Repeat("streamed content ", 10000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write([]byte(content))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	var done, total int64
+	body, err := client.Stream(context.Background(), "/", cliex.RequestOpts{
+		OnDownloadProgress: func(bytesDone, totalBytes int64) {
+			done, total = bytesDone, totalBytes
+		},
+	})
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, content, string(data))
+	assert.EqualValues(t, len(content), done)
+	assert.EqualValues(t, len(content), total)
+}
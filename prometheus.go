@@ -0,0 +1,225 @@
+package cliex
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sony/gobreaker/v2"
+)
+
+const (
+	defaultPrometheusNamespace = "cliex"
+	defaultPrometheusMaxRoutes = 100
+
+	// OtherRoute is the route label of requests with routes over PrometheusOpts.MaxRoutes.
+	OtherRoute = "other"
+)
+
+// DefaultDurationBuckets is the default buckets of the request duration histogram in seconds.
+var DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// BreakerStateHook is the optional interface of MetricsHook that receives every state transition
+// of circuit breakers, not only trips to the open state.
+type BreakerStateHook interface {
+	// OnBreakerStateChange is called when the circuit breaker with the key changes its state.
+	OnBreakerStateChange(key string, from, to gobreaker.State)
+	// OnBreakerRemove is called when the circuit breaker with the key is deleted after eviction or reset.
+	OnBreakerRemove(key string)
+}
+
+// PrometheusOpts is the options of PrometheusMetrics.
+type PrometheusOpts struct {
+	// Namespace is the prefix of metric names. Default is "cliex".
+	Namespace string
+
+	// Buckets is the buckets of the request duration histogram in seconds. Default is DefaultDurationBuckets.
+	Buckets []float64
+
+	// MaxRoutes is the maximum number of distinct route label values, requests with other routes are labeled
+	// with OtherRoute. It bounds cardinality when requests without RequestOpts.Route are labeled by their URLs.
+	// Default is 100.
+	MaxRoutes int
+}
+
+// PrometheusMetrics is the MetricsHook and prometheus.Collector that collects request count, duration histograms,
+// in-flight requests, retries and circuit breaker states. Metrics are labeled by host, route (RequestOpts.Route),
+// method and status class (2xx, 4xx, error), so set Route of requests with IDs in paths to keep cardinality bounded.
+// Register it in a prometheus.Registerer or use Config.MetricsRegisterer. It can be shared by several clients.
+type PrometheusMetrics struct {
+	requests    *prometheus.CounterVec
+	durations   *prometheus.HistogramVec
+	inFlight    *prometheus.GaugeVec
+	retries     *prometheus.CounterVec
+	states      *prometheus.GaugeVec
+	transitions *prometheus.CounterVec
+
+	maxRoutes int
+
+	mu            sync.Mutex
+	routes        map[string]struct{}
+	inFlightCount map[[3]string]int
+}
+
+// NewPrometheusMetrics returns PrometheusMetrics with the options, set it as Config.MetricsHook
+// and register it in the registry of the application.
+func NewPrometheusMetrics(opts PrometheusOpts) *PrometheusMetrics {
+	namespace := cmp.Or(opts.Namespace, defaultPrometheusNamespace)
+	buckets := slices.Clone(opts.Buckets)
+	if len(buckets) == 0 {
+		buckets = slices.Clone(DefaultDurationBuckets)
+	}
+	slices.Sort(buckets)
+
+	labels := []string{"host", "route", "method"}
+	withStatus := []string{"host", "route", "method", "status"}
+	return &PrometheusMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "requests_total", Help: "Number of sent requests (attempts).",
+		}, withStatus),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "request_duration_seconds", Help: "Duration of requests (attempts) in seconds.",
+			Buckets: buckets,
+		}, withStatus),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "requests_in_flight", Help: "Number of requests that are being sent.",
+		}, labels),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "retries_total", Help: "Number of retries of requests.",
+		}, labels),
+		states: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "circuit_breaker_state",
+			Help: "State of the circuit breaker: 0 is closed, 1 is half-open, 2 is open.",
+		}, []string{"route"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "circuit_breaker_transitions_total",
+			Help: "Number of state transitions of the circuit breaker.",
+		}, []string{"route", "from", "to"}),
+		maxRoutes:     cmp.Or(opts.MaxRoutes, defaultPrometheusMaxRoutes),
+		routes:        make(map[string]struct{}),
+		inFlightCount: make(map[[3]string]int),
+	}
+}
+
+// registerPrometheusMetrics registers PrometheusMetrics in the registerer, the already registered
+// metrics of another client are reused.
+func registerPrometheusMetrics(reg prometheus.Registerer) (*PrometheusMetrics, error) {
+	metrics := NewPrometheusMetrics(PrometheusOpts{})
+	if err := reg.Register(metrics); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if errors.As(err, &registered) {
+			if existing, ok := registered.ExistingCollector.(*PrometheusMetrics); ok {
+				return existing, nil
+			}
+		}
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// Describe sends descriptors of the metrics to the channel.
+func (m *PrometheusMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect sends the metrics to the channel.
+func (m *PrometheusMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *PrometheusMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.requests, m.durations, m.inFlight, m.retries, m.states, m.transitions}
+}
+
+// OnRequestStart increments in-flight requests.
+func (m *PrometheusMetrics) OnRequestStart(_ context.Context, info RequestInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	labels := [3]string{info.Host, m.route(info.Route), info.Method}
+	m.inFlightCount[labels]++
+	m.inFlight.WithLabelValues(labels[:]...).Inc()
+}
+
+// OnRequestEnd decrements in-flight requests and records the request and its duration.
+// The in-flight series is deleted when there are no requests with its labels.
+func (m *PrometheusMetrics) OnRequestEnd(_ context.Context, info RequestInfo, statusCode int, duration time.Duration, _ error) {
+	m.mu.Lock()
+	labels := [3]string{info.Host, m.route(info.Route), info.Method}
+	if m.inFlightCount[labels]--; m.inFlightCount[labels] <= 0 {
+		delete(m.inFlightCount, labels)
+		m.inFlight.DeleteLabelValues(labels[:]...)
+	} else {
+		m.inFlight.WithLabelValues(labels[:]...).Dec()
+	}
+	m.mu.Unlock()
+
+	status := statusClass(statusCode)
+	m.requests.WithLabelValues(labels[0], labels[1], labels[2], status).Inc()
+	m.durations.WithLabelValues(labels[0], labels[1], labels[2], status).Observe(duration.Seconds())
+}
+
+// OnRetry records the retry.
+func (m *PrometheusMetrics) OnRetry(_ context.Context, info RequestInfo, _ int, _ time.Duration, _ error) {
+	m.mu.Lock()
+	route := m.route(info.Route)
+	m.mu.Unlock()
+	m.retries.WithLabelValues(info.Host, route, info.Method).Inc()
+}
+
+// OnBreakerTrip does nothing, trips are recorded by OnBreakerStateChange.
+func (m *PrometheusMetrics) OnBreakerTrip(string) {}
+
+// OnSlowRequest does nothing, slow requests are in the duration histogram.
+func (m *PrometheusMetrics) OnSlowRequest(context.Context, RequestInfo, time.Duration) {}
+
+// OnBreakerStateChange records the state of the circuit breaker and the transition.
+func (m *PrometheusMetrics) OnBreakerStateChange(key string, from, to gobreaker.State) {
+	m.states.WithLabelValues(key).Set(breakerStateValue(to))
+	m.transitions.WithLabelValues(key, from.String(), to.String()).Inc()
+}
+
+// OnBreakerRemove deletes the state and transitions of the deleted circuit breaker.
+func (m *PrometheusMetrics) OnBreakerRemove(key string) {
+	m.states.DeleteLabelValues(key)
+	m.transitions.DeletePartialMatch(prometheus.Labels{"route": key})
+}
+
+// route returns the route label, routes over maxRoutes are replaced with OtherRoute. It must be called under the lock.
+func (m *PrometheusMetrics) route(route string) string {
+	if _, ok := m.routes[route]; ok {
+		return route
+	}
+	if len(m.routes) >= m.maxRoutes {
+		return OtherRoute
+	}
+	m.routes[route] = struct{}{}
+	return route
+}
+
+// statusClass returns the class of the status code, e.g. "2xx", or "error" if there is no response.
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "error"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+func breakerStateValue(state gobreaker.State) float64 {
+	switch state {
+	case gobreaker.StateHalfOpen:
+		return 1
+	case gobreaker.StateOpen:
+		return 2
+	default:
+		return 0
+	}
+}
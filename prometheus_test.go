@@ -0,0 +1,117 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	metrics := cliex.NewPrometheusMetrics(cliex.PrometheusOpts{Namespace: "api", Buckets: []float64{0.5, 0.1}, MaxRoutes: 2})
+	require.NoError(t, reg.Register(metrics))
+
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:                srv.URL,
+		MetricsHook:            metrics,
+		CircuitBreaker:         true,
+		CircuitBreakerFailures: 1,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	_, err = client.Request(ctx, "/users/1", cliex.RequestOpts{Route: "/users/{id}"})
+	require.NoError(t, err)
+	_, err = client.Request(ctx, "/fail", cliex.RequestOpts{
+		Route:            "/fail",
+		RetryCount:       2,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	require.Error(t, err)
+
+	// Routes over the limit are labeled as other
+	_, err = client.Get(ctx, "/users/2")
+	require.NoError(t, err)
+
+	text := gatherText(t, reg)
+	users := `host="` + host + `",method="GET",route="/users/{id}"`
+	fail := `host="` + host + `",method="GET",route="/fail"`
+	other := `host="` + host + `",method="GET",route="other"`
+	for _, line := range []string{
+		"# TYPE api_requests_total counter",
+		`api_requests_total{` + users + `,status="2xx"} 1`,
+		`api_requests_total{` + fail + `,status="5xx"} 2`,
+		`api_requests_total{` + other + `,status="2xx"} 1`,
+		"# TYPE api_request_duration_seconds histogram",
+		`api_request_duration_seconds_bucket{` + users + `,status="2xx",le="0.1"} 1`,
+		`api_request_duration_seconds_bucket{` + users + `,status="2xx",le="+Inf"} 1`,
+		`api_request_duration_seconds_count{` + fail + `,status="5xx"} 2`,
+		`api_retries_total{` + fail + `} 1`,
+		`api_circuit_breaker_state{route="/fail"} 2`,
+		`api_circuit_breaker_transitions_total{from="closed",route="/fail",to="open"} 1`,
+	} {
+		assert.Contains(t, text, line+"\n")
+	}
+	// In-flight series of finished requests are deleted
+	assert.NotContains(t, text, "api_requests_in_flight{")
+
+	// Series of the deleted breaker are removed
+	client.ResetCircuit("/fail")
+	text = gatherText(t, reg)
+	assert.NotContains(t, text, `api_circuit_breaker_state{route="/fail"}`)
+	assert.NotContains(t, text, `api_circuit_breaker_transitions_total{from="closed",route="/fail"`)
+}
+
+func TestConfig_MetricsRegisterer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	hook := &metricsHookForTest{}
+	first, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithMetricsRegisterer(reg), func(cfg *cliex.Config) {
+		cfg.MetricsHook = hook
+	})
+	require.NoError(t, err)
+	second, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithMetricsRegisterer(reg))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = first.Request(ctx, "/", cliex.RequestOpts{Route: "/"})
+	require.NoError(t, err)
+	_, err = second.Request(ctx, "/", cliex.RequestOpts{Route: "/"})
+	require.NoError(t, err)
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	assert.Contains(t, gatherText(t, reg), `cliex_requests_total{host="`+host+`",method="GET",route="/",status="2xx"} 2`+"\n")
+	assert.Equal(t, []int{http.StatusOK}, hook.codes)
+}
+
+func gatherText(t *testing.T, reg *prometheus.Registry) string {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var b strings.Builder
+	for _, family := range families {
+		_, err := expfmt.MetricFamilyToText(&b, family)
+		require.NoError(t, err)
+	}
+	return b.String()
+}
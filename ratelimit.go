@@ -0,0 +1,170 @@
+package cliex
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// RateLimitStatus is the rate limit quota reported by the server in response headers.
+type RateLimitStatus struct {
+	// Limit is the maximum number of requests in the current window, -1 if unknown.
+	Limit int
+	// Remaining is the number of requests left in the current window, -1 if unknown.
+	Remaining int
+	// Used is the number of requests made in the current window, -1 if unknown.
+	Used int
+	// Reset is the time when the quota resets, zero if unknown.
+	Reset time.Time
+	// Resource is the name of the rate limited resource (e.g. X-RateLimit-Resource in GitHub API).
+	Resource string
+	// Policy is the raw value of the RateLimit-Policy header.
+	Policy string
+}
+
+// Exhausted returns true if there are no requests left in the current window.
+func (s RateLimitStatus) Exhausted() bool {
+	return s.Remaining == 0
+}
+
+// WaitTime returns the time to wait until the quota resets, zero if it is already reset or unknown.
+func (s RateLimitStatus) WaitTime() time.Duration {
+	if s.Reset.IsZero() {
+		return 0
+	}
+	return max(time.Until(s.Reset), 0)
+}
+
+// RateLimitInfo parses rate limit headers of the response: standard RateLimit-* and RateLimit,
+// X-RateLimit-* (GitHub, Stripe, etc.) and X-Rate-Limit-*. It returns false if there are no such headers.
+func RateLimitInfo(resp *resty.Response) (RateLimitStatus, bool) {
+	if resp == nil {
+		return RateLimitStatus{Limit: -1, Remaining: -1, Used: -1}, false
+	}
+	return RateLimitInfoFromHeader(resp.Header())
+}
+
+// RateLimitInfoFromHeader parses rate limit headers. It returns false if there are no such headers.
+func RateLimitInfoFromHeader(h http.Header) (RateLimitStatus, bool) {
+	out := RateLimitStatus{Limit: -1, Remaining: -1, Used: -1}
+	if h == nil {
+		return out, false
+	}
+
+	found := false
+	now := time.Now()
+
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-", "X-Rate-Limit-"} {
+		if v, ok := headerInt(h, prefix+"Limit"); ok && out.Limit == -1 {
+			out.Limit, found = v, true
+		}
+		if v, ok := headerInt(h, prefix+"Remaining"); ok && out.Remaining == -1 {
+			out.Remaining, found = v, true
+		}
+		if v, ok := headerInt(h, prefix+"Used"); ok && out.Used == -1 {
+			out.Used, found = v, true
+		}
+		if v := h.Get(prefix + "Reset"); v != "" && out.Reset.IsZero() {
+			if reset, ok := parseRateLimitReset(v, now); ok {
+				out.Reset, found = reset, true
+			}
+		}
+		if v := h.Get(prefix + "Resource"); v != "" && out.Resource == "" {
+			out.Resource, found = v, true
+		}
+	}
+
+	// Structured header from the IETF draft: RateLimit: limit=100, remaining=50, reset=5
+	if v := h.Get("RateLimit"); v != "" {
+		for _, part := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' }) {
+			key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok {
+				continue
+			}
+			n, err := strconv.Atoi(strings.Trim(value, `" `))
+			if err != nil {
+				continue
+			}
+			switch strings.ToLower(key) {
+			case "limit", "l":
+				out.Limit, found = n, true
+			case "remaining", "r":
+				out.Remaining, found = n, true
+			case "reset", "t":
+				out.Reset, found = now.Add(time.Duration(n)*time.Second), true
+			}
+		}
+	}
+
+	if v := h.Get("RateLimit-Policy"); v != "" {
+		out.Policy, found = v, true
+	}
+
+	if out.Reset.IsZero() && out.Remaining == 0 {
+		if wait, ok := parseRetryAfter(h.Get("Retry-After"), now); ok {
+			out.Reset = now.Add(wait)
+		}
+	}
+	if out.Used == -1 && out.Limit >= 0 && out.Remaining >= 0 {
+		out.Used = out.Limit - out.Remaining
+	}
+
+	return out, found
+}
+
+// parseRateLimitReset parses reset value that can be delta seconds, unix seconds, unix milliseconds or HTTP date.
+func parseRateLimitReset(v string, now time.Time) (time.Time, bool) {
+	v = strings.TrimSpace(v)
+	if n, err := strconv.ParseFloat(v, 64); err == nil {
+		switch {
+		case n > 1e12:
+			return time.UnixMilli(int64(n)), true
+		case n > 1e9:
+			return time.Unix(int64(n), 0), true
+		case n >= 0:
+			return now.Add(time.Duration(n * float64(time.Second))), true
+		}
+		return time.Time{}, false
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// parseRetryAfter parses Retry-After header that can be delta seconds or HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		if n < 0 {
+			return 0, false
+		}
+		return time.Duration(n) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func headerInt(h http.Header, key string) (int, bool) {
+	v := strings.TrimSpace(h.Get(key))
+	if v == "" {
+		return 0, false
+	}
+	// Some APIs send a list of limits for different windows, e.g. "100, 100;w=60"
+	if i := strings.IndexAny(v, ",;"); i != -1 {
+		v = strings.TrimSpace(v[:i])
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
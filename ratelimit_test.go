@@ -0,0 +1,66 @@
+package cliex_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitInfoFromHeader(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	github := http.Header{}
+	github.Set("X-RateLimit-Limit", "5000")
+	github.Set("X-RateLimit-Remaining", "4999")
+	github.Set("X-RateLimit-Used", "1")
+	github.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	github.Set("X-RateLimit-Resource", "core")
+
+	info, ok := cliex.RateLimitInfoFromHeader(github)
+	assert.True(t, ok)
+	assert.Equal(t, 5000, info.Limit)
+	assert.Equal(t, 4999, info.Remaining)
+	assert.Equal(t, 1, info.Used)
+	assert.Equal(t, "core", info.Resource)
+	assert.True(t, reset.Equal(info.Reset))
+	assert.False(t, info.Exhausted())
+
+	standard := http.Header{}
+	standard.Set("RateLimit-Limit", "100")
+	standard.Set("RateLimit-Remaining", "0")
+	standard.Set("RateLimit-Reset", "30")
+	standard.Set("RateLimit-Policy", "100;w=60")
+
+	info, ok = cliex.RateLimitInfoFromHeader(standard)
+	assert.True(t, ok)
+	assert.Equal(t, 100, info.Limit)
+	assert.Equal(t, 100, info.Used)
+	assert.True(t, info.Exhausted())
+	assert.Equal(t, "100;w=60", info.Policy)
+	assert.InDelta(t, 30*time.Second, info.WaitTime(), float64(time.Second))
+
+	structured := http.Header{}
+	structured.Set("RateLimit", "limit=10, remaining=3, reset=5")
+	info, ok = cliex.RateLimitInfoFromHeader(structured)
+	assert.True(t, ok)
+	assert.Equal(t, 10, info.Limit)
+	assert.Equal(t, 3, info.Remaining)
+
+	retryAfter := http.Header{}
+	retryAfter.Set("X-Rate-Limit-Remaining", "0")
+	retryAfter.Set("Retry-After", "10")
+	info, ok = cliex.RateLimitInfoFromHeader(retryAfter)
+	assert.True(t, ok)
+	assert.Equal(t, -1, info.Limit)
+	assert.InDelta(t, 10*time.Second, info.WaitTime(), float64(time.Second))
+
+	_, ok = cliex.RateLimitInfoFromHeader(http.Header{"Content-Type": {"application/json"}})
+	assert.False(t, ok)
+
+	_, ok = cliex.RateLimitInfo(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,222 @@
+package cliex
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/maxbolgarin/lang"
+)
+
+// recordSkipHeaders is the headers that are set by the transport of the replaying client.
+var recordSkipHeaders = []string{"Content-Length", "Accept-Encoding", "Connection"}
+
+// DefaultRecordRedactedHeaders is the headers with credentials that are not recorded by default.
+var DefaultRecordRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// RecordedRequest is the request of the recorded session.
+type RecordedRequest struct {
+	// Time is the time when the request was sent.
+	Time time.Time `json:"time"`
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+	// Origin is the scheme and the host of the original request, e.g. "https://api.example.com".
+	Origin string `json:"origin"`
+	// Path is the path with the query of the request, e.g. "/v1/users?limit=10".
+	Path string `json:"path"`
+	// Header is the headers of the request without redacted ones.
+	Header http.Header `json:"header,omitempty"`
+	// Body is the body of the request if it is a valid UTF-8 text.
+	Body string `json:"body,omitempty"`
+	// BinaryBody is the body of the request if it is not a valid UTF-8 text, it is base64 encoded in JSON.
+	BinaryBody []byte `json:"binary_body,omitempty"`
+	// StatusCode is the status code of the original response, zero if there was no response.
+	StatusCode int `json:"status_code"`
+}
+
+// Manifest is the recorded session of requests that can be re-executed with HTTP.Replay.
+type Manifest struct {
+	Requests []RecordedRequest `json:"requests"`
+}
+
+// ReadManifest reads the manifest in JSON format.
+func ReadManifest(r io.Reader) (Manifest, error) {
+	var m Manifest
+	err := jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(r).Decode(&m)
+	return m, err
+}
+
+// WriteTo writes the manifest to the writer in JSON format.
+func (m Manifest) WriteTo(w io.Writer) (int64, error) {
+	data, err := jsoniter.ConfigCompatibleWithStandardLibrary.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// Recorder records requests sent through its middleware into a Manifest, e.g. to reproduce an incident against staging.
+// Every attempt of the request is recorded, including retries and followed redirects.
+type Recorder struct {
+	skipped []string
+
+	mu       sync.Mutex
+	requests []RecordedRequest
+}
+
+// NewRecorder returns a Recorder that doesn't record DefaultRecordRedactedHeaders and the provided headers.
+// Add its middleware to the client with HTTP.Use(recorder.Middleware()).
+func NewRecorder(redactedHeaders ...string) *Recorder {
+	return &Recorder{skipped: slices.Concat(recordSkipHeaders, DefaultRecordRedactedHeaders, redactedHeaders)}
+}
+
+// Middleware returns the middleware that records requests.
+func (r *Recorder) Middleware() Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			record, err := r.record(req)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := next(req)
+			if resp != nil {
+				record.StatusCode = resp.StatusCode
+			}
+			r.mu.Lock()
+			r.requests = append(r.requests, record)
+			r.mu.Unlock()
+			return resp, err
+		}
+	}
+}
+
+// Manifest returns the manifest with requests recorded so far.
+func (r *Recorder) Manifest() Manifest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Manifest{Requests: slices.Clone(r.requests)}
+}
+
+// Reset deletes recorded requests.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = nil
+}
+
+func (r *Recorder) record(req *http.Request) (RecordedRequest, error) {
+	record := RecordedRequest{
+		Time:   time.Now(),
+		Method: req.Method,
+		Origin: req.URL.Scheme + "://" + req.URL.Host,
+		Path:   req.URL.RequestURI(),
+		Header: req.Header.Clone(),
+	}
+	for _, key := range r.skipped {
+		record.Header.Del(key)
+	}
+	if len(record.Header) == 0 {
+		record.Header = nil
+	}
+
+	body, err := requestBody(req)
+	if err != nil {
+		return record, err
+	}
+	if utf8.Valid(body) {
+		record.Body = string(body)
+	} else {
+		record.BinaryBody = body
+	}
+	return record, nil
+}
+
+// requestBody returns a copy of the body of the request, the body is replaced if it cannot be got again.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// ReplayOpts is the options for replaying of the recorded session.
+type ReplayOpts struct {
+	// RequestOpts is the base options of replayed requests (e.g. auth, retries),
+	// method, headers and body are taken from the recorded requests.
+	RequestOpts
+
+	// KeepTiming is whether to wait between requests as long as between the recorded requests.
+	KeepTiming bool
+}
+
+// ReplayResult is the result of the replayed request.
+type ReplayResult struct {
+	// Request is the recorded request.
+	Request RecordedRequest
+	// Response is the response of the replayed request, it is nil if there is no response.
+	Response *resty.Response
+	// Err is the error of the replayed request.
+	Err error
+}
+
+// Replay re-executes requests of the manifest one by one against the BaseURL of the client,
+// or against the original origins if the client has no BaseURL. Failed requests don't stop the replay,
+// their errors are in the results. Replay returns an error only if the context is done.
+func (c *HTTP) Replay(ctx context.Context, manifest Manifest, opts ...ReplayOpts) ([]ReplayResult, error) {
+	replayOpts := lang.First(opts)
+
+	results := make([]ReplayResult, 0, len(manifest.Requests))
+	for i, recorded := range manifest.Requests {
+		if replayOpts.KeepTiming && i > 0 {
+			select {
+			case <-ctx.Done():
+				return results, ctx.Err()
+			case <-time.After(recorded.Time.Sub(manifest.Requests[i-1].Time)):
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		reqOpts := replayOpts.RequestOpts
+		reqOpts.Method = recorded.Method
+		reqOpts.HeaderValues = recorded.Header.Clone()
+		reqOpts.Body = nil
+		if recorded.Body != "" {
+			reqOpts.Body = []byte(recorded.Body)
+		} else if len(recorded.BinaryBody) > 0 {
+			reqOpts.Body = recorded.BinaryBody
+		}
+
+		url := recorded.Path
+		if c.cli.BaseURL == "" && c.resolver == nil {
+			url = strings.TrimSuffix(recorded.Origin, "/") + url
+		}
+		resp, err := c.Request(ctx, url, reqOpts)
+		results = append(results, ReplayResult{Request: recorded, Response: resp, Err: err})
+	}
+	return results, nil
+}
@@ -0,0 +1,83 @@
+package cliex_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_Replay(t *testing.T) {
+	prod := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer prod.Close()
+
+	type received struct {
+		method, uri, body, auth, trace string
+	}
+	var (
+		mu      sync.Mutex
+		staging []received
+	)
+	stagingSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		staging = append(staging, received{r.Method, r.RequestURI, string(body), r.Header.Get("Authorization"), r.Header.Get("X-Trace")})
+	}))
+	defer stagingSrv.Close()
+
+	recorder := cliex.NewRecorder("X-Api-Key")
+	client, err := cliex.New(cliex.WithBaseURL(prod.URL))
+	require.NoError(t, err)
+	client.Use(recorder.Middleware())
+	ctx := context.Background()
+
+	_, err = client.Request(ctx, "/users", cliex.RequestOpts{
+		Method:    http.MethodPost,
+		Body:      map[string]string{"name": "alice"},
+		AuthToken: "prod-token",
+		Headers:   map[string]string{"X-Trace": "1", "X-Api-Key": "secret"},
+		Query:     map[string]string{"dry": "true"},
+	})
+	require.NoError(t, err)
+	_, err = client.Request(ctx, "/fail", cliex.RequestOpts{Method: http.MethodPut, Body: []byte{0xff, 0x00}})
+	require.ErrorIs(t, err, cliex.ErrBadGateway)
+
+	manifest := recorder.Manifest()
+	require.Len(t, manifest.Requests, 2)
+	assert.Equal(t, prod.URL, manifest.Requests[0].Origin)
+	assert.Equal(t, "/users?dry=true", manifest.Requests[0].Path)
+	assert.Empty(t, manifest.Requests[0].Header.Get("Authorization"))
+	assert.Empty(t, manifest.Requests[0].Header.Get("X-Api-Key"))
+	assert.Equal(t, http.StatusBadGateway, manifest.Requests[1].StatusCode)
+
+	var buf bytes.Buffer
+	_, err = manifest.WriteTo(&buf)
+	require.NoError(t, err)
+	manifest, err = cliex.ReadManifest(&buf)
+	require.NoError(t, err)
+
+	stagingClient, err := cliex.New(cliex.WithBaseURL(stagingSrv.URL))
+	require.NoError(t, err)
+	results, err := stagingClient.Replay(ctx, manifest, cliex.ReplayOpts{RequestOpts: cliex.RequestOpts{AuthToken: "staging-token"}})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		require.NoError(t, result.Err)
+	}
+	assert.Equal(t, []received{
+		{http.MethodPost, "/users?dry=true", `{"name":"alice"}`, "Bearer staging-token", "1"},
+		{http.MethodPut, "/fail", string([]byte{0xff, 0x00}), "Bearer staging-token", ""},
+	}, staging)
+}
@@ -0,0 +1,123 @@
+package cliex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const redactedValue = "***"
+
+// jsonRedactor masks values of JSON bodies by JSON paths, e.g. "$.password", "$.card.number" or "$.users[*].email".
+type jsonRedactor struct {
+	paths [][]any
+}
+
+func newJSONRedactor(paths []string) (*jsonRedactor, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	out := &jsonRedactor{paths: make([][]any, 0, len(paths))}
+	for _, path := range paths {
+		keys, err := parseJSONPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("redact json path %s: %w", path, err)
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("redact json path %s: empty path", path)
+		}
+		out.paths = append(out.paths, keys)
+	}
+	return out, nil
+}
+
+// install masks bodies of debug logs of the resty client.
+func (r *jsonRedactor) install(cli *resty.Client) {
+	if r == nil {
+		return
+	}
+	cli.OnRequestLog(func(log *resty.RequestLog) error {
+		log.Body = r.redact(log.Body)
+		return nil
+	})
+	cli.OnResponseLog(func(log *resty.ResponseLog) error {
+		log.Body = r.redact(log.Body)
+		return nil
+	})
+}
+
+// redact returns the JSON body with masked values, the body is returned as is if it is not JSON
+// or there is nothing to mask.
+func (r *jsonRedactor) redact(body string) string {
+	var v any
+	decoder := jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil || decoder.More() {
+		return body
+	}
+	var redacted bool
+	for _, keys := range r.paths {
+		redacted = redactJSONPath(v, keys) || redacted
+	}
+	if !redacted {
+		return body
+	}
+	data, err := jsoniter.ConfigCompatibleWithStandardLibrary.MarshalIndent(v, "", "   ")
+	if err != nil {
+		return body
+	}
+	return string(data)
+}
+
+// redactJSONPath replaces values of the decoded JSON by the path keys, "*" matches all elements.
+func redactJSONPath(v any, keys []any) bool {
+	last := len(keys) == 1
+	var redacted bool
+	switch k := keys[0].(type) {
+	case string:
+		m, ok := v.(map[string]any)
+		if !ok {
+			if s, ok := v.([]any); ok && k == "*" {
+				return redactSlice(s, -1, keys)
+			}
+			return false
+		}
+		for key, value := range m {
+			if key != k && k != "*" {
+				continue
+			}
+			if last {
+				m[key] = redactedValue
+				redacted = true
+			} else {
+				redacted = redactJSONPath(value, keys[1:]) || redacted
+			}
+		}
+	case int:
+		s, ok := v.([]any)
+		if !ok || k < 0 || k >= len(s) {
+			return false
+		}
+		return redactSlice(s, k, keys)
+	}
+	return redacted
+}
+
+// redactSlice redacts the element of the slice with the index or all elements if the index is negative.
+func redactSlice(s []any, index int, keys []any) bool {
+	var redacted bool
+	for i := range s {
+		if index >= 0 && i != index {
+			continue
+		}
+		if len(keys) == 1 {
+			s[i] = redactedValue
+			redacted = true
+		} else {
+			redacted = redactJSONPath(s[i], keys[1:]) || redacted
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,68 @@
+package cliex_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestHTTP_RedactJSONPaths(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token":"secret-token","users":[{"name":"alice","email":"alice@example.com"}],"total":2}`))
+	}))
+	defer srv.Close()
+
+	var out syncBuffer
+	client, err := cliex.New(
+		cliex.WithBaseURL(srv.URL),
+		cliex.WithDebug(true),
+		cliex.WithLogger(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		cliex.WithRedactJSONPaths("$.password", "$.card.number", "$.token", "$.users[*].email"),
+	)
+	require.NoError(t, err)
+
+	var result map[string]any
+	_, err = client.Request(context.Background(), "/login", cliex.RequestOpts{
+		Method: http.MethodPost,
+		Body:   map[string]any{"login": "alice", "password": "p@ss", "card": map[string]any{"number": "4111111111111111"}},
+		Result: &result,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "secret-token", result["token"])
+
+	log := out.String()
+	require.Contains(t, log, "alice")
+	for _, secret := range []string{"p@ss", "4111111111111111", "secret-token", "alice@example.com"} {
+		assert.NotContains(t, log, secret)
+	}
+	assert.Contains(t, log, "***")
+
+	_, err = cliex.New(cliex.WithRedactJSONPaths("$.items[x]"))
+	require.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package cliex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Redirect is a single redirect followed by the request.
+type Redirect struct {
+	// URL is the URL that responded with the redirect.
+	URL string
+	// StatusCode is the status code of the redirect response, e.g. 301 or 307.
+	StatusCode int
+	// Location is the URL the request was redirected to.
+	Location string
+}
+
+// RedirectError is the error of the request that followed redirects before failing.
+// It has the same message as the wrapped error.
+type RedirectError struct {
+	// Redirects is the chain of redirects in order.
+	Redirects []Redirect
+	// Err is the request error.
+	Err error
+}
+
+func (e *RedirectError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RedirectError) Unwrap() error {
+	return e.Err
+}
+
+// RedirectHistory returns the chain of redirects followed by the request of the response, nil if there were none.
+func RedirectHistory(resp *resty.Response) []Redirect {
+	if resp == nil || resp.Request == nil {
+		return nil
+	}
+	return getRequestState(resp.Request.Context()).getRedirects()
+}
+
+// RedirectHistoryFromError returns the chain of redirects followed by the failed request, nil if there were none.
+// It uses the last attempt if the request was retried.
+func RedirectHistoryFromError(err error) []Redirect {
+	if retryErr, ok := AsRetryError(err); ok {
+		err = retryErr.Last()
+	}
+	var redirectErr *RedirectError
+	if errors.As(err, &redirectErr) {
+		return redirectErr.Redirects
+	}
+	return nil
+}
+
+// ErrNoLocation is returned by RedirectLocation when the response has no Location header.
+var ErrNoLocation = errors.New("no location header")
+
+// RedirectLocation returns the Location header of the 3xx response (see RequestOpts.ReturnOn3xx)
+// resolved against the URL of the request.
+func RedirectLocation(resp *resty.Response) (*url.URL, error) {
+	if resp == nil || resp.RawResponse == nil {
+		return nil, ErrNoLocation
+	}
+	location := resp.Header().Get("Location")
+	if location == "" {
+		return nil, ErrNoLocation
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("parse location: %w", err)
+	}
+	if req := resp.RawResponse.Request; req != nil && req.URL != nil {
+		u = req.URL.ResolveReference(u)
+	}
+	return u, nil
+}
+
+// recordRedirects is a redirect policy that saves every redirect to the request state
+// and stops following redirects if RequestOpts.ReturnOn3xx is set.
+var recordRedirects = resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
+	state := getRequestState(req.Context())
+	if state != nil && state.stopRedirects {
+		return http.ErrUseLastResponse
+	}
+	if state == nil || req.Response == nil || len(via) == 0 {
+		return nil
+	}
+	state.addRedirect(Redirect{
+		URL:        via[len(via)-1].URL.String(),
+		StatusCode: req.Response.StatusCode,
+		Location:   req.URL.String(),
+	})
+	return nil
+})
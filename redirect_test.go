@@ -0,0 +1,97 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_RedirectHistory(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/x", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/y", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/y", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	resp, err := client.Get(ctx, "/a")
+	require.NoError(t, err)
+	assert.Equal(t, []cliex.Redirect{
+		{URL: srv.URL + "/a", StatusCode: http.StatusFound, Location: srv.URL + "/b"},
+		{URL: srv.URL + "/b", StatusCode: http.StatusMovedPermanently, Location: srv.URL + "/c"},
+	}, cliex.RedirectHistory(resp))
+
+	resp, err = client.Get(ctx, "/c")
+	require.NoError(t, err)
+	assert.Nil(t, cliex.RedirectHistory(resp))
+
+	_, err = client.Request(ctx, "/x", cliex.RequestOpts{
+		RetryCount:       2,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	require.ErrorIs(t, err, cliex.ErrInternalServerError)
+	assert.Equal(t, []cliex.Redirect{
+		{URL: srv.URL + "/x", StatusCode: http.StatusTemporaryRedirect, Location: srv.URL + "/y"},
+	}, cliex.RedirectHistoryFromError(err))
+}
+
+func TestHTTP_ReturnOn3xx(t *testing.T) {
+	var followed bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/presign", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/storage/file?signature=abc")
+		w.WriteHeader(http.StatusSeeOther)
+	})
+	mux.HandleFunc("/storage/file", func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: srv.URL})
+	require.NoError(t, err)
+
+	resp, err := client.Do(context.Background(), "/presign", cliex.RequestOpts{ReturnOn3xx: true})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode())
+	assert.True(t, resp.IsRedirect())
+	assert.False(t, followed)
+
+	location, err := resp.Location()
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL+"/storage/file?signature=abc", location.String())
+
+	// Redirects are followed by default
+	raw, err := client.Request(context.Background(), "/presign", cliex.RequestOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, raw.StatusCode())
+	assert.True(t, followed)
+
+	_, err = cliex.RedirectLocation(raw)
+	assert.ErrorIs(t, err, cliex.ErrNoLocation)
+}
@@ -0,0 +1,21 @@
+package cliex
+
+import "errors"
+
+// ErrNotSent is wrapped into the error of the attempt that failed before the request was completely written
+// to the connection, e.g. connection refused, or connection reset and broken pipe while sending the body.
+// The server has not received the whole request, so it is safe to send it again even with non-idempotent method.
+var ErrNotSent = errors.New("request was not fully sent")
+
+// CanReplay returns true if the request with the method that failed with the error is safe to be sent again:
+// the method is idempotent (GET, HEAD, OPTIONS, PUT, DELETE, TRACE) or the request failed with ErrNotSent.
+// It is the replay policy of RequestOpts.RetrySafeOnly and of failover in HTTPSet.RequestBalanced,
+// use RequestOpts.Idempotent to mark a non-idempotent request (e.g. POST with an idempotency key) as safe.
+func CanReplay(method string, err error) bool {
+	return isIdempotent(method) || errors.Is(err, ErrNotSent)
+}
+
+// canReplay returns true if the request can be sent again after the error according to RequestOpts.RetrySafeOnly.
+func (o RequestOpts) canReplay(err error) bool {
+	return !o.RetrySafeOnly || o.Idempotent || CanReplay(o.Method, err)
+}
@@ -0,0 +1,127 @@
+package cliex_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanReplay(t *testing.T) {
+	errReset := errors.New("connection reset")
+
+	assert.True(t, cliex.CanReplay(http.MethodGet, errReset))
+	assert.True(t, cliex.CanReplay(http.MethodPut, errReset))
+	assert.False(t, cliex.CanReplay(http.MethodPost, errReset))
+	assert.True(t, cliex.CanReplay(http.MethodPost, errors.Join(cliex.ErrNotSent, errReset)))
+}
+
+func TestHTTP_RetrySafeOnly(t *testing.T) {
+	// The server reads the whole request and drops the connection without a response
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		io.Copy(io.Discard, r.Body)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	opts := cliex.RequestOpts{
+		Method:           http.MethodPost,
+		Body:             []byte("payment"),
+		RetryCount:       3,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		RetrySafeOnly:    true,
+		NoLogRetryError:  true,
+	}
+	_, err = client.Request(ctx, "/", opts)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, cliex.ErrNotSent)
+	assert.EqualValues(t, 1, requests.Load())
+
+	opts.Idempotent = true
+	_, err = client.Request(ctx, "/", opts)
+	require.Error(t, err)
+	assert.EqualValues(t, 4, requests.Load())
+
+	// Nothing is sent to the closed port, so POST is retried
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	opts.Idempotent = false
+	_, err = client.Request(ctx, "http://"+addr+"/", opts)
+	require.ErrorIs(t, err, cliex.ErrNotSent)
+	var retryErr *cliex.RetryError
+	require.ErrorAs(t, err, &retryErr)
+	assert.Len(t, retryErr.Attempts, 3)
+}
+
+func TestHTTPSet_FailoverNotSent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	set := cliex.NewSet(
+		cliex.MustNew(cliex.WithBaseURL("http://"+addr)),
+		cliex.MustNew(cliex.WithBaseURL(srv.URL)),
+	).WithFailover(true)
+
+	for range 2 {
+		_, err := set.RequestBalanced(context.Background(), "/", cliex.RequestOpts{Method: http.MethodPost})
+		require.NoError(t, err)
+	}
+}
+
+func TestHTTPSet_FailoverNonIdempotentRetries(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	set := cliex.NewSet(cliex.MustNew(cliex.WithBaseURL(srv.URL))).WithFailover(true)
+	opts := cliex.RequestOpts{
+		Method:           http.MethodPost,
+		RetryCount:       3,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		NoLogRetryError:  true,
+	}
+
+	// The client retries the non-idempotent request itself with RetryCount of the request
+	_, err := set.RequestBalanced(context.Background(), "/", opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), hits.Load())
+
+	// RetrySafeOnly forbids retries of the request that was sent
+	hits.Store(0)
+	opts.RetrySafeOnly = true
+	_, err = set.RequestBalanced(context.Background(), "/", opts)
+	require.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	assert.Equal(t, int64(1), hits.Load())
+}
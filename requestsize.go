@@ -0,0 +1,67 @@
+package cliex
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrRequestTooLarge is returned without sending when the size of the request headers and body
+// exceeds Config.MaxRequestSize or RequestOpts.MaxRequestSize. Such requests are not retried.
+var ErrRequestTooLarge = errors.New("request is too large")
+
+// headerSize returns the approximate size of the request line and the headers on the wire.
+func headerSize(req *http.Request) int64 {
+	// "GET /path HTTP/1.1\r\n" and "Host: host\r\n"
+	size := len(req.Method) + len(req.URL.RequestURI()) + len(" HTTP/1.1\r\n") + 1
+	size += len("Host: \r\n") + len(req.Host)
+	for k, values := range req.Header {
+		for _, v := range values {
+			size += len(k) + len(v) + len(": \r\n")
+		}
+	}
+	return int64(size + len("\r\n"))
+}
+
+// accountRequestSize adds the size of the request to the request stats and checks the size limit of the request.
+// Body of unknown length is counted while it is sent and the request fails when it exceeds the limit.
+func accountRequestSize(req *http.Request) error {
+	var (
+		stats = getRequestStats(req.Context())
+		limit = getRequestState(req.Context()).getMaxRequestSize()
+		size  = headerSize(req)
+	)
+
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if hasBody && req.ContentLength > 0 {
+		size += req.ContentLength
+	}
+	if limit > 0 && size > limit {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrRequestTooLarge, size, limit)
+	}
+	stats.addBytesSent(size)
+
+	if hasBody && req.ContentLength <= 0 {
+		req.Body = &countingBody{ReadCloser: req.Body, stats: stats, size: size, limit: limit}
+	}
+	return nil
+}
+
+// countingBody counts bytes of the streamed request body and fails the request when it exceeds the limit.
+type countingBody struct {
+	io.ReadCloser
+	stats *requestStats
+	size  int64
+	limit int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	b.stats.addBytesSent(int64(n))
+	if b.limit > 0 && b.size > b.limit {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrRequestTooLarge, b.limit)
+	}
+	return n, err
+}
@@ -0,0 +1,107 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SRVScheme is the scheme of BaseURL that is resolved with DNS SRV (or Config.ResolveFunc) to http://host:port.
+	SRVScheme = "srv"
+	// SRVSchemeHTTPS is the scheme of BaseURL that is resolved with DNS SRV (or Config.ResolveFunc) to https://host:port.
+	SRVSchemeHTTPS = "srv+https"
+
+	defaultResolveCacheTTL = 30 * time.Second
+)
+
+// ErrNoAddresses is returned when the service of srv:// BaseURL is resolved to an empty list of addresses.
+var ErrNoAddresses = errors.New("no addresses for service")
+
+// baseURLResolver resolves the service from srv:// BaseURL to the list of addresses
+// and returns them in round-robin order. Addresses are cached for the TTL.
+type baseURLResolver struct {
+	scheme  string
+	service string
+	path    string
+	lookup  func(ctx context.Context, service string) ([]string, error)
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	addrs   []string
+	expires time.Time
+	next    int
+}
+
+func newBaseURLResolver(cfg Config) (*baseURLResolver, error) {
+	if !isSRVURL(cfg.BaseURL) {
+		return nil, nil
+	}
+	u, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	r := &baseURLResolver{
+		scheme:  "http",
+		service: u.Host,
+		path:    strings.TrimSuffix(u.Path, "/"),
+		lookup:  cfg.ResolveFunc,
+		ttl:     cfg.ResolveCacheTTL,
+	}
+	if u.Scheme == SRVSchemeHTTPS {
+		r.scheme = "https"
+	}
+	if r.lookup == nil {
+		r.lookup = lookupSRV
+	}
+	return r, nil
+}
+
+// resolve returns the base URL with the next resolved address.
+func (r *baseURLResolver) resolve(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.addrs) == 0 || time.Now().After(r.expires) {
+		addrs, err := r.lookup(ctx, r.service)
+		if err != nil {
+			return "", fmt.Errorf("resolve %s: %w", r.service, err)
+		}
+		if len(addrs) == 0 {
+			return "", fmt.Errorf("resolve %s: %w", r.service, ErrNoAddresses)
+		}
+		r.addrs, r.expires = addrs, time.Now().Add(r.ttl)
+	}
+
+	addr := r.addrs[r.next%len(r.addrs)]
+	r.next++
+
+	return r.scheme + "://" + addr + r.path, nil
+}
+
+// lookupSRV returns addresses of SRV records with the lowest priority.
+func lookupSRV(ctx context.Context, service string) ([]string, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", service)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, rec := range records {
+		// Records are sorted by priority
+		if rec.Priority != records[0].Priority {
+			break
+		}
+		addrs = append(addrs, net.JoinHostPort(strings.TrimSuffix(rec.Target, "."), strconv.Itoa(int(rec.Port))))
+	}
+	return addrs, nil
+}
+
+func isSRVURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, SRVScheme+"://") || strings.HasPrefix(rawURL, SRVSchemeHTTPS+"://")
+}
@@ -0,0 +1,61 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_SRVBaseURL(t *testing.T) {
+	var hits [2]atomic.Int64
+	servers := make([]*httptest.Server, 2)
+	for i := range servers {
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/users", r.URL.Path)
+			hits[i].Add(1)
+		}))
+		defer servers[i].Close()
+	}
+
+	var lookups atomic.Int64
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL: "srv://users.service.consul/api",
+		ResolveFunc: func(ctx context.Context, service string) ([]string, error) {
+			assert.Equal(t, "users.service.consul", service)
+			lookups.Add(1)
+			return []string{
+				strings.TrimPrefix(servers[0].URL, "http://"),
+				strings.TrimPrefix(servers[1].URL, "http://"),
+			}, nil
+		},
+		ResolveCacheTTL: time.Minute,
+	})
+	require.NoError(t, err)
+
+	for range 4 {
+		_, err = client.Get(context.Background(), "/users")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(1), lookups.Load())
+	assert.Equal(t, int64(2), hits[0].Load())
+	assert.Equal(t, int64(2), hits[1].Load())
+
+	client, err = cliex.NewWithConfig(cliex.Config{
+		BaseURL: "srv://users.service.consul",
+		ResolveFunc: func(ctx context.Context, service string) ([]string, error) {
+			return nil, nil
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Get(context.Background(), "/users")
+	assert.ErrorIs(t, err, cliex.ErrNoAddresses)
+}
@@ -0,0 +1,198 @@
+package cliex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
+)
+
+var errStreamRead = errors.New("streamed body is already read by Reader")
+
+// Response is the wrapper of the resty response with lazy access to the body.
+// Body is read from OutputPath file on the first access if the response was saved to a file.
+type Response struct {
+	resp      *resty.Response
+	isSuccess func(*resty.Response) bool
+
+	once sync.Once
+	body []byte
+	err  error
+}
+
+// WrapResponse returns a Response wrapper of the resty response.
+func WrapResponse(resp *resty.Response) *Response {
+	return &Response{resp: resp}
+}
+
+// Do makes HTTP request with the given options like Request and returns the Response wrapper.
+func (c *HTTP) Do(ctx context.Context, url string, opts RequestOpts) (*Response, error) {
+	resp, err := c.Request(ctx, url, opts)
+	if resp == nil {
+		return nil, err
+	}
+	out := WrapResponse(resp)
+	out.isSuccess = c.isSuccess
+	return out, err
+}
+
+// Raw returns the underlying resty response.
+func (r *Response) Raw() *resty.Response {
+	return r.resp
+}
+
+// StatusCode returns the status code of the response, zero if there is no response.
+func (r *Response) StatusCode() int {
+	if r.resp == nil {
+		return 0
+	}
+	return r.resp.StatusCode()
+}
+
+// Header returns the headers of the response.
+func (r *Response) Header() http.Header {
+	if r.resp == nil {
+		return http.Header{}
+	}
+	return r.resp.Header()
+}
+
+// ETag returns the ETag header of the response, e.g. to send it as RequestOpts.IfMatch.
+func (r *Response) ETag() string {
+	return r.Header().Get("ETag")
+}
+
+// Trailer returns the trailer headers of the response, e.g. grpc-status of gRPC-web protocols.
+// Trailers of the response with raw body (e.g. saved to OutputPath) are available only after the body is read.
+func (r *Response) Trailer() http.Header {
+	if r.resp == nil || r.resp.RawResponse == nil || r.resp.RawResponse.Trailer == nil {
+		return http.Header{}
+	}
+	return r.resp.RawResponse.Trailer
+}
+
+// Duration returns the time spent on the request.
+func (r *Response) Duration() time.Duration {
+	if r.resp == nil {
+		return 0
+	}
+	return r.resp.Time()
+}
+
+// Stats returns the total duration and the number of attempts of the request, see Stats.
+func (r *Response) Stats() RequestStats {
+	return Stats(r.resp)
+}
+
+// IsSuccess reports whether the response is successful by Config.IsSuccess of the client for responses of Do
+// and returns true if the status code is 2xx for responses of WrapResponse.
+func (r *Response) IsSuccess() bool {
+	if r.isSuccess != nil && r.resp != nil {
+		return r.isSuccess(r.resp)
+	}
+	return r.StatusCode() >= 200 && r.StatusCode() < 300
+}
+
+// IsRedirect returns true if the status code is 3xx.
+func (r *Response) IsRedirect() bool {
+	return r.StatusCode() >= 300 && r.StatusCode() < 400
+}
+
+// IsClientError returns true if the status code is 4xx.
+func (r *Response) IsClientError() bool {
+	return r.StatusCode() >= 400 && r.StatusCode() < 500
+}
+
+// IsServerError returns true if the status code is 5xx.
+func (r *Response) IsServerError() bool {
+	return r.StatusCode() >= 500
+}
+
+// Location returns the Location header of the 3xx response resolved against the request URL.
+func (r *Response) Location() (*url.URL, error) {
+	return RedirectLocation(r.resp)
+}
+
+// SavedFile returns the path of the file where the response was saved with OutputPath, empty if it was not saved.
+func (r *Response) SavedFile() string {
+	if r.resp == nil || r.resp.Request == nil {
+		return ""
+	}
+	return getRequestState(r.resp.Request.Context()).getSavedFile()
+}
+
+func (r *Response) isStreamed() bool {
+	return r.resp != nil && r.resp.Request != nil && getRequestState(r.resp.Request.Context()).isStreamed()
+}
+
+// Bytes returns the body of the response. If the response was saved to a file, the file is read on the first call.
+func (r *Response) Bytes() ([]byte, error) {
+	r.once.Do(func() {
+		if r.resp == nil {
+			return
+		}
+		if path := r.SavedFile(); path != "" {
+			r.body, r.err = os.ReadFile(path)
+			return
+		}
+		if r.isStreamed() {
+			raw := r.resp.RawBody()
+			r.body, r.err = io.ReadAll(raw)
+			raw.Close()
+			return
+		}
+		r.body = r.resp.Body()
+	})
+	return r.body, r.err
+}
+
+// String returns the body of the response as a string.
+func (r *Response) String() (string, error) {
+	body, err := r.Bytes()
+	return string(body), err
+}
+
+// JSON decodes the JSON body of the response into v.
+func (r *Response) JSON(v any) error {
+	body, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// Reader returns a reader of the body. If the response was saved to a file, the file is opened
+// without loading it into memory. The body of the response with RequestOpts.StreamResponse is returned as is
+// and can be read only once. Reader should be closed after use.
+func (r *Response) Reader() (io.ReadCloser, error) {
+	if path := r.SavedFile(); path != "" {
+		return os.Open(path)
+	}
+	if r.isStreamed() {
+		var raw io.ReadCloser
+		r.once.Do(func() {
+			raw = r.resp.RawBody()
+			r.err = errStreamRead
+		})
+		if raw != nil {
+			return raw, nil
+		}
+	}
+	body, err := r.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(body)), nil
+}
@@ -0,0 +1,90 @@
+package cliex_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Do(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/test": func(ctx context.Context, req *http.Request) (any, error) {
+			return map[string]string{"key": "value"}, nil
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	resp, err := client.Do(ctx, "/test", cliex.RequestOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.True(t, resp.IsSuccess())
+	assert.False(t, resp.IsRedirect())
+	assert.False(t, resp.IsClientError())
+	assert.False(t, resp.IsServerError())
+	assert.Empty(t, resp.SavedFile())
+	assert.NotNil(t, resp.Raw())
+
+	var body map[string]string
+	require.NoError(t, resp.JSON(&body))
+	assert.Equal(t, "value", body["key"])
+
+	text, err := resp.String()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"key":"value"}`, text)
+
+	// Saved file is read lazily
+	path := filepath.Join(t.TempDir(), "out.json")
+	resp, err = client.Do(ctx, "/test", cliex.RequestOpts{OutputPath: path})
+	require.NoError(t, err)
+	assert.Equal(t, path, resp.SavedFile())
+
+	r, err := resp.Reader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.JSONEq(t, `{"key":"value"}`, string(data))
+
+	body = nil
+	require.NoError(t, resp.JSON(&body))
+	assert.Equal(t, "value", body["key"])
+}
+
+func TestHTTP_DoIsSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	// 404 is a legitimate result of the API
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithIsSuccess(func(resp *resty.Response) bool {
+		return resp.StatusCode() < 300 || resp.StatusCode() == http.StatusNotFound
+	}))
+	require.NoError(t, err)
+
+	resp, err := client.Do(context.Background(), "/missing", cliex.RequestOpts{})
+	require.NoError(t, err)
+	assert.True(t, resp.IsSuccess())
+	assert.True(t, resp.IsClientError())
+
+	// Responses of WrapResponse are successful only with 2xx status codes
+	assert.False(t, cliex.WrapResponse(resp.Raw()).IsSuccess())
+}
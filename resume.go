@@ -0,0 +1,279 @@
+package cliex
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	partFileSuffix = ".part"
+	partMetaSuffix = ".part.json"
+)
+
+var (
+	// ErrChecksumMismatch is returned by DownloadFile when the checksum of the file doesn't match DownloadOpts.Checksum.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrInconsistentRange is returned by DownloadFile when the resumed response doesn't match the partial file,
+	// the partial file is deleted, so the next download starts from the beginning.
+	ErrInconsistentRange = errors.New("inconsistent range response")
+)
+
+// partMeta is the validators of the partially downloaded file that are stored next to it.
+type partMeta struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Total        int64  `json:"total"`
+}
+
+// validator returns the value for If-Range header, weak ETags cannot be used for range requests.
+func (m partMeta) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+// interruptedError is the error of the transfer of the body that can be resumed.
+type interruptedError struct {
+	err error
+}
+
+func (e *interruptedError) Error() string { return e.err.Error() }
+func (e *interruptedError) Unwrap() error { return e.err }
+
+// downloadResumable downloads the file to <path>.part and continues the download from the end of the partial file
+// with Range request. Interrupted transfers are resumed up to RetryCount times.
+func (c *HTTP) downloadResumable(ctx context.Context, url, path string, opts DownloadOpts) (*resty.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.downloadRange(ctx, url, path, opts)
+		var interrupted *interruptedError
+		if err == nil || !errors.As(err, &interrupted) || ctx.Err() != nil || attempt >= opts.RetryCount {
+			return resp, err
+		}
+		if !opts.NoLogRetryError {
+			c.log.Warn("download is interrupted, resuming", append(identityAttrs(opts.RequestName, opts.RequestLabels),
+				"error", err, "n", attempt, "path", path)...)
+		}
+	}
+}
+
+func (c *HTTP) downloadRange(ctx context.Context, url, path string, opts DownloadOpts) (*resty.Response, error) {
+	partPath, metaPath := path+partFileSuffix, path+partMetaSuffix
+	file, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open partial file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat partial file: %w", err)
+	}
+
+	offset, meta := info.Size(), readPartMeta(metaPath)
+	if offset > 0 && meta.validator() == "" {
+		// There is no way to check that the partial file is of the same resource
+		offset = 0
+	}
+
+	reqOpts := opts.RequestOpts
+	reqOpts.OutputPath = ""
+	reqOpts.StreamResponse = true
+	reqOpts.IdentityEncoding = true
+	var resp *resty.Response
+	for {
+		reqOpts.Headers = maps.Clone(opts.Headers)
+		if offset > 0 {
+			if reqOpts.Headers == nil {
+				reqOpts.Headers = make(map[string]string, 2)
+			}
+			reqOpts.Headers["Range"] = "bytes=" + strconv.FormatInt(offset, 10) + "-"
+			reqOpts.Headers["If-Range"] = meta.validator()
+		}
+		resp, err = c.Request(ctx, url, reqOpts)
+		if offset > 0 && errors.Is(err, ErrRangeNotSatisfiable) {
+			apiErr, _ := AsAPIError(err)
+			if _, _, total, ok := parseContentRange(apiErr.Headers.Get("Content-Range")); ok && total == offset {
+				// The partial file is already complete
+				return resp, finishPart(file, metaPath, path, opts)
+			}
+			offset = 0
+			continue
+		}
+		if err != nil {
+			return resp, err
+		}
+		break
+	}
+	raw := resp.RawBody()
+	defer raw.Close()
+
+	total := resp.RawResponse.ContentLength
+	if resp.StatusCode() == http.StatusPartialContent {
+		start, _, rangeTotal, ok := parseContentRange(resp.Header().Get("Content-Range"))
+		etag := resp.Header().Get("ETag")
+		if !ok || start != offset || (meta.Total > 0 && rangeTotal > 0 && rangeTotal != meta.Total) ||
+			(meta.ETag != "" && etag != "" && etag != meta.ETag) {
+			os.Remove(metaPath)
+			file.Truncate(0)
+			return resp, fmt.Errorf("%w: content range %q", ErrInconsistentRange, resp.Header().Get("Content-Range"))
+		}
+		total = rangeTotal
+	} else {
+		// The server sent the whole content
+		offset = 0
+	}
+
+	meta = partMeta{ETag: resp.Header().Get("ETag"), LastModified: resp.Header().Get("Last-Modified"), Total: total}
+	if err := writePartMeta(metaPath, meta); err != nil {
+		return resp, err
+	}
+	if err := file.Truncate(offset); err != nil {
+		return resp, fmt.Errorf("truncate partial file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return resp, fmt.Errorf("seek partial file: %w", err)
+	}
+
+	var w io.Writer = file
+	if opts.TeeWriter != nil {
+		w = io.MultiWriter(w, opts.TeeWriter)
+	}
+	if opts.OnDownloadProgress != nil {
+		w = &progressWriter{w: w, done: offset, total: total, f: opts.OnDownloadProgress}
+	}
+	n, err := io.Copy(w, raw)
+	if err != nil {
+		return resp, &interruptedError{err: fmt.Errorf("save response: %w", err)}
+	}
+	if total >= 0 && offset+n != total {
+		return resp, &interruptedError{err: fmt.Errorf("save response: %w: got %d of %d bytes", io.ErrUnexpectedEOF, offset+n, total)}
+	}
+	return resp, finishPart(file, metaPath, path, opts)
+}
+
+// finishPart deletes the metadata of the completed partial file and renames it to the path.
+func finishPart(file *os.File, metaPath, path string, opts DownloadOpts) error {
+	if err := commitOutput(file, RequestOpts{OutputPath: path, OutputSync: opts.OutputSync}); err != nil {
+		return err
+	}
+	os.Remove(metaPath)
+	return nil
+}
+
+func readPartMeta(path string) partMeta {
+	var meta partMeta
+	data, err := os.ReadFile(path)
+	if err == nil {
+		jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+func writePartMeta(path string, meta partMeta) error {
+	data, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write partial file metadata: %w", err)
+	}
+	return nil
+}
+
+// parseContentRange parses Content-Range header "bytes <start>-<end>/<total>" or "bytes */<total>",
+// start and end are -1 for unsatisfied range, total is -1 if it is unknown.
+func parseContentRange(value string) (start, end, total int64, ok bool) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(value), "bytes ")
+	if !found {
+		return 0, 0, 0, false
+	}
+	rng, size, found := strings.Cut(rest, "/")
+	if !found {
+		return 0, 0, 0, false
+	}
+	total = -1
+	if size != "*" {
+		var err error
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, 0, false
+		}
+	}
+	if rng == "*" {
+		return -1, -1, total, true
+	}
+	first, last, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, 0, false
+	}
+	start, err1 := strconv.ParseInt(first, 10, 64)
+	end, err2 := strconv.ParseInt(last, 10, 64)
+	if err1 != nil || err2 != nil || start > end {
+		return 0, 0, 0, false
+	}
+	return start, end, total, true
+}
+
+// parseChecksum returns the hash and the expected digest of the checksum "<algorithm>:<hex>".
+func parseChecksum(checksum string) (hash.Hash, []byte, error) {
+	algorithm, digest, ok := strings.Cut(checksum, ":")
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid checksum %q, expected <algorithm>:<hex>", checksum)
+	}
+	var h hash.Hash
+	switch strings.ToLower(algorithm) {
+	case "md5":
+		h = md5.New()
+	case "sha1":
+		h = sha1.New()
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, nil, fmt.Errorf("unsupported checksum algorithm %s", algorithm)
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil || len(expected) != h.Size() {
+		return nil, nil, fmt.Errorf("invalid %s checksum digest %q", algorithm, digest)
+	}
+	return h, expected, nil
+}
+
+// verifyChecksum compares the checksum of the file with the expected one, the file is deleted if they don't match.
+func verifyChecksum(path, checksum string) error {
+	h, expected, err := parseChecksum(checksum)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open downloaded file: %w", err)
+	}
+	_, err = io.Copy(h, file)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("read downloaded file: %w", err)
+	}
+	if actual := h.Sum(nil); !bytes.Equal(actual, expected) {
+		os.Remove(path)
+		return fmt.Errorf("%w: expected %x, got %x", ErrChecksumMismatch, expected, actual)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package cliex_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_DownloadFile_Resume(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		content  = []byte(strings.Repeat("0123456789", 10000))
+		etag     = `"v1"`
+		breakAt  = len(content) / 2
+		requests []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Header.Get("Range"))
+		data, tag, limit := content, etag, breakAt
+		breakAt = 0
+		mu.Unlock()
+
+		w.Header().Set("ETag", tag)
+		if limit > 0 {
+			// Send a half of the content and drop the connection
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Write(data[:limit])
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "file.bin")
+	sum := sha256.Sum256(content)
+	opts := cliex.DownloadOpts{Resume: true, Checksum: "sha256:" + hex.EncodeToString(sum[:])}
+
+	_, err = client.DownloadFile(ctx, "/file", path, opts)
+	require.Error(t, err)
+	part, err := os.ReadFile(path + ".part")
+	require.NoError(t, err)
+	assert.Len(t, part, len(content)/2)
+	assert.NoFileExists(t, path)
+
+	_, err = client.DownloadFile(ctx, "/file", path, opts)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.NoFileExists(t, path+".part")
+	assert.Equal(t, []string{"", "bytes=50000-"}, requests)
+
+	// Retries resume the interrupted transfer, the changed resource is downloaded from the beginning
+	mu.Lock()
+	requests, breakAt, etag = nil, 10000, `"v2"`
+	content = []byte(strings.Repeat("abcdefghij", 10000))
+	mu.Unlock()
+	os.Remove(path)
+	opts = cliex.DownloadOpts{Resume: true, RequestOpts: cliex.RequestOpts{RetryCount: 2, NoLogRetryError: true}}
+	_, err = client.DownloadFile(ctx, "/file", path, opts)
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Equal(t, []string{"", "bytes=10000-"}, requests)
+
+	require.NoError(t, os.WriteFile(path+".part", content[:10], 0o644))
+	require.NoError(t, os.WriteFile(path+".part.json", []byte(`{"etag":"\"v1\""}`), 0o644))
+	_, err = client.DownloadFile(ctx, "/file", path, opts)
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	_, err = client.DownloadFile(ctx, "/file", path, cliex.DownloadOpts{Checksum: "sha256:" + hex.EncodeToString(sum[:])})
+	require.ErrorIs(t, err, cliex.ErrChecksumMismatch)
+	assert.NoFileExists(t, path)
+
+	_, err = client.DownloadFile(ctx, "/file", path, cliex.DownloadOpts{Checksum: "crc:00"})
+	require.Error(t, err)
+}
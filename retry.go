@@ -0,0 +1,155 @@
+package cliex
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrRetryDeadline is the cause of RetryError when RequestOpts.RetryWithinDeadline is set
+// and the next retry would not finish before the context deadline.
+var ErrRetryDeadline = errors.New("deadline would be exceeded")
+
+// RetryAttempt is a single failed attempt of the request.
+type RetryAttempt struct {
+	// N is the number of the attempt starting from 1.
+	N int
+	// Time is the time when the attempt was finished.
+	Time time.Time
+	// Wait is the time waited before the attempt.
+	Wait time.Duration
+	// Duration is the time spent on the attempt itself.
+	Duration time.Duration
+	// Err is the error of the attempt.
+	Err error
+}
+
+// RetryError is returned when the request failed after all retries or the context was canceled during retries.
+// It keeps every failed attempt, use errors.As to get it. Error() returns a compact summary
+// where the same errors are counted instead of being repeated.
+type RetryError struct {
+	// RequestName is the name of the request from RequestOpts.
+	RequestName string
+	// Labels is the labels of the request from RequestOpts.
+	Labels map[string]string
+	// Attempts is the list of failed attempts in order.
+	Attempts []RetryAttempt
+	// Cause is the reason of stopping retries before using all of them, e.g. context error.
+	Cause error
+}
+
+// Error returns the summary of the retries.
+func (e *RetryError) Error() string {
+	var b strings.Builder
+
+	retries := strconv.Itoa(max(len(e.Attempts)-1, 0))
+	if e.Cause != nil {
+		b.WriteString("failed " + requestTitle(e.RequestName) + "request, canceled after " + retries + " retries: " + e.Cause.Error())
+	} else {
+		b.WriteString("failed " + requestTitle(e.RequestName) + "request after " + retries + " retries")
+	}
+	if len(e.Attempts) == 0 {
+		return b.String()
+	}
+
+	b.WriteString(", got errors: ")
+
+	var (
+		order  []string
+		counts = make(map[string]int, len(e.Attempts))
+	)
+	for _, a := range e.Attempts {
+		msg := "<nil>"
+		if a.Err != nil {
+			msg = a.Err.Error()
+		}
+		if counts[msg] == 0 {
+			order = append(order, msg)
+		}
+		counts[msg]++
+	}
+	for i, msg := range order {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(msg)
+		if counts[msg] > 1 {
+			b.WriteString(" (x" + strconv.Itoa(counts[msg]) + ")")
+		}
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the cause and errors of all attempts, so errors.Is works with any of them.
+func (e *RetryError) Unwrap() []error {
+	out := make([]error, 0, len(e.Attempts)+1)
+	if e.Cause != nil {
+		out = append(out, e.Cause)
+	}
+	for _, a := range e.Attempts {
+		if a.Err != nil {
+			out = append(out, a.Err)
+		}
+	}
+	return out
+}
+
+// Last returns the error of the last attempt, nil if there are no attempts.
+func (e *RetryError) Last() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+func (e *RetryError) add(n int, wait, duration time.Duration, err error) {
+	e.Attempts = append(e.Attempts, RetryAttempt{N: n, Time: time.Now(), Wait: wait, Duration: duration, Err: err})
+}
+
+// fitsDeadline returns false if the next attempt after the wait is not expected to finish before the deadline.
+// The attempt is expected to take as long as the slowest of the previous ones.
+func (e *RetryError) fitsDeadline(deadline time.Time, wait time.Duration) bool {
+	var longest time.Duration
+	for _, a := range e.Attempts {
+		longest = max(longest, a.Duration)
+	}
+	return time.Now().Add(wait + longest).Before(deadline)
+}
+
+// AsRetryError returns RetryError from the error chain.
+func AsRetryError(err error) (*RetryError, bool) {
+	var retryErr *RetryError
+	if errors.As(err, &retryErr) {
+		return retryErr, true
+	}
+	return nil, false
+}
+
+// backoffHeader is the vendor-specific header with the wait time before the next retry.
+type backoffHeader struct {
+	name string
+	unit time.Duration
+}
+
+// retryAfter returns the wait time before the next attempt from the backoff header of any failed response
+// or from Retry-After header of 429 and 503 responses.
+func (b backoffHeader) retryAfter(resp *resty.Response) (time.Duration, bool) {
+	if b.name != "" && resp != nil {
+		if value := strings.TrimSpace(resp.Header().Get(b.name)); value != "" {
+			if n, err := strconv.ParseFloat(value, 64); err == nil && n >= 0 && n < math.MaxInt64/float64(b.unit) {
+				return time.Duration(n * float64(b.unit)), true
+			}
+		}
+	}
+	switch statusCode(resp) {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return parseRetryAfter(resp.Header().Get("Retry-After"), time.Now())
+	}
+	return 0, false
+}
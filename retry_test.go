@@ -0,0 +1,190 @@
+package cliex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_RetryError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/fail": func(ctx context.Context, req *http.Request) (any, error) {
+			return nil, cliex.ErrServiceUnavailable
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	_, err = client.Request(ctx, "/fail", cliex.RequestOpts{
+		RequestName:      "test",
+		RetryCount:       5,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: 5 * time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+
+	retryErr, ok := cliex.AsRetryError(err)
+	require.True(t, ok)
+	require.Len(t, retryErr.Attempts, 5)
+	for i, a := range retryErr.Attempts {
+		assert.Equal(t, i+1, a.N)
+		assert.False(t, a.Time.IsZero())
+		assert.ErrorIs(t, a.Err, cliex.ErrServiceUnavailable)
+	}
+	assert.Zero(t, retryErr.Attempts[0].Wait)
+	assert.Positive(t, retryErr.Attempts[1].Wait)
+	assert.ErrorIs(t, retryErr.Last(), cliex.ErrServiceUnavailable)
+
+	// Same errors are counted instead of being repeated
+	assert.NotContains(t, err.Error(), ";")
+	assert.Contains(t, err.Error(), "failed test request after 4 retries")
+	assert.Contains(t, err.Error(), "(x5)")
+}
+
+func TestHTTP_RetryErrorCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/fail": func(ctx context.Context, req *http.Request) (any, error) {
+			return nil, cliex.ErrBadGateway
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	reqCtx, reqCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer reqCancel()
+
+	_, err = client.Request(reqCtx, "/fail", cliex.RequestOpts{
+		InfiniteRetry:    true,
+		RetryWaitTime:    10 * time.Millisecond,
+		RetryMaxWaitTime: 20 * time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, cliex.ErrBadGateway)
+	assert.Contains(t, err.Error(), "canceled after")
+
+	var retryErr *cliex.RetryError
+	require.True(t, errors.As(err, &retryErr))
+	assert.NotEmpty(t, retryErr.Attempts)
+}
+
+func TestHTTP_RetryWithinDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/slow": cliex.WithDelayForTest(50*time.Millisecond, func(ctx context.Context, req *http.Request) (any, error) {
+			return nil, cliex.ErrServiceUnavailable
+		}),
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	reqCtx, reqCancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer reqCancel()
+
+	start := time.Now()
+	_, err = client.Request(reqCtx, "/slow", cliex.RequestOpts{
+		InfiniteRetry:       true,
+		RetryWaitTime:       100 * time.Millisecond,
+		RetryMaxWaitTime:    100 * time.Millisecond,
+		RetryWithinDeadline: true,
+		NoLogRetryError:     true,
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, cliex.ErrRetryDeadline)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 300*time.Millisecond)
+	assert.Equal(t, int64(2), requestCounter.Load())
+}
+
+func TestHTTP_RetryAfter(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1)%2 == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	opts := cliex.RequestOpts{
+		RetryCount:       2,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: 200 * time.Millisecond,
+		NoLogRetryError:  true,
+	}
+	start := time.Now()
+	_, err = client.Request(context.Background(), "/", opts)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	opts.IgnoreRetryAfter = true
+	start = time.Now()
+	_, err = client.Request(context.Background(), "/", opts)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.EqualValues(t, 4, requests.Load())
+}
+
+func TestHTTP_BackoffHeader(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1)%2 == 1 {
+			w.Header().Set("X-Backoff-Millis", "150")
+			w.Header().Set("Retry-After", "10")
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithBackoffHeader("X-Backoff-Millis", time.Millisecond))
+	require.NoError(t, err)
+
+	opts := cliex.RequestOpts{
+		RetryCount:       2,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Second,
+		NoLogRetryError:  true,
+	}
+	start := time.Now()
+	_, err = client.Request(context.Background(), "/", opts)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+
+	opts.IgnoreRetryAfter = true
+	start = time.Now()
+	_, err = client.Request(context.Background(), "/", opts)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
@@ -0,0 +1,94 @@
+package cliex
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/lang"
+)
+
+// RetryPolicy decides whether the failed request is retried and how long to wait before the next attempt.
+// Retries are still limited by RequestOpts.RetryCount and other retry options of the request.
+type RetryPolicy interface {
+	// NextDelay returns the wait time before the next attempt after the failed attempt with the number
+	// starting from 1, the response (nil if it is not received) and the error of the attempt.
+	// Retrying is stopped if it returns false.
+	NextDelay(attempt int, resp *resty.Response, err error) (time.Duration, bool)
+}
+
+// RetryPolicyFunc is the function that implements RetryPolicy.
+type RetryPolicyFunc func(attempt int, resp *resty.Response, err error) (time.Duration, bool)
+
+// NextDelay calls the function.
+func (f RetryPolicyFunc) NextDelay(attempt int, resp *resty.Response, err error) (time.Duration, bool) {
+	return f(attempt, resp, err)
+}
+
+// ExponentialJitterRetry returns the policy that doubles the wait time for every attempt starting from minWait
+// with a random jitter, capped by maxWait. It is the default policy of requests with RetryWaitTime and RetryMaxWaitTime.
+func ExponentialJitterRetry(minWait, maxWait time.Duration) RetryPolicy {
+	minWait = lang.Check(minWait, defaultWaitTime)
+	maxWait = lang.Check(maxWait, defaultMaxWaitTime)
+	return RetryPolicyFunc(func(attempt int, _ *resty.Response, _ error) (time.Duration, bool) {
+		return getSleepTime(attempt, minWait, maxWait), true
+	})
+}
+
+// ConstantRetry returns the policy that waits the same time before every attempt.
+func ConstantRetry(wait time.Duration) RetryPolicy {
+	return RetryPolicyFunc(func(int, *resty.Response, error) (time.Duration, bool) {
+		return wait, true
+	})
+}
+
+// FibonacciRetry returns the policy that waits base multiplied by Fibonacci numbers (1, 1, 2, 3, 5, ...)
+// before attempts, capped by maxWait. Default maxWait is 0, means no limit.
+func FibonacciRetry(base, maxWait time.Duration) RetryPolicy {
+	return RetryPolicyFunc(func(attempt int, _ *resty.Response, _ error) (time.Duration, bool) {
+		prev, cur := time.Duration(0), base
+		for range attempt - 1 {
+			prev, cur = cur, prev+cur
+			if maxWait > 0 && cur >= maxWait {
+				return maxWait, true
+			}
+		}
+		return lang.If(maxWait > 0, min(cur, maxWait), cur), true
+	})
+}
+
+// RetryAfterRetry returns the policy that waits for Retry-After of 429 and 503 responses (delta seconds or HTTP date)
+// capped by maxWait, and uses the fallback policy for other failures. Default fallback is ExponentialJitterRetry
+// with default wait times, default maxWait is 0, means no limit.
+func RetryAfterRetry(fallback RetryPolicy, maxWait time.Duration) RetryPolicy {
+	if fallback == nil {
+		fallback = ExponentialJitterRetry(0, 0)
+	}
+	return RetryPolicyFunc(func(attempt int, resp *resty.Response, err error) (time.Duration, bool) {
+		switch statusCode(resp) {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			if wait, ok := parseRetryAfter(resp.Header().Get("Retry-After"), time.Now()); ok {
+				return lang.If(maxWait > 0, min(wait, maxWait), wait), true
+			}
+		}
+		return fallback.NextDelay(attempt, resp, err)
+	})
+}
+
+// retryPolicy returns the policy of the request: RequestOpts.RetryPolicy, Config.RetryPolicy or the default one
+// with exponential backoff and Retry-After (or Config.BackoffHeader) capped by RetryMaxWaitTime.
+func (c *HTTP) retryPolicy(opts RequestOpts) RetryPolicy {
+	if policy := lang.If(opts.RetryPolicy != nil, opts.RetryPolicy, c.retry); policy != nil {
+		return policy
+	}
+	exponential := ExponentialJitterRetry(opts.RetryWaitTime, opts.RetryMaxWaitTime)
+	if opts.IgnoreRetryAfter {
+		return exponential
+	}
+	return RetryPolicyFunc(func(attempt int, resp *resty.Response, err error) (time.Duration, bool) {
+		if wait, ok := c.backoff.retryAfter(resp); ok {
+			return min(wait, opts.RetryMaxWaitTime), true
+		}
+		return exponential.NextDelay(attempt, resp, err)
+	})
+}
@@ -0,0 +1,98 @@
+package cliex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicies(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	var waits []time.Duration
+	fibonacci := cliex.FibonacciRetry(time.Millisecond, 6*time.Millisecond)
+	for attempt := 1; attempt <= 6; attempt++ {
+		wait, ok := fibonacci.NextDelay(attempt, nil, errFailed)
+		require.True(t, ok)
+		waits = append(waits, wait/time.Millisecond)
+	}
+	assert.Equal(t, []time.Duration{1, 1, 2, 3, 5, 6}, waits)
+
+	wait, ok := cliex.ConstantRetry(time.Second).NextDelay(5, nil, errFailed)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	for attempt := 1; attempt <= 5; attempt++ {
+		wait, ok := cliex.ExponentialJitterRetry(10*time.Millisecond, 50*time.Millisecond).NextDelay(attempt, nil, errFailed)
+		require.True(t, ok)
+		assert.GreaterOrEqual(t, wait, 10*time.Millisecond)
+		assert.LessOrEqual(t, wait, 50*time.Millisecond)
+	}
+
+	retryAfter := cliex.RetryAfterRetry(cliex.ConstantRetry(time.Millisecond), 10*time.Second)
+	resp := &resty.Response{RawResponse: &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+	}}
+	wait, ok = retryAfter.NextDelay(1, resp, errFailed)
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Second, wait)
+
+	resp.RawResponse.StatusCode = http.StatusInternalServerError
+	wait, ok = retryAfter.NextDelay(1, resp, errFailed)
+	require.True(t, ok)
+	assert.Equal(t, time.Millisecond, wait)
+
+	// Default fallback is exponential backoff
+	wait, ok = cliex.RetryAfterRetry(nil, 0).NextDelay(1, resp, errFailed)
+	require.True(t, ok)
+	assert.Positive(t, wait)
+}
+
+func TestHTTP_RetryPolicy(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	var attempts []int
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithRetryPolicy(cliex.RetryPolicyFunc(
+		func(attempt int, resp *resty.Response, err error) (time.Duration, bool) {
+			attempts = append(attempts, attempt)
+			return time.Millisecond, resp.StatusCode() == http.StatusServiceUnavailable && attempt < 3
+		})))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// The policy stops retrying before RetryCount is used
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{RetryCount: 10, NoLogRetryError: true})
+	require.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	assert.EqualValues(t, 3, requests.Load())
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+
+	// The policy of the request overrides the client one
+	requests.Store(0)
+	start := time.Now()
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{
+		RetryCount:      3,
+		RetryPolicy:     cliex.ConstantRetry(20 * time.Millisecond),
+		NoLogRetryError: true,
+	})
+	require.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	assert.EqualValues(t, 3, requests.Load())
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	retryErr, ok := cliex.AsRetryError(err)
+	require.True(t, ok)
+	assert.Equal(t, 20*time.Millisecond, retryErr.Attempts[1].Wait)
+}
@@ -0,0 +1,82 @@
+package cliex
+
+import (
+	"net/http"
+	neturl "net/url"
+	"strings"
+
+	"github.com/maxbolgarin/lang"
+)
+
+// RewriteRule changes the outgoing request before sending, e.g. to route staging traffic to another host
+// or to add a path prefix of API gateway. All matching rules are applied in order.
+type RewriteRule struct {
+	// Match selects requests by hosts and path prefixes. Empty filter matches all requests.
+	Match CaptureFilter `yaml:"match" json:"match"`
+
+	// Scheme replaces the scheme of the request URL, e.g. "https". Default is empty, means no change.
+	Scheme string `yaml:"scheme" json:"scheme"`
+
+	// Host replaces the host with optional port of the request URL. Default is empty, means no change.
+	Host string `yaml:"host" json:"host"`
+
+	// PathPrefixFrom is replaced with PathPrefixTo if the path starts with it.
+	// Empty PathPrefixFrom means PathPrefixTo is prepended to the path, empty PathPrefixTo means the prefix is stripped.
+	PathPrefixFrom string `yaml:"path_prefix_from" json:"path_prefix_from"`
+	PathPrefixTo   string `yaml:"path_prefix_to" json:"path_prefix_to"`
+
+	// SetHeaders is the map of headers that are set to the request replacing existing values.
+	SetHeaders map[string]string `yaml:"set_headers" json:"set_headers"`
+
+	// RemoveHeaders is the list of headers that are removed from the request.
+	RemoveHeaders []string `yaml:"remove_headers" json:"remove_headers"`
+}
+
+// requestHost returns the host the request to the URL is sent to after applying RewriteRules, it is the host
+// of host rate limits, throttling, circuit breakers and metrics.
+func (c *HTTP) requestHost(url string) string {
+	fullURL := lang.If(strings.HasPrefix(url, "http"), url, c.cli.BaseURL+url)
+	if len(c.rewrite) == 0 {
+		return hostFromURL(fullURL)
+	}
+	u, err := neturl.Parse(fullURL)
+	if err != nil {
+		return ""
+	}
+	applyRewriteRules(c.rewrite, &http.Request{URL: u, Header: make(http.Header)})
+	return u.Host
+}
+
+// applyRewriteRules changes the request according to the matching rules.
+func applyRewriteRules(rules []RewriteRule, req *http.Request) {
+	for _, rule := range rules {
+		if !rule.Match.IsEmpty() && (!rule.Match.matchHost(req.URL) || !rule.Match.matchPath(req.URL.Path)) {
+			continue
+		}
+		rule.apply(req)
+	}
+}
+
+func (r RewriteRule) apply(req *http.Request) {
+	if r.Scheme != "" {
+		req.URL.Scheme = r.Scheme
+	}
+	if r.Host != "" {
+		req.URL.Host = r.Host
+		// Host header is taken from the URL
+		req.Host = ""
+	}
+	if (r.PathPrefixFrom != "" || r.PathPrefixTo != "") && strings.HasPrefix(req.URL.Path, r.PathPrefixFrom) {
+		req.URL.Path = r.PathPrefixTo + strings.TrimPrefix(req.URL.Path, r.PathPrefixFrom)
+		if !strings.HasPrefix(req.URL.Path, "/") {
+			req.URL.Path = "/" + req.URL.Path
+		}
+		req.URL.RawPath = ""
+	}
+	for _, h := range r.RemoveHeaders {
+		req.Header.Del(h)
+	}
+	for k, v := range r.SetHeaders {
+		req.Header.Set(k, v)
+	}
+}
@@ -0,0 +1,63 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_RewriteRules(t *testing.T) {
+	production := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to production: %s", r.URL.Path)
+	}))
+	defer production.Close()
+
+	staging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("X-Env") + " " + r.Header.Get("X-Debug")))
+	}))
+	defer staging.Close()
+
+	hook := &metricsHookForTest{}
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:     production.URL,
+		MetricsHook: hook,
+		RewriteRules: []cliex.RewriteRule{
+			{
+				Host:          strings.TrimPrefix(staging.URL, "http://"),
+				SetHeaders:    map[string]string{"X-Env": "staging"},
+				RemoveHeaders: []string{"X-Debug"},
+			},
+			{
+				Match:          cliex.CaptureFilter{PathPrefixes: []string{"/v1/"}},
+				PathPrefixFrom: "/v1",
+				PathPrefixTo:   "/gateway/api/v1",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	headers := map[string]string{"X-Debug": "true"}
+
+	resp, err := client.Request(ctx, "/v1/users", cliex.RequestOpts{Headers: headers})
+	require.NoError(t, err)
+	assert.Equal(t, "/gateway/api/v1/users staging", resp.String())
+
+	resp, err = client.Request(ctx, "/health", cliex.RequestOpts{Headers: headers})
+	require.NoError(t, err)
+	assert.Equal(t, "/health staging", resp.String())
+
+	// Metrics are labeled with the rewritten host
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	require.Len(t, hook.starts, 2)
+	for _, info := range hook.starts {
+		assert.Equal(t, strings.TrimPrefix(staging.URL, "http://"), info.Host)
+	}
+}
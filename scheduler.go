@@ -0,0 +1,110 @@
+package cliex
+
+import (
+	"context"
+	"sync"
+)
+
+// Priority is the priority of the request in the queue of MaxConcurrentRequests.
+type Priority int
+
+const (
+	// PriorityNormal is the default priority of requests.
+	PriorityNormal Priority = 0
+	// PriorityHigh requests are sent before any queued normal and low priority requests, e.g. interactive traffic.
+	PriorityHigh Priority = 1
+	// PriorityLow requests are sent only when there are no queued requests with higher priority, e.g. bulk jobs.
+	PriorityLow Priority = -1
+)
+
+// scheduler limits the number of concurrent requests and grants free slots to waiting requests by priority.
+// Requests with the same priority are served in FIFO order.
+type scheduler struct {
+	mu     sync.Mutex
+	free   int
+	queues [3][]chan struct{} // high, normal, low
+}
+
+func newScheduler(maxConcurrent int) *scheduler {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	return &scheduler{free: maxConcurrent}
+}
+
+// acquire blocks until a slot is granted or the context is done.
+func (s *scheduler) acquire(ctx context.Context, p Priority) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.free > 0 && s.queued() == 0 {
+		s.free--
+		s.mu.Unlock()
+		return nil
+	}
+	q := queueIndex(p)
+	ready := make(chan struct{})
+	s.queues[q] = append(s.queues[q], ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		removed := s.remove(q, ready)
+		s.mu.Unlock()
+		if !removed {
+			// Slot was granted concurrently with cancellation
+			s.release()
+		}
+		return ctx.Err()
+	}
+}
+
+// release returns the slot to the waiting request with the highest priority or to the pool.
+func (s *scheduler) release() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, queue := range s.queues {
+		if len(queue) > 0 {
+			close(queue[0])
+			s.queues[i] = queue[1:]
+			return
+		}
+	}
+	s.free++
+}
+
+func (s *scheduler) queued() (n int) {
+	for _, queue := range s.queues {
+		n += len(queue)
+	}
+	return n
+}
+
+func (s *scheduler) remove(q int, ready chan struct{}) bool {
+	for i, ch := range s.queues[q] {
+		if ch == ready {
+			s.queues[q] = append(s.queues[q][:i], s.queues[q][i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func queueIndex(p Priority) int {
+	switch {
+	case p > PriorityNormal:
+		return 0
+	case p < PriorityNormal:
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,74 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Priority(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		order   []string
+		release = make(chan struct{})
+	)
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/block": func(ctx context.Context, req *http.Request) (any, error) {
+			<-release
+			return nil, nil
+		},
+		"/job": func(ctx context.Context, req *http.Request) (any, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, req.URL.Query().Get("name"))
+			return nil, nil
+		},
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+	cfg.MaxConcurrentRequests = 1
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	send := func(path, name string, priority cliex.Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Request(ctx, path, cliex.RequestOpts{
+				Query:    map[string]string{"name": name},
+				Priority: priority,
+			})
+			assert.NoError(t, err)
+		}()
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	send("/block", "block", cliex.PriorityNormal)
+	send("/job", "low", cliex.PriorityLow)
+	send("/job", "normal", cliex.PriorityNormal)
+	send("/job", "high-1", cliex.PriorityHigh)
+	send("/job", "high-2", cliex.PriorityHigh)
+
+	// Canceled request leaves the queue
+	canceledCtx, cancelRequest := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancelRequest()
+	_, err = client.Request(canceledCtx, "/job", cliex.RequestOpts{Priority: cliex.PriorityHigh})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, []string{"high-1", "high-2", "normal", "low"}, order)
+}
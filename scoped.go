@@ -0,0 +1,90 @@
+package cliex
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/lang"
+)
+
+// ScopedHTTP is a view of the HTTP client that applies default request options to all its requests,
+// e.g. a header, an auth token or a retry policy of the incoming request in a handler.
+// It is cheap to create and doesn't modify or copy the underlying client.
+type ScopedHTTP struct {
+	c    *HTTP
+	opts RequestOpts
+}
+
+// With returns a scoped view of the client that uses opts as defaults of every request.
+// Non-zero fields of the request options replace the defaults, maps (Headers, Query, PathParams, etc.) are merged
+// with the values of the request options taking precedence. Boolean defaults can't be turned off by the request
+// options, because false is the zero value, see HTTP.Call.
+func (c *HTTP) With(opts RequestOpts) *ScopedHTTP {
+	return &ScopedHTTP{c: c, opts: opts.clone()}
+}
+
+// With returns a new scoped view with opts merged to the defaults of the current one.
+func (s *ScopedHTTP) With(opts RequestOpts) *ScopedHTTP {
+	return &ScopedHTTP{c: s.c, opts: mergeRequestOpts(s.opts, opts.clone())}
+}
+
+// Client returns the underlying HTTP client.
+func (s *ScopedHTTP) Client() *HTTP {
+	return s.c
+}
+
+// Request makes a request with the default options merged with opts.
+func (s *ScopedHTTP) Request(ctx context.Context, url string, opts RequestOpts) (*resty.Response, error) {
+	return s.c.Request(ctx, url, mergeRequestOpts(s.opts, opts))
+}
+
+// Do makes a request with the default options merged with opts and returns *Response.
+func (s *ScopedHTTP) Do(ctx context.Context, url string, opts RequestOpts) (*Response, error) {
+	return s.c.Do(ctx, url, mergeRequestOpts(s.opts, opts))
+}
+
+// Req performs request with method to the BaseURL + URL and returns response
+func (s *ScopedHTTP) Req(ctx context.Context, method string, url string, requestAndResponseBody ...any) (*resty.Response, error) {
+	return s.Request(ctx, url, RequestOpts{
+		Method: method,
+		Body:   lang.First(requestAndResponseBody),
+		Result: lang.Index(requestAndResponseBody, 1)})
+}
+
+// Get performs GET request to the BaseURL + URL and returns response
+func (s *ScopedHTTP) Get(ctx context.Context, url string, responseBody ...any) (*resty.Response, error) {
+	return s.Request(ctx, url, RequestOpts{
+		Result: lang.First(responseBody)})
+}
+
+// Post performs POST request to the BaseURL + URL and returns response
+func (s *ScopedHTTP) Post(ctx context.Context, url string, requestBody any, responseBody ...any) (*resty.Response, error) {
+	return s.Request(ctx, url, RequestOpts{
+		Method: http.MethodPost,
+		Body:   requestBody,
+		Result: lang.First(responseBody)})
+}
+
+// Put performs PUT request to the BaseURL + URL and returns response
+func (s *ScopedHTTP) Put(ctx context.Context, url string, requestBody any, responseBody ...any) (*resty.Response, error) {
+	return s.Request(ctx, url, RequestOpts{
+		Method: http.MethodPut,
+		Body:   requestBody,
+		Result: lang.First(responseBody)})
+}
+
+// Patch performs PATCH request to the BaseURL + URL and returns response
+func (s *ScopedHTTP) Patch(ctx context.Context, url string, requestBody any, responseBody ...any) (*resty.Response, error) {
+	return s.Request(ctx, url, RequestOpts{
+		Method: http.MethodPatch,
+		Body:   requestBody,
+		Result: lang.First(responseBody)})
+}
+
+// Delete performs DELETE request to the BaseURL + URL and returns response
+func (s *ScopedHTTP) Delete(ctx context.Context, url string, responseBody ...any) (*resty.Response, error) {
+	return s.Request(ctx, url, RequestOpts{
+		Method: http.MethodDelete,
+		Result: lang.First(responseBody)})
+}
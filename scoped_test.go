@@ -0,0 +1,71 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_With(t *testing.T) {
+	var failures atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flaky" && failures.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(r.Method + ";" + r.Header.Get("Authorization") + ";" + r.Header.Get("X-Tenant") + ";" + r.Header.Get("X-Trace")))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	headers := map[string]string{"X-Tenant": "acme", "X-Trace": "default"}
+	scoped := client.With(cliex.RequestOpts{
+		AuthToken:        "user-token",
+		Headers:          headers,
+		RetryCount:       3,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	headers["X-Tenant"] = "changed"
+
+	resp, err := scoped.Get(ctx, "/")
+	require.NoError(t, err)
+	assert.Equal(t, "GET;Bearer user-token;acme;default", resp.String())
+
+	resp, err = scoped.Request(ctx, "/", cliex.RequestOpts{Method: http.MethodPost, Headers: map[string]string{"X-Trace": "abc"}})
+	require.NoError(t, err)
+	assert.Equal(t, "POST;Bearer user-token;acme;abc", resp.String())
+
+	// Header names are merged case-insensitively
+	for range 10 {
+		resp, err = scoped.Request(ctx, "/", cliex.RequestOpts{Headers: map[string]string{"x-trace": "lower"}})
+		require.NoError(t, err)
+		assert.Equal(t, "GET;Bearer user-token;acme;lower", resp.String())
+	}
+
+	resp, err = scoped.Get(ctx, "/flaky")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, failures.Load())
+
+	nested := scoped.With(cliex.RequestOpts{Headers: map[string]string{"X-Trace": "nested"}})
+	resp, err = nested.Delete(ctx, "/")
+	require.NoError(t, err)
+	assert.Equal(t, "DELETE;Bearer user-token;acme;nested", resp.String())
+	assert.Same(t, client, nested.Client())
+
+	// The client itself is not modified
+	resp, err = client.Get(ctx, "/")
+	require.NoError(t, err)
+	assert.Equal(t, "GET;;;", resp.String())
+}
@@ -4,7 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/maxbolgarin/abstract"
@@ -18,8 +24,15 @@ type HTTPSet struct {
 	broken    *abstract.SafeSet[int]
 	log       Logger
 	useBroken bool
+	failover  bool
+	recover   bool
+	mws       []Middleware
+	next      atomic.Uint64
 }
 
+// ErrNoClients is returned when a request is made with an empty HTTPSet.
+var ErrNoClients = errors.New("no clients in set")
+
 // NewSet returns a new HTTPSet with provided clients.
 // You can add client using Add method.
 func NewSet(clis ...*HTTP) *HTTPSet {
@@ -46,6 +59,20 @@ func (c *HTTPSet) WithLogger(logger Logger) *HTTPSet {
 	return c
 }
 
+// WithRecoverPanics enables recovering of panics in requests of RequestBalanced, the panic is logged with Logger
+// and returned as *PanicError. Requests of Request and other fan-out methods always recover panics.
+func (c *HTTPSet) WithRecoverPanics(recoverPanics bool) *HTTPSet {
+	c.recover = recoverPanics
+	return c
+}
+
+// WithFailover sets the failover mode of RequestBalanced. If a request fails on the selected client,
+// it is retried on the next working client. Only requests that are safe to replay are retried, see CanReplay.
+func (c *HTTPSet) WithFailover(failover bool) *HTTPSet {
+	c.failover = failover
+	return c
+}
+
 // Add adds a new HTTP client to the set.
 func (c *HTTPSet) Add(cfgs ...Config) error {
 	if len(cfgs) == 0 {
@@ -60,12 +87,21 @@ func (c *HTTPSet) Add(cfgs ...Config) error {
 		if err != nil {
 			return fmt.Errorf("client %d: %w", i, err)
 		}
+		cli.Use(c.mws...)
 		c.clients = append(c.clients, cli)
 	}
 
 	return nil
 }
 
+// Use adds middlewares to every client in the set and to clients that are added later, see HTTP.Use.
+func (c *HTTPSet) Use(middlewares ...Middleware) {
+	c.mws = append(c.mws, middlewares...)
+	for _, cli := range c.clients {
+		cli.Use(middlewares...)
+	}
+}
+
 // UseBroken returns a new HTTPSet with the same clients but with the UseBroken flag set.
 // When you call Request on this set, only broken clients will be used.
 // Client with successful request will be deleted from broken list.
@@ -77,7 +113,11 @@ func (c *HTTPSet) UseBroken() (*HTTPSet, bool) {
 	out := &HTTPSet{
 		clients:   c.clients,
 		broken:    c.broken,
+		log:       c.log,
 		useBroken: true,
+		failover:  c.failover,
+		recover:   c.recover,
+		mws:       c.mws,
 	}
 
 	return out, true
@@ -106,13 +146,217 @@ func (c *HTTPSet) Client(i int) *HTTP {
 // Request makes a request to the given URL with the given options and returns a list of responses.
 // If useBroken is false, only working clients will be used.
 // If useBroken is true, only broken clients will be used.
+// Every request decodes the response into its own value created by opts.NewResult or cloned from opts.Result,
+// it is available in resp.Result(). opts.Result receives the result of the first successful client after all requests are done.
 func (c *HTTPSet) Request(ctx context.Context, url string, opts RequestOpts) ([]*resty.Response, error) {
+	return c.RequestEach(ctx, url, opts, nil)
+}
+
+// RequestEach makes a request like Request, but options of every client are overridden by the function
+// with the client index, e.g. to set different auth tokens or headers for replicas with distinct credentials.
+// The function receives a copy of opts with copied headers, query and path params, so it may change them.
+func (c *HTTPSet) RequestEach(ctx context.Context, url string, opts RequestOpts, override func(i int, opts *RequestOpts)) ([]*resty.Response, error) {
+	resps, err := c.fanOut(ctx, url, func(i int) RequestOpts {
+		clientOpts := opts
+		if override != nil {
+			clientOpts = opts.clone()
+			override(i, &clientOpts)
+		}
+		clientOpts.Result = newResult(clientOpts)
+		return clientOpts
+	})
+	if len(resps) > 0 {
+		copyResult(opts.Result, resps[0].resp.Result())
+	}
+	return lang.Convert(resps, func(r setResponse) *resty.Response { return r.resp }), err
+}
+
+// RequestBalanced makes a request to the given URL using one of working clients selected in round-robin order.
+// Broken clients are used only if all clients are broken.
+// In failover mode, the failed idempotent request is transparently retried on the next working client
+// if it failed with a network error, 5xx, 408 or 429. RetryCount is the total number of attempts across clients
+// (default is the number of clients), requests to every client are sent without their own retries.
+// When all clients have been tried, the next round starts after the delay of RetryPolicy of the request
+// (or Config.RetryPolicy of the first client, or exponential backoff from RetryWaitTime by default).
+// Non-idempotent request keeps RetryCount for retries of the selected client (limited by RetrySafeOnly)
+// and is sent to the next client only if it failed before being completely sent (ErrNotSent), see CanReplay.
+func (c *HTTPSet) RequestBalanced(ctx context.Context, url string, opts RequestOpts) (*resty.Response, error) {
+	if len(c.clients) == 0 {
+		return nil, ErrNoClients
+	}
+	if !c.failover {
+		return c.requestClient(ctx, c.pick(nil), url, opts)
+	}
+
+	attempts := lang.Check(opts.RetryCount, len(c.clients))
+	clientOpts := opts
+	if opts.Idempotent || isIdempotent(opts.Method) {
+		clientOpts.RetryCount = 0
+		clientOpts.InfiniteRetry = false
+	} else {
+		attempts = len(c.clients)
+	}
+
+	retryOpts := opts
+	retryOpts.RetryWaitTime = lang.Check(opts.RetryWaitTime, defaultWaitTime)
+	retryOpts.RetryMaxWaitTime = lang.Check(opts.RetryMaxWaitTime, defaultMaxWaitTime)
+	policy := c.clients[0].retryPolicy(retryOpts)
+
 	var (
-		fs    = make([]*abstract.Future[*resty.Response], len(c.clients))
-		resps = make([]*resty.Response, 0, len(c.clients))
+		tried = make(map[int]bool, len(c.clients))
+		errs  []error
+		round int
+	)
+	for attempt := 0; attempt < attempts; attempt++ {
+		if len(tried) == len(c.clients) {
+			round++
+			clear(tried)
+			sleepTime, ok := policy.NextDelay(round, nil, errs[len(errs)-1])
+			if !ok {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return nil, errors.Join(append(errs, ctx.Err())...)
+			case <-time.After(sleepTime):
+			}
+		}
+
+		i := c.pick(tried)
+		tried[i] = true
+
+		resp, err := c.requestClient(ctx, i, url, clientOpts)
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, err)
+		if !isFailoverError(err) || !(opts.Idempotent || CanReplay(opts.Method, err)) {
+			break
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// requestClient makes a request using the client with index i and updates the list of broken clients.
+func (c *HTTPSet) requestClient(ctx context.Context, i int, url string, opts RequestOpts) (*resty.Response, error) {
+	resp, err := c.doRequestClient(ctx, i, url, opts)
+	if err != nil {
+		c.broken.Add(i)
+		return nil, fmt.Errorf("client %d: %w", i, err)
+	}
+	c.broken.Delete(i)
+	return resp, nil
+}
+
+func (c *HTTPSet) doRequestClient(ctx context.Context, i int, url string, opts RequestOpts) (_ *resty.Response, err error) {
+	if c.recover {
+		defer recoverPanic(c.log, &err)
+	}
+	return c.clients[i].Request(ctx, url, opts)
+}
+
+// pick returns the index of the next working client in round-robin order that is not in the exclude list.
+// It returns a broken client if there are no working ones.
+func (c *HTTPSet) pick(exclude map[int]bool) int {
+	n := len(c.clients)
+	start := int(c.next.Add(1)-1) % n
+	for k := range n {
+		if i := (start + k) % n; !exclude[i] && !c.broken.Has(i) {
+			return i
+		}
+	}
+	for k := range n {
+		if i := (start + k) % n; !exclude[i] {
+			return i
+		}
+	}
+	return start
+}
+
+// clone returns a copy of the options with copied maps of headers, query and path params.
+func (o RequestOpts) clone() RequestOpts {
+	o.Headers = maps.Clone(o.Headers)
+	o.HeaderValues = o.HeaderValues.Clone()
+	o.Query = maps.Clone(o.Query)
+	o.QueryValues = url.Values(http.Header(o.QueryValues).Clone())
+	o.PathParams = maps.Clone(o.PathParams)
+	o.FormData = maps.Clone(o.FormData)
+	o.Cookies = slices.Clone(o.Cookies)
+	return o
+}
+
+// isIdempotent returns true if the request with the method can be safely sent again.
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// isFailoverError returns true if the request may succeed on another client.
+func isFailoverError(err error) bool {
+	switch code := GetCodeFromError(err); {
+	case code == 0, code >= 500, code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return true
+	}
+	return false
+}
+
+// TypedResult is the result of a request of one client in the set.
+type TypedResult[T any] struct {
+	// Index is the index of the client in the set.
+	Index int
+	// Result is the response body decoded into T.
+	Result T
+	// Response is the response of the client.
+	Response *resty.Response
+}
+
+// SetRequest makes a request to the given URL using every client in the set and decodes each response into its own T.
+// It returns results of successful requests ordered by client index. RequestOpts.Result is ignored.
+func SetRequest[T any](ctx context.Context, set *HTTPSet, url string, opts RequestOpts) ([]TypedResult[T], error) {
+	results := make([]T, len(set.clients))
+	resps, err := set.fanOut(ctx, url, func(i int) RequestOpts {
+		clientOpts := opts
+		clientOpts.Result = &results[i]
+		return clientOpts
+	})
+	return lang.Convert(resps, func(r setResponse) TypedResult[T] {
+		return TypedResult[T]{Index: r.index, Result: results[r.index], Response: r.resp}
+	}), err
+}
+
+type setResponse struct {
+	index int
+	resp  *resty.Response
+}
+
+// fanOut sends request to every client in the set with options returned by getOpts for the client index.
+func (c *HTTPSet) fanOut(ctx context.Context, url string, getOpts func(i int) RequestOpts) ([]setResponse, error) {
+	var (
+		results = c.fanOutResults(ctx, url, getOpts)
+		resps   = make([]setResponse, 0, len(results))
 
 		errs []error
 	)
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("client %d: %w", r.Index, r.Err))
+		} else {
+			resps = append(resps, setResponse{index: r.Index, resp: r.Response})
+		}
+	}
+	return resps, errors.Join(errs...)
+}
+
+// fanOutResults sends request to every client in the set and returns results of all requests ordered by client index.
+func (c *HTTPSet) fanOutResults(ctx context.Context, url string, getOpts func(i int) RequestOpts) []SetResult {
+	var (
+		fs      = make([]*abstract.Future[*resty.Response], len(c.clients))
+		results = make([]SetResult, 0, len(c.clients))
+	)
 
 	for i, http := range c.clients {
 		if c.useBroken && !c.broken.Has(i) {
@@ -121,7 +365,9 @@ func (c *HTTPSet) Request(ctx context.Context, url string, opts RequestOpts) ([]
 		if !c.useBroken && c.broken.Has(i) {
 			continue // !useBroken: send only in working
 		}
-		fs[i] = abstract.NewFuture(ctx, c.log, func(ctx context.Context) (*resty.Response, error) {
+		opts := getOpts(i)
+		fs[i] = abstract.NewFuture(ctx, c.log, func(ctx context.Context) (_ *resty.Response, err error) {
+			defer recoverPanic(c.log, &err)
 			return http.Request(ctx, url, opts)
 		})
 	}
@@ -132,15 +378,14 @@ func (c *HTTPSet) Request(ctx context.Context, url string, opts RequestOpts) ([]
 		}
 		resp, err := f.Get(ctx)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("client %d: %w", i, err))
 			c.broken.Add(i)
 		} else {
 			c.broken.Delete(i)
-			resps = append(resps, resp)
 		}
+		results = append(results, SetResult{Index: i, Response: resp, Err: err})
 	}
 
-	return resps, errors.Join(errs...)
+	return results
 }
 
 // Req makes a request to the given URL with the given options and returns a list of responses.
@@ -154,14 +399,14 @@ func (c *HTTPSet) Req(ctx context.Context, method string, url string, requestAnd
 // Get makes a GET request to the given URL and returns a list of responses.
 func (c *HTTPSet) Get(ctx context.Context, url string, responseBody ...any) ([]*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Body: lang.First(responseBody)})
+		Result: lang.First(responseBody)})
 }
 
 // GetQ makes a GET request to the given URL with the given query and returns a list of responses.
 func (c *HTTPSet) GetQ(ctx context.Context, url string, responseBody any, queryPairs ...string) ([]*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Body:  responseBody,
-		Query: lang.PairsToMap(queryPairs)})
+		Result:      responseBody,
+		QueryValues: pairsToValues(queryPairs)})
 }
 
 // Post makes a POST request to the given URL with the given request body and returns a list of responses.
@@ -175,10 +420,10 @@ func (c *HTTPSet) Post(ctx context.Context, url string, requestBody any, respons
 // PostQ makes a POST request to the given URL with the given request body and query and returns a list of responses.
 func (c *HTTPSet) PostQ(ctx context.Context, url string, requestBody any, responseBody any, queryPairs ...string) ([]*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Method: http.MethodPost,
-		Body:   requestBody,
-		Result: responseBody,
-		Query:  lang.PairsToMap(queryPairs)})
+		Method:      http.MethodPost,
+		Body:        requestBody,
+		Result:      responseBody,
+		QueryValues: pairsToValues(queryPairs)})
 }
 
 // Put makes a PUT request to the given URL with the given request body and returns a list of responses.
@@ -192,10 +437,10 @@ func (c *HTTPSet) Put(ctx context.Context, url string, requestBody any, response
 // PutQ makes a PUT request to the given URL with the given request body and query and returns a list of responses.
 func (c *HTTPSet) PutQ(ctx context.Context, url string, requestBody any, responseBody any, queryPairs ...string) ([]*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Method: http.MethodPut,
-		Body:   requestBody,
-		Result: responseBody,
-		Query:  lang.PairsToMap(queryPairs)})
+		Method:      http.MethodPut,
+		Body:        requestBody,
+		Result:      responseBody,
+		QueryValues: pairsToValues(queryPairs)})
 }
 
 // Patch makes a PATCH request to the given URL with the given request body and returns a list of responses.
@@ -209,10 +454,10 @@ func (c *HTTPSet) Patch(ctx context.Context, url string, requestBody any, respon
 // PatchQ makes a PATCH request to the given URL with the given request body and query and returns a list of responses.
 func (c *HTTPSet) PatchQ(ctx context.Context, url string, requestBody any, responseBody any, queryPairs ...string) ([]*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Method: http.MethodPatch,
-		Body:   requestBody,
-		Result: responseBody,
-		Query:  lang.PairsToMap(queryPairs)})
+		Method:      http.MethodPatch,
+		Body:        requestBody,
+		Result:      responseBody,
+		QueryValues: pairsToValues(queryPairs)})
 }
 
 // Delete makes a DELETE request to the given URL with the given request body and returns a list of responses.
@@ -226,8 +471,35 @@ func (c *HTTPSet) Delete(ctx context.Context, url string, requestBody any, respo
 // DeleteQ makes a DELETE request to the given URL with the given request body and query and returns a list of responses.
 func (c *HTTPSet) DeleteQ(ctx context.Context, url string, requestBody any, responseBody any, queryPairs ...string) ([]*resty.Response, error) {
 	return c.Request(ctx, url, RequestOpts{
-		Method: http.MethodDelete,
-		Body:   requestBody,
-		Result: responseBody,
-		Query:  lang.PairsToMap(queryPairs)})
+		Method:      http.MethodDelete,
+		Body:        requestBody,
+		Result:      responseBody,
+		QueryValues: pairsToValues(queryPairs)})
+}
+
+// newResult returns a new value for the result of one request of the fan-out.
+func newResult(opts RequestOpts) any {
+	if opts.NewResult != nil {
+		return opts.NewResult()
+	}
+	if opts.Result == nil {
+		return nil
+	}
+	t := reflect.TypeOf(opts.Result)
+	if t.Kind() != reflect.Pointer {
+		return opts.Result
+	}
+	return reflect.New(t.Elem()).Interface()
+}
+
+// copyResult copies the value of the src pointer to the dst pointer if they have the same type.
+func copyResult(dst, src any) {
+	if dst == nil || src == nil || dst == src {
+		return
+	}
+	dstValue, srcValue := reflect.ValueOf(dst), reflect.ValueOf(src)
+	if dstValue.Kind() != reflect.Pointer || dstValue.IsNil() || srcValue.Type() != dstValue.Type() || srcValue.IsNil() {
+		return
+	}
+	dstValue.Elem().Set(srcValue.Elem())
 }
@@ -0,0 +1,274 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSetForTest(t *testing.T, ctx context.Context, names ...string) *cliex.HTTPSet {
+	t.Helper()
+
+	cfgs := make([]cliex.Config, 0, len(names))
+	for _, name := range names {
+		var requestCounter atomic.Int64
+		responseMap := cliex.ResponseMapForTest{
+			"/name": func(ctx context.Context, req *http.Request) (any, error) {
+				if name == "" {
+					return nil, cliex.ErrServiceUnavailable
+				}
+				return map[string]string{"name": name}, nil
+			},
+		}
+		cfgs = append(cfgs, cliex.GetConfigForTest(ctx, &requestCounter, responseMap))
+	}
+
+	set, err := cliex.NewSetFromConfigs(cfgs...)
+	require.NoError(t, err)
+
+	return set
+}
+
+func TestSetRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	set := newSetForTest(t, ctx, "first", "", "third")
+
+	type response struct {
+		Name string `json:"name"`
+	}
+
+	results, err := cliex.SetRequest[response](ctx, set, "/name", cliex.RequestOpts{})
+	assert.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, 0, results[0].Index)
+	assert.Equal(t, "first", results[0].Result.Name)
+	assert.Equal(t, http.StatusOK, results[0].Response.StatusCode())
+
+	assert.Equal(t, 2, results[1].Index)
+	assert.Equal(t, "third", results[1].Result.Name)
+
+	assert.Equal(t, []int{1}, set.GetBroken())
+}
+
+func TestHTTPSet_RequestResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	set := newSetForTest(t, ctx, "first", "second", "third")
+
+	type response struct {
+		Name string `json:"name"`
+	}
+
+	var result response
+	resps, err := set.Get(ctx, "/name", &result)
+	require.NoError(t, err)
+	require.Len(t, resps, 3)
+
+	assert.Equal(t, "first", result.Name)
+	for i, name := range []string{"first", "second", "third"} {
+		assert.Equal(t, name, resps[i].Result().(*response).Name)
+		assert.NotSame(t, &result, resps[i].Result())
+	}
+
+	var created atomic.Int64
+	resps, err = set.Request(ctx, "/name", cliex.RequestOpts{
+		NewResult: func() any {
+			created.Add(1)
+			return &map[string]string{}
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), created.Load())
+	assert.Equal(t, "second", (*resps[1].Result().(*map[string]string))["name"])
+}
+
+func TestHTTPSet_RequestEach(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization") + " " + r.Header.Get("X-Region") + " " + r.URL.Query().Get("q")))
+	}))
+	defer srv.Close()
+
+	set, err := cliex.NewSetFromConfigs(cliex.Config{BaseURL: srv.URL}, cliex.Config{BaseURL: srv.URL})
+	require.NoError(t, err)
+
+	opts := cliex.RequestOpts{
+		Headers: map[string]string{"X-Region": "eu"},
+		Query:   map[string]string{"q": "all"},
+	}
+	resps, err := set.RequestEach(context.Background(), "/", opts, func(i int, opts *cliex.RequestOpts) {
+		opts.AuthToken = []string{"token-a", "token-b"}[i]
+		if i == 1 {
+			opts.SetHeader("X-Region", "us")
+		}
+	})
+	require.NoError(t, err)
+	require.Len(t, resps, 2)
+	assert.Equal(t, "Bearer token-a eu all", resps[0].String())
+	assert.Equal(t, "Bearer token-b us all", resps[1].String())
+	assert.Equal(t, map[string]string{"X-Region": "eu"}, opts.Headers)
+}
+
+func TestHTTPSet_RequestBalanced(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	set := newSetForTest(t, ctx, "first", "", "third")
+
+	type response struct {
+		Name string `json:"name"`
+	}
+
+	// Round-robin without failover returns errors of broken clients
+	var names []string
+	var failed int
+	for range 3 {
+		var result response
+		_, err := set.RequestBalanced(ctx, "/name", cliex.RequestOpts{Result: &result})
+		if err != nil {
+			assert.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+			failed++
+			continue
+		}
+		names = append(names, result.Name)
+	}
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, []string{"first", "third"}, names)
+	assert.Equal(t, []int{1}, set.GetBroken())
+
+	// Broken clients are skipped
+	for range 4 {
+		_, err := set.RequestBalanced(ctx, "/name", cliex.RequestOpts{})
+		assert.NoError(t, err)
+	}
+
+	set.DeleteBroken(1)
+	set.WithFailover(true)
+
+	for range 6 {
+		var result response
+		_, err := set.RequestBalanced(ctx, "/name", cliex.RequestOpts{Result: &result})
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Name)
+	}
+
+	// Non-idempotent requests are not retried on another client
+	set.DeleteBroken(1)
+	failed = 0
+	for range 3 {
+		_, err := set.RequestBalanced(ctx, "/name", cliex.RequestOpts{Method: http.MethodPost})
+		if err != nil {
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed)
+
+	set.DeleteBroken(1)
+	for range 3 {
+		_, err := set.RequestBalanced(ctx, "/name", cliex.RequestOpts{Method: http.MethodPost, Idempotent: true})
+		assert.NoError(t, err)
+	}
+
+	_, err := cliex.NewSet().RequestBalanced(ctx, "/name", cliex.RequestOpts{})
+	assert.ErrorIs(t, err, cliex.ErrNoClients)
+}
+
+func TestHTTPSet_FailoverAllBroken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	set := newSetForTest(t, ctx, "", "").WithFailover(true)
+
+	_, err := set.RequestBalanced(ctx, "/name", cliex.RequestOpts{
+		RetryCount:       5,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	require.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	assert.Equal(t, 5, strings.Count(err.Error(), "client "))
+	assert.ElementsMatch(t, []int{0, 1}, set.GetBroken())
+
+	// Rounds are delayed by the retry policy of the request
+	var rounds []int
+	_, err = set.RequestBalanced(ctx, "/name", cliex.RequestOpts{
+		RetryCount:      5,
+		NoLogRetryError: true,
+		RetryPolicy: cliex.RetryPolicyFunc(func(round int, _ *resty.Response, err error) (time.Duration, bool) {
+			assert.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+			rounds = append(rounds, round)
+			return time.Millisecond, round < 2
+		}),
+	})
+	require.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	assert.Equal(t, 4, strings.Count(err.Error(), "client "))
+	assert.Equal(t, []int{1, 2}, rounds)
+}
+
+func TestHTTPSet_RequestMerged(t *testing.T) {
+	shard := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}))
+	}
+	ctx := context.Background()
+
+	s1, s2 := shard(`[{"id":1},{"id":2}]`), shard(`[{"id":3}]`)
+	defer s1.Close()
+	defer s2.Close()
+	set := cliex.NewSet(cliex.MustNew(cliex.WithBaseURL(s1.URL)), cliex.MustNew(cliex.WithBaseURL(s2.URL)))
+
+	var items []struct {
+		ID int `json:"id"`
+	}
+	resps, err := set.RequestMerged(ctx, "/items", cliex.RequestOpts{Result: &items}, cliex.MergeOpts{})
+	require.NoError(t, err)
+	assert.Len(t, resps, 2)
+	require.Len(t, items, 3)
+	assert.Equal(t, 3, items[2].ID)
+
+	m1, m2 := shard(`{"total":2,"tags":["a"],"stats":{"eu":2}}`), shard(`{"total":1,"tags":["b"],"stats":{"us":1}}`)
+	defer m1.Close()
+	defer m2.Close()
+	set = cliex.NewSet(cliex.MustNew(cliex.WithBaseURL(m1.URL)), cliex.MustNew(cliex.WithBaseURL(m2.URL)))
+
+	var merged map[string]any
+	_, err = set.RequestMerged(ctx, "/", cliex.RequestOpts{Result: &merged}, cliex.MergeOpts{Strategy: cliex.MergeMaps})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"total": float64(1),
+		"tags":  []any{"a", "b"},
+		"stats": map[string]any{"eu": float64(2), "us": float64(1)},
+	}, merged)
+
+	_, err = set.RequestMerged(ctx, "/", cliex.RequestOpts{}, cliex.MergeOpts{Strategy: cliex.MergeConcat})
+	require.ErrorIs(t, err, cliex.ErrMerge)
+
+	l1, l2 := shard(`{"v":"old","meta":{"updated_at":"2024-01-01T00:00:00Z"}}`), shard(`{"v":"new","meta":{"updated_at":"2024-06-01T00:00:00Z"}}`)
+	defer l1.Close()
+	defer l2.Close()
+	set = cliex.NewSet(cliex.MustNew(cliex.WithBaseURL(l1.URL)), cliex.MustNew(cliex.WithBaseURL(l2.URL)))
+
+	var latest struct {
+		V string `json:"v"`
+	}
+	_, err = set.RequestMerged(ctx, "/", cliex.RequestOpts{Result: &latest}, cliex.MergeOpts{
+		Strategy:      cliex.MergeLatest,
+		TimestampPath: "$.meta.updated_at",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "new", latest.V)
+}
@@ -0,0 +1,159 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/maxbolgarin/lang"
+)
+
+// MergeStrategy is the strategy of merging JSON results of clients in HTTPSet.RequestMerged.
+type MergeStrategy string
+
+const (
+	// MergeConcat concatenates JSON arrays returned by clients in order of client indexes.
+	MergeConcat MergeStrategy = "concat"
+	// MergeMaps deep merges JSON objects returned by clients in order of client indexes:
+	// values of later clients override scalar values, nested objects are merged and nested arrays are concatenated.
+	MergeMaps MergeStrategy = "maps"
+	// MergeLatest picks the JSON result with the latest timestamp in MergeOpts.TimestampPath.
+	MergeLatest MergeStrategy = "latest"
+)
+
+// ErrMerge is returned when results of clients cannot be merged with the strategy.
+var ErrMerge = errors.New("cannot merge results")
+
+// MergeOpts is the options of merging JSON results of clients.
+type MergeOpts struct {
+	// Strategy is the strategy of merging. Default is MergeConcat.
+	Strategy MergeStrategy
+
+	// TimestampPath is the JSON path of the timestamp field for MergeLatest, e.g. "$.updated_at".
+	// Timestamp is a RFC 3339 string or a number of unix seconds.
+	TimestampPath string
+}
+
+// RequestMerged makes a request to the given URL using every client in the set (scatter-gather across sharded
+// backends) and merges JSON results of successful requests into opts.Result with the strategy.
+// It returns responses of successful requests and errors of failed ones like Request.
+func (c *HTTPSet) RequestMerged(ctx context.Context, url string, opts RequestOpts, merge MergeOpts) ([]*resty.Response, error) {
+	resps, err := c.fanOut(ctx, url, func(int) RequestOpts {
+		clientOpts := opts
+		clientOpts.Result = nil
+		return clientOpts
+	})
+	out := lang.Convert(resps, func(r setResponse) *resty.Response { return r.resp })
+	if len(resps) == 0 {
+		return out, err
+	}
+
+	results := make([]any, 0, len(resps))
+	for _, r := range resps {
+		var v any
+		if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(r.resp.Body(), &v); err != nil {
+			return out, fmt.Errorf("client %d: decode result: %w", r.index, err)
+		}
+		results = append(results, v)
+	}
+
+	merged, mergeErr := mergeResults(results, merge)
+	if mergeErr != nil {
+		return out, mergeErr
+	}
+	if opts.Result != nil {
+		data, mergeErr := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(merged)
+		if mergeErr == nil {
+			mergeErr = jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, opts.Result)
+		}
+		if mergeErr != nil {
+			return out, fmt.Errorf("decode merged result: %w", mergeErr)
+		}
+	}
+	return out, err
+}
+
+func mergeResults(results []any, opts MergeOpts) (any, error) {
+	switch lang.Check(opts.Strategy, MergeConcat) {
+	case MergeConcat:
+		out := make([]any, 0)
+		for i, r := range results {
+			items, ok := r.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: result %d is not an array", ErrMerge, i)
+			}
+			out = append(out, items...)
+		}
+		return out, nil
+
+	case MergeMaps:
+		out := make(map[string]any)
+		for i, r := range results {
+			m, ok := r.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: result %d is not an object", ErrMerge, i)
+			}
+			mergeJSONMaps(out, m)
+		}
+		return out, nil
+
+	case MergeLatest:
+		var (
+			latest   any
+			latestTS time.Time
+		)
+		for i, r := range results {
+			v, ok := lookupJSONPath(r, opts.TimestampPath)
+			if !ok {
+				return nil, fmt.Errorf("%w: result %d has no %s", ErrMerge, i, opts.TimestampPath)
+			}
+			ts, err := parseMergeTimestamp(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: result %d: %w", ErrMerge, i, err)
+			}
+			if latest == nil || ts.After(latestTS) {
+				latest, latestTS = r, ts
+			}
+		}
+		return latest, nil
+	}
+	return nil, fmt.Errorf("%w: unknown strategy %s", ErrMerge, opts.Strategy)
+}
+
+func mergeJSONMaps(dst, src map[string]any) {
+	for k, v := range src {
+		switch sv := v.(type) {
+		case map[string]any:
+			if dv, ok := dst[k].(map[string]any); ok {
+				mergeJSONMaps(dv, sv)
+				continue
+			}
+		case []any:
+			if dv, ok := dst[k].([]any); ok {
+				dst[k] = append(dv, sv...)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+func parseMergeTimestamp(v any) (time.Time, error) {
+	switch ts := v.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return t, nil
+		}
+		if n, err := strconv.ParseFloat(ts, 64); err == nil {
+			return time.Unix(0, int64(n*float64(time.Second))), nil
+		}
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", ts)
+	case float64:
+		return time.Unix(0, int64(ts*float64(time.Second))), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %v", v)
+}
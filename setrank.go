@@ -0,0 +1,122 @@
+package cliex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ErrNoBestResponse is returned by HTTPSet.RequestBest when the ranking function selects no response.
+var ErrNoBestResponse = errors.New("no best response")
+
+// SetResult is the result of a request of one client in the set, see HTTPSet.RequestBest.
+type SetResult struct {
+	// Index is the index of the client in the set.
+	Index int
+	// Response is the response of the client, it may be set for failed requests with unsuccessful status.
+	Response *resty.Response
+	// Err is the error of the request.
+	Err error
+}
+
+// RankFunc returns the index of the best result in results, -1 means there is no suitable result.
+type RankFunc func(results []SetResult) int
+
+// RequestBest makes a request to the given URL using every client in the set and returns the response
+// selected by rank from results of all clients, e.g. the one with the highest block number from several
+// RPC nodes. Results of all clients (including failed ones) are returned ordered by client index.
+// Every request decodes the response into its own value like Request, opts.Result receives the result
+// of the best response. Default rank is FirstSuccess.
+func (c *HTTPSet) RequestBest(ctx context.Context, url string, opts RequestOpts, rank RankFunc) (*resty.Response, []SetResult, error) {
+	if len(c.clients) == 0 {
+		return nil, nil, ErrNoClients
+	}
+	if rank == nil {
+		rank = FirstSuccess
+	}
+	results := c.fanOutResults(ctx, url, func(int) RequestOpts {
+		clientOpts := opts
+		clientOpts.Result = newResult(opts)
+		return clientOpts
+	})
+
+	best := rank(results)
+	if best < 0 || best >= len(results) || results[best].Response == nil {
+		errs := make([]error, 0, len(results)+1)
+		errs = append(errs, ErrNoBestResponse)
+		for _, r := range results {
+			if r.Err != nil {
+				errs = append(errs, fmt.Errorf("client %d: %w", r.Index, r.Err))
+			}
+		}
+		return nil, results, errors.Join(errs...)
+	}
+
+	resp := results[best].Response
+	copyResult(opts.Result, resp.Result())
+	return resp, results, results[best].Err
+}
+
+// FirstSuccess is the RankFunc that selects the successful response of the client with the lowest index.
+func FirstSuccess(results []SetResult) int {
+	for i, r := range results {
+		if r.Err == nil && r.Response != nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// RankByMaxJSON returns the RankFunc that selects the successful response with the highest number
+// in the JSON path of the body, e.g. "$.result.number" for the latest block. Numbers in strings
+// are supported including hex numbers with 0x prefix. Responses without the number are skipped.
+func RankByMaxJSON(path string) RankFunc {
+	return func(results []SetResult) int {
+		best, bestValue := -1, 0.0
+		for i, r := range results {
+			if r.Err != nil || r.Response == nil {
+				continue
+			}
+			value, ok := jsonNumber(r.Response.Body(), path)
+			if ok && (best == -1 || value > bestValue) {
+				best, bestValue = i, value
+			}
+		}
+		return best
+	}
+}
+
+// jsonNumber returns the number in the JSON path of the body.
+func jsonNumber(body []byte, path string) (float64, bool) {
+	var v any
+	decoder := jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return 0, false
+	}
+	value, ok := lookupJSONPath(v, path)
+	if !ok {
+		return 0, false
+	}
+	var s string
+	switch value := value.(type) {
+	case json.Number:
+		s = value.String()
+	case string:
+		s = strings.TrimSpace(value)
+	default:
+		return 0, false
+	}
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return float64(n), true
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil
+}
@@ -0,0 +1,63 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPSet_RequestBest(t *testing.T) {
+	newNode := func(body string, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+	}
+	nodes := []*httptest.Server{
+		newNode(`{"result":{"number":"0x10"}}`, http.StatusOK),
+		newNode(`{"result":{"number":"0x1a"}}`, http.StatusOK),
+		newNode(`{"result":{"number":"0xff"}}`, http.StatusServiceUnavailable),
+		newNode(`{"result":{}}`, http.StatusOK),
+	}
+	cfgs := make([]cliex.Config, 0, len(nodes))
+	for _, node := range nodes {
+		defer node.Close()
+		cfgs = append(cfgs, cliex.Config{BaseURL: node.URL})
+	}
+	set, err := cliex.NewSetFromConfigs(cfgs...)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	type block struct {
+		Result struct {
+			Number string `json:"number"`
+		} `json:"result"`
+	}
+
+	var best block
+	resp, results, err := set.RequestBest(ctx, "/", cliex.RequestOpts{Result: &best}, cliex.RankByMaxJSON("$.result.number"))
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, "0x1a", best.Result.Number)
+	assert.Same(t, results[1].Response, resp)
+	assert.ErrorIs(t, results[2].Err, cliex.ErrServiceUnavailable)
+	assert.Equal(t, 3, results[3].Index)
+
+	set.DeleteBroken(2)
+	resp, _, err = set.RequestBest(ctx, "/", cliex.RequestOpts{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, nodes[0].URL+"/", resp.Request.URL)
+
+	set.DeleteBroken(2)
+
+	_, results, err = set.RequestBest(ctx, "/", cliex.RequestOpts{}, func([]cliex.SetResult) int { return -1 })
+	require.ErrorIs(t, err, cliex.ErrNoBestResponse)
+	require.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	assert.Len(t, results, 4)
+}
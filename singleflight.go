@@ -0,0 +1,142 @@
+package cliex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/lang"
+)
+
+// flightGroup coalesces identical in-flight GET requests into one upstream request and shares its response.
+type flightGroup struct {
+	enabled bool
+
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	resp *resty.Response
+	err  error
+}
+
+func newFlightGroup(enabled bool) *flightGroup {
+	return &flightGroup{enabled: enabled, calls: make(map[string]*flightCall)}
+}
+
+// key returns the key of the request by the URL, query, headers, cookies and credentials including
+// the values of Config.ContextHeaders from the context. It returns false if the request cannot be coalesced.
+func (g *flightGroup) key(ctx context.Context, url string, opts RequestOpts, contextHeaders []ContextHeader) (string, bool) {
+	if (!g.enabled && !opts.Dedupe) || (opts.Method != "" && opts.Method != http.MethodGet) || opts.Body != nil ||
+		opts.OutputPath != "" || opts.StreamResponse || opts.BodyReader != nil || opts.GetBody != nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	b.WriteString(requestURLKey(url, opts))
+	for _, k := range sortedKeys(opts.Headers) {
+		fmt.Fprintf(&b, "\n%s: %s", strings.ToLower(k), opts.Headers[k])
+	}
+	for _, k := range sortedKeys(opts.HeaderValues) {
+		fmt.Fprintf(&b, "\n%s: %s", strings.ToLower(k), strings.Join(opts.HeaderValues[k], ", "))
+	}
+	for _, cookie := range opts.Cookies {
+		fmt.Fprintf(&b, "\ncookie: %s=%s", cookie.Name, cookie.Value)
+	}
+	// The shared request is sent with the context of the first caller, so callers with different context values
+	// must not share it
+	for _, h := range contextHeaders {
+		fmt.Fprintf(&b, "\ncontext %s: %s", strings.ToLower(h.Header), h.resolve(ctx))
+	}
+	fmt.Fprintf(&b, "\n%s\n%s:%s\n%s\n%s\n%s", opts.AuthToken, opts.BasicAuthUser, opts.BasicAuthPass,
+		strings.Join(opts.Accept, ","), opts.APIVersion, opts.ClientCertName)
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// do calls the function once for concurrent calls with the same key and returns its result to every caller.
+// The function is called with the context that is canceled only when all callers are gone,
+// it keeps values of the context of the first caller.
+func (g *flightGroup) do(ctx context.Context, key string, f func(ctx context.Context) (*resty.Response, error)) (*resty.Response, error) {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &flightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+
+		go func() {
+			defer cancel()
+			resp, err := f(callCtx)
+
+			g.mu.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+
+			call.resp, call.err = resp, err
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.resp, call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// Nobody waits for the response, so the request is canceled and the next caller starts a new one
+			call.cancel()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// sharedResponse returns the copy of the shared response with the result of the caller.
+func sharedResponse(results resultDecoder, resp *resty.Response, opts RequestOpts) (*resty.Response, error) {
+	if resp == nil || resp.Request == nil {
+		return resp, nil
+	}
+	out := copyResponse(resp, opts.Result)
+	if opts.Result != nil && reflect.TypeOf(opts.Result) == reflect.TypeOf(resp.Result()) {
+		copyResult(opts.Result, resp.Result())
+		opts.Result = nil
+	}
+	return results.useMemoized(out, opts)
+}
+
+// requestShared sends the request once for identical concurrent GET requests, see RequestOpts.Dedupe.
+func (c *HTTP) requestShared(ctx context.Context, url string, opts RequestOpts) (*resty.Response, error) {
+	key, ok := c.flights.key(ctx, lang.If(strings.HasPrefix(url, "http"), url, c.cli.BaseURL+url), opts, c.ctxHeaders)
+	if !ok {
+		return c.requestWithBreaker(ctx, url, opts)
+	}
+	sharedOpts := opts
+	sharedOpts.Result, sharedOpts.TeeWriter = newResult(opts), nil
+	resp, err := c.flights.do(ctx, key, func(ctx context.Context) (*resty.Response, error) {
+		return c.requestWithBreaker(ctx, url, sharedOpts)
+	})
+	if err != nil {
+		return resp, err
+	}
+	return sharedResponse(c.results, resp, opts)
+}
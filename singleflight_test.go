@@ -0,0 +1,124 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Singleflight(t *testing.T) {
+	var hits atomic.Int64
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"` + r.Header.Get("X-Tenant") + `"}`))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithSingleflight(true))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	type response struct {
+		Name string `json:"name"`
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]response, 10)
+	)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Request(ctx, "/config", cliex.RequestOpts{
+				Result:  &results[i],
+				Headers: map[string]string{"X-Tenant": []string{"a", "b"}[i%2]},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, &results[i], resp.Result())
+		}()
+	}
+
+	// A canceled caller leaves, the request is still sent for others
+	canceledCtx, cancel := context.WithCancel(ctx)
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := client.Request(canceledCtx, "/config", cliex.RequestOpts{Headers: map[string]string{"X-Tenant": "a"}})
+		canceled <- err
+	}()
+
+	require.Eventually(t, func() bool { return hits.Load() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-canceled, context.Canceled)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 2, hits.Load())
+	for i, result := range results {
+		assert.Equal(t, []string{"a", "b"}[i%2], result.Name)
+	}
+
+	// Requests are not coalesced if they are not concurrent
+	_, err = client.Get(ctx, "/config")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, hits.Load())
+}
+
+func TestHTTP_SingleflightContextValues(t *testing.T) {
+	type subjectKey struct{}
+
+	var hits atomic.Int64
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		subject := r.Header.Get("X-Subject")
+		if cookie, err := r.Cookie("session"); err == nil {
+			subject += ";" + cookie.Value
+		}
+		w.Write([]byte(subject))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithSingleflight(true),
+		cliex.WithContextHeader("X-Subject", subjectKey{}))
+	require.NoError(t, err)
+
+	var (
+		wg      sync.WaitGroup
+		callers = []struct {
+			subject string
+			cookie  string
+		}{{"alice", "a"}, {"bob", "a"}, {"alice", "b"}}
+		results = make([]string, len(callers))
+	)
+	for i, caller := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := context.WithValue(context.Background(), subjectKey{}, caller.subject)
+			resp, err := client.Request(ctx, "/me", cliex.RequestOpts{
+				Cookies: []*http.Cookie{{Name: "session", Value: caller.cookie}},
+			})
+			assert.NoError(t, err)
+			results[i] = resp.String()
+		}()
+	}
+	require.Eventually(t, func() bool { return hits.Load() == 3 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, []string{"alice;a", "bob;a", "alice;b"}, results)
+}
@@ -0,0 +1,161 @@
+package cliex
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// SLOAllRoutes is the key of Config.SLOs that applies the SLO to every route without its own SLO.
+	SLOAllRoutes = "*"
+
+	defaultSLOWindow        = time.Minute
+	defaultSLOMinRequests   = 10
+	defaultSLOLatencyTarget = 0.95
+)
+
+// SLO is the latency and error rate objective of the requests to a route.
+type SLO struct {
+	// Latency is the maximum duration of a request including retries, zero means no latency objective.
+	Latency time.Duration `yaml:"latency" json:"latency"`
+
+	// LatencyTarget is the fraction of requests that must be faster than Latency. Default is 0.95.
+	LatencyTarget float64 `yaml:"latency_target" json:"latency_target"`
+
+	// ErrorRate is the maximum fraction of failed requests, zero means no error rate objective.
+	ErrorRate float64 `yaml:"error_rate" json:"error_rate"`
+
+	// Window is the duration of the window in which the objectives are evaluated. Default is 1 minute.
+	Window time.Duration `yaml:"window" json:"window"`
+
+	// MinRequests is the minimum number of requests in the window to evaluate the objectives. Default is 10.
+	MinRequests int `yaml:"min_requests" json:"min_requests"`
+}
+
+// SLOViolation describes the window in which the route violated its SLO.
+type SLOViolation struct {
+	// Route is the route of requests, see RequestOpts.Route.
+	Route string
+	// SLO is the violated objective.
+	SLO SLO
+	// Start is the start of the window.
+	Start time.Time
+	// End is the end of the window.
+	End time.Time
+	// Requests is the number of requests in the window.
+	Requests int
+	// SlowRate is the fraction of requests slower than SLO.Latency.
+	SlowRate float64
+	// ErrorRate is the fraction of failed requests.
+	ErrorRate float64
+	// LatencyViolated is true if the fraction of fast requests is less than SLO.LatencyTarget.
+	LatencyViolated bool
+	// ErrorRateViolated is true if the error rate is greater than SLO.ErrorRate.
+	ErrorRateViolated bool
+}
+
+// sloTracker counts requests per route in tumbling windows and reports violations when a window ends.
+// A window starts with the first request to the route and is evaluated by a timer at its end,
+// so the violation is reported even if there are no more requests to the route.
+type sloTracker struct {
+	slos     map[string]SLO
+	onBreach func(SLOViolation)
+
+	mu      sync.Mutex
+	windows map[string]*sloWindow
+}
+
+type sloWindow struct {
+	slo      SLO
+	start    time.Time
+	requests int
+	slow     int
+	errors   int
+}
+
+func newSLOTracker(slos map[string]SLO, onBreach func(SLOViolation)) *sloTracker {
+	if len(slos) == 0 {
+		return nil
+	}
+	prepared := make(map[string]SLO, len(slos))
+	for route, slo := range slos {
+		if slo.Window <= 0 {
+			slo.Window = defaultSLOWindow
+		}
+		if slo.MinRequests <= 0 {
+			slo.MinRequests = defaultSLOMinRequests
+		}
+		if slo.LatencyTarget <= 0 || slo.LatencyTarget > 1 {
+			slo.LatencyTarget = defaultSLOLatencyTarget
+		}
+		prepared[route] = slo
+	}
+	return &sloTracker{
+		slos:     prepared,
+		onBreach: onBreach,
+		windows:  make(map[string]*sloWindow),
+	}
+}
+
+// record adds the request to the window of the route, the window is started if there is no current one.
+func (t *sloTracker) record(route string, duration time.Duration, err error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.windows[route]
+	if !ok {
+		slo, ok := t.slos[route]
+		if !ok {
+			slo, ok = t.slos[SLOAllRoutes]
+		}
+		if !ok {
+			return
+		}
+		w = &sloWindow{slo: slo, start: time.Now()}
+		t.windows[route] = w
+		time.AfterFunc(slo.Window, func() { t.end(route, w) })
+	}
+
+	w.requests++
+	if err != nil {
+		w.errors++
+	}
+	if w.slo.Latency > 0 && duration > w.slo.Latency {
+		w.slow++
+	}
+}
+
+// end evaluates the ended window of the route and calls the callback in case of violation.
+func (t *sloTracker) end(route string, w *sloWindow) {
+	t.mu.Lock()
+	delete(t.windows, route)
+	violation, violated := w.evaluate(route, w.start.Add(w.slo.Window))
+	t.mu.Unlock()
+
+	if violated {
+		t.onBreach(violation)
+	}
+}
+
+func (w *sloWindow) evaluate(route string, end time.Time) (SLOViolation, bool) {
+	if w.requests < w.slo.MinRequests {
+		return SLOViolation{}, false
+	}
+	v := SLOViolation{
+		Route:     route,
+		SLO:       w.slo,
+		Start:     w.start,
+		End:       end,
+		Requests:  w.requests,
+		SlowRate:  float64(w.slow) / float64(w.requests),
+		ErrorRate: float64(w.errors) / float64(w.requests),
+	}
+	v.LatencyViolated = w.slo.Latency > 0 && 1-v.SlowRate < w.slo.LatencyTarget
+	v.ErrorRateViolated = w.slo.ErrorRate > 0 && v.ErrorRate > w.slo.ErrorRate
+
+	return v, v.LatencyViolated || v.ErrorRateViolated
+}
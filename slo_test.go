@@ -0,0 +1,76 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_SLO(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(20 * time.Millisecond)
+		case "/fail":
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+	}))
+	defer mockServer.Close()
+
+	var (
+		mu         sync.Mutex
+		violations []cliex.SLOViolation
+	)
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL: mockServer.URL,
+		SLOs: map[string]cliex.SLO{
+			"/slow":            {Latency: 10 * time.Millisecond, Window: 50 * time.Millisecond, MinRequests: 2},
+			cliex.SLOAllRoutes: {ErrorRate: 0.5, Window: 50 * time.Millisecond, MinRequests: 2},
+		},
+		OnSLOViolation: func(v cliex.SLOViolation) {
+			mu.Lock()
+			violations = append(violations, v)
+			mu.Unlock()
+		},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for range 3 {
+		client.Get(ctx, "/slow")
+		client.Get(ctx, "/fail")
+		client.Get(ctx, "/ok")
+	}
+
+	// Violations are reported when windows end without waiting for the next requests
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(violations) == 2
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, violations, 2)
+
+	byRoute := map[string]cliex.SLOViolation{}
+	for _, v := range violations {
+		byRoute[v.Route] = v
+	}
+	assert.True(t, byRoute["/slow"].LatencyViolated)
+	assert.Equal(t, 3, byRoute["/slow"].Requests)
+	assert.InDelta(t, 1.0, byRoute["/slow"].SlowRate, 0.01)
+	assert.Equal(t, 50*time.Millisecond, byRoute["/slow"].End.Sub(byRoute["/slow"].Start))
+
+	assert.True(t, byRoute["/fail"].ErrorRateViolated)
+	assert.False(t, byRoute["/fail"].LatencyViolated)
+	assert.InDelta(t, 1.0, byRoute["/fail"].ErrorRate, 0.01)
+}
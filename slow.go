@@ -0,0 +1,18 @@
+package cliex
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// reportSlowRequest logs the attempt of the request that exceeded Config.SlowRequestThreshold
+// with its trace timings and reports it to the metrics hook.
+func (c *HTTP) reportSlowRequest(ctx context.Context, info RequestInfo, req *resty.Request, code int, duration time.Duration) {
+	trace := req.TraceInfo()
+	c.log.Warn("slow request", "method", info.Method, "route", info.Route, "host", info.Host, "code", code,
+		"duration", duration, "threshold", c.slowRequest, "dns", trace.DNSLookup, "connect", trace.TCPConnTime,
+		"tls", trace.TLSHandshake, "server", trace.ServerTime, "conn_reused", trace.IsConnReused)
+	c.metrics.OnSlowRequest(ctx, info, duration)
+}
@@ -0,0 +1,193 @@
+package cliex
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSSERetry  = 3 * time.Second
+	maxSSELineSize   = 16 << 20
+	eventStreamMedia = "text/event-stream"
+)
+
+// ErrNotEventStream is returned by SSE when the response is not text/event-stream.
+var ErrNotEventStream = errors.New("response is not an event stream")
+
+// Event is the event of Server-Sent Events stream.
+type Event struct {
+	// ID is the id field of the event or the last id of the stream if the event has no id.
+	ID string
+	// Type is the event field of the event. Default is "message".
+	Type string
+	// Data is the data of the event, lines of several data fields are joined with "\n".
+	Data string
+	// Retry is the reconnection time from the retry field of the event, it is zero if the event has no retry field.
+	Retry time.Duration
+}
+
+// SSE subscribes to the Server-Sent Events stream on the BaseURL + URL and calls the handler for every event.
+// It blocks until the context is done, the handler returns an error or the server responds with 204 No Content.
+// The stream is reopened when the connection is closed: after the time from the retry field of events
+// (default is RequestOpts.RetryWaitTime or 3 seconds) with Last-Event-ID header of the last received event.
+// Opening requests are retried with RetryCount, RetryWaitTime and other retry options of the request,
+// SSE returns the error if the stream cannot be opened. Config.RequestTimeout limits the duration
+// of a connection, so the stream is reopened after it.
+func (c *HTTP) SSE(ctx context.Context, url string, opts RequestOpts, handler func(Event) error) error {
+	opts.StreamResponse = true
+	opts.Headers = maps.Clone(opts.Headers)
+	if opts.Headers == nil {
+		opts.Headers = make(map[string]string, 3)
+	}
+	if !opts.hasHeader("Accept") {
+		opts.Headers["Accept"] = eventStreamMedia
+	}
+	if !opts.hasHeader("Cache-Control") {
+		opts.Headers["Cache-Control"] = "no-cache"
+	}
+
+	stream := &sseStream{retry: cmp.Or(opts.RetryWaitTime, defaultSSERetry)}
+	for {
+		if stream.lastID != "" {
+			opts.Headers["Last-Event-ID"] = stream.lastID
+		}
+		resp, err := c.Do(ctx, url, opts)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode() == http.StatusNoContent {
+			resp.Raw().RawBody().Close()
+			return nil
+		}
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header().Get("Content-Type")); mediaType != eventStreamMedia {
+			resp.Raw().RawBody().Close()
+			return fmt.Errorf("%w: content type %s", ErrNotEventStream, resp.Header().Get("Content-Type"))
+		}
+
+		body, err := resp.Reader()
+		if err != nil {
+			return err
+		}
+		err = stream.read(body, handler)
+		body.Close()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var handlerErr *sseHandlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+
+		c.log.Debug("event stream is closed, reconnect", append(identityAttrs(opts.RequestName, opts.RequestLabels),
+			"url", url, "last_event_id", stream.lastID, "retry", stream.retry, "error", err)...)
+		timer := time.NewTimer(stream.retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// sseHandlerError marks the error of the handler to stop the subscription instead of reconnecting.
+type sseHandlerError struct {
+	err error
+}
+
+func (e *sseHandlerError) Error() string {
+	return e.err.Error()
+}
+
+// sseStream is the state of the event stream that is kept between connections.
+type sseStream struct {
+	lastID string
+	retry  time.Duration
+}
+
+// read parses events of the stream according to the HTML Living Standard and calls the handler for every event.
+func (s *sseStream) read(body io.Reader, handler func(Event) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxSSELineSize)
+	scanner.Split(scanSSELines)
+
+	var (
+		event   Event
+		data    strings.Builder
+		hasData bool
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if hasData {
+				event.ID = s.lastID
+				event.Type = cmp.Or(event.Type, "message")
+				event.Data = data.String()
+				if err := handler(event); err != nil {
+					return &sseHandlerError{err: err}
+				}
+			}
+			event, hasData = Event{}, false
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Type = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+				event.Retry = time.Duration(ms) * time.Millisecond
+				s.retry = event.Retry
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// scanSSELines is the bufio.SplitFunc for lines ending with "\r\n", "\n" or "\r".
+func scanSSELines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' {
+			if i+1 == len(data) && !atEOF {
+				return 0, nil, nil
+			}
+			if i+1 < len(data) && data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
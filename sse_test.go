@@ -0,0 +1,84 @@
+package cliex_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_SSE(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		lastEventID []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/events":
+			mu.Lock()
+			lastEventID = append(lastEventID, r.Header.Get("Last-Event-ID"))
+			connection := len(lastEventID)
+			mu.Unlock()
+
+			assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+			w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+			if connection == 1 {
+				w.Write([]byte(": comment\nretry: 10\n\nid: 1\ndata: first\r\n\r\nevent: update\ndata: line 1\ndata:line 2\rid: 2\n\n"))
+				w.(http.Flusher).Flush()
+				w.Write([]byte("data: incomplete"))
+				return
+			}
+			w.Write([]byte("data: after reconnect\n\nevent: done\ndata\n\n"))
+		case "/text":
+			w.Write([]byte("data: not sse\n\n"))
+		case "/gone":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	errDone := errors.New("done")
+	var events []cliex.Event
+	err = client.SSE(ctx, "/events", cliex.RequestOpts{}, func(event cliex.Event) error {
+		events = append(events, event)
+		if event.Type == "done" {
+			return errDone
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errDone)
+	assert.Equal(t, []cliex.Event{
+		{ID: "1", Type: "message", Data: "first"},
+		{ID: "2", Type: "update", Data: "line 1\nline 2"},
+		{ID: "2", Type: "message", Data: "after reconnect"},
+		{ID: "2", Type: "done", Data: ""},
+	}, events)
+	assert.Equal(t, []string{"", "2"}, lastEventID)
+
+	err = client.SSE(ctx, "/text", cliex.RequestOpts{}, func(cliex.Event) error { return nil })
+	require.ErrorIs(t, err, cliex.ErrNotEventStream)
+
+	err = client.SSE(ctx, "/gone", cliex.RequestOpts{}, func(cliex.Event) error { return nil })
+	require.NoError(t, err)
+
+	err = client.SSE(ctx, "/missing", cliex.RequestOpts{}, func(cliex.Event) error { return nil })
+	require.ErrorIs(t, err, cliex.ErrNotFound)
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	err = client.SSE(ctx, "/events", cliex.RequestOpts{}, func(cliex.Event) error { return nil })
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,126 @@
+package cliex
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// requestState is the state of a single attempt of the request that is collected
+// by hooks and redirect policies via the request context.
+type requestState struct {
+	mu        sync.Mutex
+	redirects []Redirect
+	savedFile string
+	streamed  bool
+
+	// stopRedirects is set before sending the request to return 3xx responses instead of following them
+	stopRedirects bool
+
+	// maxRequestSize is set before sending the request to limit the size of headers and body
+	maxRequestSize int64
+
+	// chunked and contentLength are set before sending the request to control transfer encoding of the body
+	chunked       ChunkedMode
+	contentLength int64
+
+	// wroteRequest is set by the trace when the request is completely written to the connection
+	wroteRequest atomic.Bool
+
+	// phase is set by the trace to the phase of the request that is in progress
+	phase atomic.Value // TimeoutPhase
+
+	// trailers is set before sending the request to send them after the request body
+	trailers http.Header
+
+	// streamBody is set before sending the request to send the streamed multipart form instead of the resty body
+	streamBody io.ReadCloser
+
+	// onUploadProgress is set before sending the request to report the number of sent bytes of the body
+	onUploadProgress func(bytesDone, totalBytes int64)
+
+	// encoder and encoding are set before sending the request to compress the body with the content coding
+	encoder  Encoder
+	encoding string
+}
+
+type requestStateKey struct{}
+
+func withRequestState(ctx context.Context) (context.Context, *requestState) {
+	state := &requestState{}
+	return context.WithValue(ctx, requestStateKey{}, state), state
+}
+
+func getRequestState(ctx context.Context) *requestState {
+	if ctx == nil {
+		return nil
+	}
+	state, _ := ctx.Value(requestStateKey{}).(*requestState)
+	return state
+}
+
+func (s *requestState) addRedirect(r Redirect) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.redirects = append(s.redirects, r)
+}
+
+func (s *requestState) getRedirects() []Redirect {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.redirects) == 0 {
+		return nil
+	}
+	out := make([]Redirect, len(s.redirects))
+	copy(out, s.redirects)
+	return out
+}
+
+func (s *requestState) setSavedFile(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.savedFile = path
+}
+
+func (s *requestState) getSavedFile() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.savedFile
+}
+
+func (s *requestState) setStreamed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streamed = true
+}
+
+func (s *requestState) isStreamed() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streamed
+}
+
+func (s *requestState) getMaxRequestSize() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.maxRequestSize
+}
+
+func (s *requestState) getTrailers() http.Header {
+	if s == nil {
+		return nil
+	}
+	return s.trailers
+}
@@ -0,0 +1,94 @@
+package cliex
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// RequestStats is the statistics of the request collected across all its attempts.
+type RequestStats struct {
+	// Duration is the total time of the request including retries and waits between them.
+	Duration time.Duration
+	// Attempts is the number of sent attempts, 1 means the request succeeded without retries.
+	Attempts int
+	// HalfOpen is true if the request was sent by the circuit breaker in half-open state.
+	HalfOpen bool
+	// BytesSent is the approximate number of bytes of request line, headers and body sent in all attempts.
+	BytesSent int64
+}
+
+type requestStatsKey struct{}
+
+// requestStats is the RequestStats shared by all attempts of the request via the request context.
+type requestStats struct {
+	mu    sync.Mutex
+	stats RequestStats
+}
+
+func withRequestStats(ctx context.Context) (context.Context, *requestStats) {
+	stats := &requestStats{}
+	return context.WithValue(ctx, requestStatsKey{}, stats), stats
+}
+
+func getRequestStats(ctx context.Context) *requestStats {
+	if ctx == nil {
+		return nil
+	}
+	stats, _ := ctx.Value(requestStatsKey{}).(*requestStats)
+	return stats
+}
+
+func (s *requestStats) addAttempt() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Attempts++
+}
+
+func (s *requestStats) addBytesSent(n int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.BytesSent += n
+}
+
+func (s *requestStats) setHalfOpen() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.HalfOpen = true
+}
+
+func (s *requestStats) setDuration(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Duration = d
+}
+
+func (s *requestStats) get() RequestStats {
+	if s == nil {
+		return RequestStats{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Stats returns the total duration, the number of attempts and the circuit breaker state of the request
+// of the response, e.g. to record requests that succeeded after retries. Memoized responses (RequestOpts.CacheTTL)
+// return statistics of the original request. It returns zero RequestStats if the response is not made by HTTP.
+func Stats(resp *resty.Response) RequestStats {
+	if resp == nil || resp.Request == nil {
+		return RequestStats{}
+	}
+	return getRequestStats(resp.Request.Context()).get()
+}
@@ -0,0 +1,111 @@
+package cliex_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Stats(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flaky" && calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{
+		BaseURL:                srv.URL,
+		CircuitBreaker:         true,
+		CircuitBreakerFailures: 1,
+		CircuitBreakerTimeout:  10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	resp, err := client.Request(ctx, "/flaky", cliex.RequestOpts{
+		RetryCount:       3,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+		NoLogRetryError:  true,
+	})
+	require.NoError(t, err)
+	stats := cliex.Stats(resp)
+	assert.Equal(t, 3, stats.Attempts)
+	assert.False(t, stats.HalfOpen)
+	assert.GreaterOrEqual(t, stats.Duration, resp.Time())
+
+	resp, err = client.Get(ctx, "/ok")
+	require.NoError(t, err)
+	assert.Equal(t, 1, cliex.WrapResponse(resp).Stats().Attempts)
+
+	calls.Store(0)
+	_, err = client.Get(ctx, "/flaky")
+	require.Error(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = client.Get(ctx, "/flaky")
+	require.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+	time.Sleep(20 * time.Millisecond)
+
+	resp, err = client.Get(ctx, "/flaky")
+	require.NoError(t, err)
+	stats = cliex.Stats(resp)
+	assert.Equal(t, 1, stats.Attempts)
+	assert.True(t, stats.HalfOpen)
+
+	assert.Equal(t, cliex.RequestStats{}, cliex.Stats(nil))
+}
+
+func TestHTTP_MaxRequestSize(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithMaxRequestSize(1024))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	resp, err := client.Post(ctx, "/", map[string]string{"name": "john"})
+	require.NoError(t, err)
+	bytesSent := cliex.Stats(resp).BytesSent
+	assert.Greater(t, bytesSent, int64(len(`{"name":"john"}`)))
+	assert.Less(t, bytesSent, int64(1024))
+
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{
+		Method:          http.MethodPost,
+		Body:            strings.Repeat("a", 2048),
+		RetryCount:      3,
+		NoLogRetryError: true,
+	})
+	require.ErrorIs(t, err, cliex.ErrRequestTooLarge)
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{
+		Method:     http.MethodPost,
+		BodyReader: io.MultiReader(strings.NewReader(strings.Repeat("a", 2048))),
+	})
+	require.ErrorIs(t, err, cliex.ErrRequestTooLarge)
+
+	_, err = client.Request(ctx, "/", cliex.RequestOpts{
+		Method:         http.MethodPost,
+		Body:           strings.Repeat("a", 2048),
+		MaxRequestSize: 4096,
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,23 @@
+package cliex
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Stream makes HTTP request with the given options like Request and returns the body of the successful response
+// without reading it into memory, e.g. to process multi-gigabyte downloads. The body must be closed after use.
+// Config.RequestTimeout limits reading of the body too, use a longer timeout or the context to control it.
+// Use Do with RequestOpts.StreamResponse to get the headers of the response as well.
+func (c *HTTP) Stream(ctx context.Context, url string, opts RequestOpts) (io.ReadCloser, error) {
+	opts.StreamResponse = true
+	resp, err := c.Request(ctx, url, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.NilOn404 && resp.StatusCode() == http.StatusNotFound {
+		return http.NoBody, nil
+	}
+	return resp.RawBody(), nil
+}
@@ -0,0 +1,70 @@
+package cliex_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Stream(t *testing.T) {
+	content := strings.Repeat("streamed content ", 10000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/file":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte(content))
+		case "/denied":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"no access"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	body, err := client.Stream(ctx, "/file", cliex.RequestOpts{})
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, content, string(data))
+
+	_, err = client.Stream(ctx, "/denied", cliex.RequestOpts{})
+	require.ErrorIs(t, err, cliex.ErrForbidden)
+	assert.Contains(t, err.Error(), "no access")
+
+	body, err = client.Stream(ctx, "/missing", cliex.RequestOpts{NilOn404: true})
+	require.NoError(t, err)
+	data, err = io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	resp, err := client.Do(ctx, "/file", cliex.RequestOpts{StreamResponse: true})
+	require.NoError(t, err)
+	assert.Equal(t, "application/octet-stream", resp.Header().Get("Content-Type"))
+	reader, err := resp.Reader()
+	require.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	reader.Close()
+	assert.Equal(t, content, string(data))
+	_, err = resp.Bytes()
+	require.Error(t, err)
+
+	resp, err = client.Do(ctx, "/file", cliex.RequestOpts{StreamResponse: true})
+	require.NoError(t, err)
+	str, err := resp.String()
+	require.NoError(t, err)
+	assert.Equal(t, content, str)
+}
@@ -0,0 +1,69 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrUnknownTemplate is returned by HTTP.Call when the template is not registered.
+var ErrUnknownTemplate = errors.New("unknown request template")
+
+// RegisterTemplate stores the request options under the name to use them with HTTP.Call.
+// Route of the options is the URL of the request, it may contain path params, e.g. "/users/{id}".
+// Registering a template with the same name replaces the previous one.
+func (c *HTTP) RegisterTemplate(name string, opts RequestOpts) {
+	c.templates.Set(name, opts)
+}
+
+// Call makes a request using the registered template. Non-zero fields of overrides replace the fields of the template,
+// maps (Headers, Query, PathParams, FormData, Files) are merged with the values of overrides taking precedence,
+// header names are compared case-insensitively. Boolean fields can only be enabled by overrides:
+// false is the zero value, so the field that is true in the template can't be turned off.
+func (c *HTTP) Call(ctx context.Context, name string, overrides RequestOpts) (*resty.Response, error) {
+	tmpl, ok := c.templates.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	opts := mergeRequestOpts(tmpl, overrides)
+	return c.Request(ctx, opts.Route, opts)
+}
+
+// mergeRequestOpts returns the base options with applied non-zero fields of overrides.
+// Keys of Headers and HeaderValues are canonicalized, so "content-type" of overrides replaces "Content-Type" of base.
+func mergeRequestOpts(base, overrides RequestOpts) RequestOpts {
+	out := reflect.ValueOf(&base).Elem()
+	over := reflect.ValueOf(overrides)
+
+	for i := range over.NumField() {
+		field := over.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		target := out.Field(i)
+		name := over.Type().Field(i).Name
+		isHeader := name == "Headers" || name == "HeaderValues"
+		if field.Kind() != reflect.Map || (target.IsNil() && !isHeader) {
+			target.Set(field)
+			continue
+		}
+		// Copy the map of the template to not modify it
+		merged := reflect.MakeMapWithSize(field.Type(), target.Len()+field.Len())
+		for _, m := range []reflect.Value{target, field} {
+			iter := m.MapRange()
+			for iter.Next() {
+				key := iter.Key()
+				if isHeader {
+					key = reflect.ValueOf(http.CanonicalHeaderKey(key.String()))
+				}
+				merged.SetMapIndex(key, iter.Value())
+			}
+		}
+		target.Set(merged)
+	}
+	return base
+}
@@ -0,0 +1,47 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Call(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "template", r.Header.Get("X-Source"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `","trace":"` + r.Header.Get("X-Trace") + `"}`))
+	}))
+	defer mockServer.Close()
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL})
+	require.NoError(t, err)
+
+	headers := map[string]string{"X-Source": "template", "X-Trace": "default"}
+	client.RegisterTemplate("create-item", cliex.RequestOpts{
+		Method:  http.MethodPost,
+		Route:   "/users/{id}/items",
+		Headers: headers,
+	})
+
+	var result map[string]string
+	_, err = client.Call(context.Background(), "create-item", cliex.RequestOpts{
+		PathParams: map[string]string{"id": "42"},
+		Headers:    map[string]string{"X-Trace": "abc"},
+		Result:     &result,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"path": "/users/42/items", "trace": "abc"}, result)
+
+	// Template is not modified by overrides
+	assert.Equal(t, "default", headers["X-Trace"])
+
+	_, err = client.Call(context.Background(), "unknown", cliex.RequestOpts{})
+	assert.ErrorIs(t, err, cliex.ErrUnknownTemplate)
+}
@@ -0,0 +1,309 @@
+package cliex
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/maxbolgarin/lang"
+)
+
+// RequireHeaderForTest wraps the response function and checks that the request contains
+// the header with the expected value. It responds with 401 Unauthorized otherwise.
+func RequireHeaderForTest(key, value string, f ResponseFuncForTest) ResponseFuncForTest {
+	return func(ctx context.Context, req *http.Request) (any, error) {
+		if !equalForTest(req.Header.Get(key), value) {
+			return nil, fmt.Errorf("%w: invalid header %s", ErrUnauthorized, key)
+		}
+		return callForTest(ctx, req, f)
+	}
+}
+
+// RequireAuthorizationForTest wraps the response function and checks that the Authorization header
+// of the request is equal to the expected value (e.g. "Bearer token" or "Token abc").
+// It responds with 401 Unauthorized otherwise.
+func RequireAuthorizationForTest(value string, f ResponseFuncForTest) ResponseFuncForTest {
+	return func(ctx context.Context, req *http.Request) (any, error) {
+		if !equalForTest(req.Header.Get("Authorization"), value) {
+			return nil, fmt.Errorf("%w: invalid authorization", ErrUnauthorized)
+		}
+		return callForTest(ctx, req, f)
+	}
+}
+
+// RequireBearerTokenForTest wraps the response function and checks that the request is authorized
+// with the expected bearer token. It responds with 401 Unauthorized otherwise.
+func RequireBearerTokenForTest(token string, f ResponseFuncForTest) ResponseFuncForTest {
+	return RequireAuthorizationForTest("Bearer "+token, f)
+}
+
+// RequireBasicAuthForTest wraps the response function and checks that the request is authorized
+// with the expected basic auth credentials. It responds with 401 Unauthorized otherwise.
+func RequireBasicAuthForTest(user, pass string, f ResponseFuncForTest) ResponseFuncForTest {
+	return func(ctx context.Context, req *http.Request) (any, error) {
+		gotUser, gotPass, ok := req.BasicAuth()
+		if !ok || !equalForTest(gotUser, user) || !equalForTest(gotPass, pass) {
+			return nil, fmt.Errorf("%w: invalid basic auth", ErrUnauthorized)
+		}
+		return callForTest(ctx, req, f)
+	}
+}
+
+// WithDelayForTest wraps the response function and delays every response by the given duration.
+// The delay is interrupted if the request or the server context is canceled.
+func WithDelayForTest(delay time.Duration, f ResponseFuncForTest) ResponseFuncForTest {
+	return func(ctx context.Context, req *http.Request) (any, error) {
+		if err := sleepForTest(ctx, req, delay); err != nil {
+			return nil, err
+		}
+		return callForTest(ctx, req, f)
+	}
+}
+
+// WithRandomDelayForTest wraps the response function and delays every response by a random duration in [min, max).
+// Delays are generated from the provided seed, so the same seed produces the same sequence of delays.
+func WithRandomDelayForTest(min, max time.Duration, seed uint64, f ResponseFuncForTest) ResponseFuncForTest {
+	rnd := newRandForTest(seed)
+	return func(ctx context.Context, req *http.Request) (any, error) {
+		delay := min
+		if max > min {
+			delay += time.Duration(rnd.float64() * float64(max-min))
+		}
+		if err := sleepForTest(ctx, req, delay); err != nil {
+			return nil, err
+		}
+		return callForTest(ctx, req, f)
+	}
+}
+
+// WithFailureRateForTest wraps the response function and fails requests with the given probability (from 0 to 1).
+// Failed requests return the provided error (ErrInternalServerError if it is nil).
+// Failures are generated from the provided seed, so the same seed produces the same sequence of failures.
+func WithFailureRateForTest(rate float64, seed uint64, err error, f ResponseFuncForTest) ResponseFuncForTest {
+	rnd := newRandForTest(seed)
+	err = lang.Check(err, ErrInternalServerError)
+	return func(ctx context.Context, req *http.Request) (any, error) {
+		if rnd.float64() < rate {
+			return nil, err
+		}
+		return callForTest(ctx, req, f)
+	}
+}
+
+// WithFailFirstForTest wraps the response function and fails the first n requests with the provided error
+// (ErrInternalServerError if it is nil). It is useful to check retries and circuit breaker deterministically.
+func WithFailFirstForTest(n int, err error, f ResponseFuncForTest) ResponseFuncForTest {
+	var counter atomic.Int64
+	err = lang.Check(err, ErrInternalServerError)
+	return func(ctx context.Context, req *http.Request) (any, error) {
+		if counter.Add(1) <= int64(n) {
+			return nil, err
+		}
+		return callForTest(ctx, req, f)
+	}
+}
+
+// CapturedRequest is a request received by the test server.
+type CapturedRequest struct {
+	// Method is the HTTP method of the request.
+	Method string
+	// Path is the URL path of the request.
+	Path string
+	// Query is the query string of the request.
+	Query url.Values
+	// Header is the headers of the request.
+	Header http.Header
+	// Body is the raw body of the request.
+	Body []byte
+	// JSON is the decoded JSON body of the request, it is nil if the body is not a valid JSON.
+	JSON any
+}
+
+// JSONPath returns a value from the decoded JSON body by the path, e.g. "$.user.id" or "$.items[0]".
+// Values matched by the path with wildcards like "$.items[*].id" are returned as []any.
+func (r CapturedRequest) JSONPath(path string) (any, bool) {
+	if r.JSON == nil {
+		return nil, false
+	}
+	return lookupJSONPath(r.JSON, path)
+}
+
+// RequestRecorderForTest stores requests received by the test server.
+// Use CaptureForTest to record requests of a path.
+type RequestRecorderForTest struct {
+	mu   sync.Mutex
+	reqs []CapturedRequest
+}
+
+// NewRequestRecorderForTest returns a new empty RequestRecorderForTest.
+func NewRequestRecorderForTest() *RequestRecorderForTest {
+	return &RequestRecorderForTest{}
+}
+
+// Requests returns all captured requests in the order they were received.
+func (r *RequestRecorderForTest) Requests() []CapturedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CapturedRequest(nil), r.reqs...)
+}
+
+// Last returns the last captured request.
+func (r *RequestRecorderForTest) Last() (CapturedRequest, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.reqs) == 0 {
+		return CapturedRequest{}, false
+	}
+	return r.reqs[len(r.reqs)-1], true
+}
+
+// Len returns the number of captured requests.
+func (r *RequestRecorderForTest) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.reqs)
+}
+
+// Reset deletes all captured requests.
+func (r *RequestRecorderForTest) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reqs = nil
+}
+
+func (r *RequestRecorderForTest) add(req CapturedRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reqs = append(r.reqs, req)
+}
+
+// CaptureForTest wraps the response function and stores every request in the recorder.
+// The request body is restored, so the wrapped function can read it again.
+func CaptureForTest(rec *RequestRecorderForTest, f ResponseFuncForTest) ResponseFuncForTest {
+	return func(ctx context.Context, req *http.Request) (any, error) {
+		var body []byte
+		if req.Body != nil {
+			var err error
+			if body, err = io.ReadAll(req.Body); err != nil {
+				return nil, err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		captured := CapturedRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.Query(),
+			Header: req.Header.Clone(),
+			Body:   body,
+		}
+		if len(body) > 0 {
+			var decoded any
+			if err := json.Unmarshal(body, &decoded); err == nil {
+				captured.JSON = decoded
+			}
+		}
+		rec.add(captured)
+
+		return callForTest(ctx, req, f)
+	}
+}
+
+// TestingT is an interface wrapper around *testing.T.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertJSONPath checks that the JSON body of the captured request has the expected value by the path
+// (e.g. "$.user.id"). Expected value is compared after JSON encoding, so AssertJSONPath(t, req, "$.id", 42)
+// matches the decoded float64 value. It returns true if the assertion passes.
+func AssertJSONPath(t TestingT, req CapturedRequest, path string, expected any) bool {
+	t.Helper()
+
+	actual, ok := req.JSONPath(path)
+	if !ok {
+		t.Errorf("json path %s not found in request body: %s", path, string(req.Body))
+		return false
+	}
+
+	raw, err := json.Marshal(expected)
+	if err != nil {
+		t.Errorf("cannot marshal expected value: %v", err)
+		return false
+	}
+	var normalized any
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		t.Errorf("cannot unmarshal expected value: %v", err)
+		return false
+	}
+
+	if !reflect.DeepEqual(normalized, actual) {
+		t.Errorf("json path %s: expected %v, got %v", path, normalized, actual)
+		return false
+	}
+	return true
+}
+
+type randForTest struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newRandForTest(seed uint64) *randForTest {
+	return &randForTest{rnd: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (r *randForTest) float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Float64()
+}
+
+func sleepForTest(ctx context.Context, req *http.Request, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func callForTest(ctx context.Context, req *http.Request, f ResponseFuncForTest) (any, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return f(ctx, req)
+}
+
+func equalForTest(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func statusCodeForTest(err error) int {
+	for code, apiErr := range ErrorMapping {
+		if code >= 400 && code < 600 && errors.Is(err, apiErr) {
+			return code
+		}
+	}
+	return http.StatusInternalServerError
+}
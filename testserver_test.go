@@ -0,0 +1,171 @@
+package cliex_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireAuthForTest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ok := func(ctx context.Context, req *http.Request) (any, error) {
+		return map[string]string{"key": "value"}, nil
+	}
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/bearer": cliex.RequireBearerTokenForTest("token", ok),
+		"/basic":  cliex.RequireBasicAuthForTest("user", "pass", ok),
+		"/header": cliex.RequireHeaderForTest("X-Api-Key", "key", nil),
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	_, err = client.Request(ctx, "/bearer", cliex.RequestOpts{AuthToken: "token"})
+	assert.NoError(t, err)
+
+	_, err = client.Request(ctx, "/bearer", cliex.RequestOpts{AuthToken: "wrong"})
+	assert.ErrorIs(t, err, cliex.ErrUnauthorized)
+
+	_, err = client.Request(ctx, "/basic", cliex.RequestOpts{BasicAuthUser: "user", BasicAuthPass: "pass"})
+	assert.NoError(t, err)
+
+	_, err = client.Get(ctx, "/basic")
+	assert.ErrorIs(t, err, cliex.ErrUnauthorized)
+
+	resp, err := client.Request(ctx, "/header", cliex.RequestOpts{Headers: map[string]string{"X-Api-Key": "key"}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	_, err = client.Get(ctx, "/header")
+	assert.ErrorIs(t, err, cliex.ErrUnauthorized)
+
+	assert.Equal(t, int64(6), requestCounter.Load())
+}
+
+func TestChaosForTest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/slow":  cliex.WithDelayForTest(200*time.Millisecond, nil),
+		"/flaky": cliex.WithFailFirstForTest(2, cliex.ErrServiceUnavailable, nil),
+		"/rate":  cliex.WithFailureRateForTest(1, 42, nil, nil),
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+	cfg.RequestTimeout = 50 * time.Millisecond
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, "/slow")
+	assert.Error(t, err)
+
+	_, err = client.Get(ctx, "/flaky")
+	assert.ErrorIs(t, err, cliex.ErrServiceUnavailable)
+
+	_, err = client.Request(ctx, "/flaky", cliex.RequestOpts{
+		RetryCount:    3,
+		RetryWaitTime: time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	_, err = client.Get(ctx, "/rate")
+	assert.ErrorIs(t, err, cliex.ErrInternalServerError)
+}
+
+func TestCaptureForTest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := cliex.NewRequestRecorderForTest()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/users": cliex.CaptureForTest(rec, func(ctx context.Context, req *http.Request) (any, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil || len(body) == 0 {
+				return nil, cliex.ErrBadRequest
+			}
+			return map[string]string{"key": "value"}, nil
+		}),
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	body := map[string]any{
+		"user":  map[string]any{"id": 42, "name": "max"},
+		"items": []string{"a", "b"},
+		"tags":  []map[string]any{{"id": 1}, {"id": 2}},
+	}
+	_, err = client.Post(ctx, "/users", body)
+	require.NoError(t, err)
+
+	req, ok := rec.Last()
+	require.True(t, ok)
+	assert.Equal(t, 1, rec.Len())
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "/users", req.Path)
+
+	cliex.AssertJSONPath(t, req, "$.user.id", 42)
+	cliex.AssertJSONPath(t, req, "$.user.name", "max")
+	cliex.AssertJSONPath(t, req, "$.items[1]", "b")
+	cliex.AssertJSONPath(t, req, "$.items[*]", []string{"a", "b"})
+	cliex.AssertJSONPath(t, req, "$.tags[*].id", []int{1, 2})
+
+	mockT := &mockTestingT{}
+	assert.False(t, cliex.AssertJSONPath(mockT, req, "$.user.id", 43))
+	assert.False(t, cliex.AssertJSONPath(mockT, req, "$.user.email", "x"))
+	assert.False(t, cliex.AssertJSONPath(mockT, req, "$.tags[*].name", []string{}))
+	assert.Equal(t, 3, mockT.errors)
+}
+
+type mockTestingT struct {
+	errors int
+}
+
+func (m *mockTestingT) Helper() {}
+
+func (m *mockTestingT) Errorf(format string, args ...any) {
+	m.errors++
+}
+
+func TestClientBasicAuth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requestCounter atomic.Int64
+	responseMap := cliex.ResponseMapForTest{
+		"/basic": cliex.RequireBasicAuthForTest("user", "pass", nil),
+		"/other": cliex.RequireBasicAuthForTest("other", "secret", nil),
+	}
+	cfg := cliex.GetConfigForTest(ctx, &requestCounter, responseMap)
+	cfg.BasicAuthUser = "user"
+	cfg.BasicAuthPass = "pass"
+
+	client, err := cliex.NewWithConfig(cfg)
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, "/basic")
+	assert.NoError(t, err)
+
+	_, err = client.Get(ctx, "/other")
+	assert.ErrorIs(t, err, cliex.ErrUnauthorized)
+
+	_, err = client.Request(ctx, "/other", cliex.RequestOpts{BasicAuthUser: "other", BasicAuthPass: "secret"})
+	assert.NoError(t, err)
+}
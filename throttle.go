@@ -0,0 +1,163 @@
+package cliex
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const defaultQueueOn429Wait = time.Second
+
+// hostThrottle queues requests to hosts that returned 429 Too Many Requests until the reset time.
+// After the reset time one request is sent as a probe, other queued requests are released
+// only if the probe is not throttled again, so the throttling is not prolonged by a burst of retries.
+type hostThrottle struct {
+	mu      sync.Mutex
+	hosts   map[string]*throttledHost
+	backoff backoffHeader
+	wait    time.Duration
+}
+
+type throttledHost struct {
+	until    time.Time
+	probing  bool
+	released chan struct{}
+}
+
+func newHostThrottle(enabled bool, wait time.Duration, backoff backoffHeader) *hostThrottle {
+	if !enabled {
+		return nil
+	}
+	return &hostThrottle{
+		hosts:   make(map[string]*throttledHost),
+		backoff: backoff,
+		wait:    wait,
+	}
+}
+
+// acquire blocks while the host is throttled and returns the ticket that must be reported with the response.
+func (t *hostThrottle) acquire(ctx context.Context, host string) (*throttleTicket, error) {
+	if t == nil || host == "" {
+		return nil, nil
+	}
+	host = strings.ToLower(host)
+
+	for {
+		t.mu.Lock()
+		h, ok := t.hosts[host]
+		if !ok {
+			t.mu.Unlock()
+			return &throttleTicket{t: t, host: host}, nil
+		}
+		released := h.released
+		delay := time.Until(h.until)
+		if delay <= 0 && !h.probing {
+			h.probing = true
+			t.mu.Unlock()
+			return &throttleTicket{t: t, host: host, probe: true}, nil
+		}
+		t.mu.Unlock()
+
+		// Queued requests wait for the reset time or for the result of the probe
+		if err := waitRelease(ctx, released, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func waitRelease(ctx context.Context, released <-chan struct{}, delay time.Duration) error {
+	var timeout <-chan time.Time
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-released:
+	case <-timeout:
+	}
+	return nil
+}
+
+// throttleTicket is the permission to send the request to the host.
+type throttleTicket struct {
+	t     *hostThrottle
+	host  string
+	probe bool
+	done  bool
+}
+
+// report updates the state of the host with the response: 429 throttles the host until the reset time,
+// other response of the probe releases queued requests.
+func (tk *throttleTicket) report(resp *resty.Response) {
+	if tk == nil || tk.done {
+		return
+	}
+	tk.done = true
+
+	t := tk.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := t.hosts[tk.host]
+	switch status := statusCode(resp); {
+	case status == http.StatusTooManyRequests:
+		until := time.Now().Add(t.waitTime(resp))
+		if h == nil {
+			t.hosts[tk.host] = &throttledHost{until: until, released: make(chan struct{})}
+			return
+		}
+		h.until = maxTime(h.until, until)
+		if tk.probe {
+			h.probing = false
+			t.wake(h)
+		}
+
+	case h == nil || !tk.probe:
+		// Responses of requests that were sent before the host was throttled don't release the queue
+
+	case status != 0:
+		delete(t.hosts, tk.host)
+		close(h.released)
+
+	default:
+		// The probe is not sent or failed without response, the next queued request becomes the probe
+		h.probing = false
+		t.wake(h)
+	}
+}
+
+// cancel releases the ticket of the request that was not sent.
+func (tk *throttleTicket) cancel() {
+	tk.report(nil)
+}
+
+// wake wakes up queued requests to check the state of the host again.
+func (t *hostThrottle) wake(h *throttledHost) {
+	close(h.released)
+	h.released = make(chan struct{})
+}
+
+// waitTime returns the time to hold requests after 429 from the response headers.
+func (t *hostThrottle) waitTime(resp *resty.Response) time.Duration {
+	if wait, ok := t.backoff.retryAfter(resp); ok && wait > 0 {
+		return wait
+	}
+	if status, ok := RateLimitInfo(resp); ok && status.Exhausted() && status.WaitTime() > 0 {
+		return status.WaitTime()
+	}
+	return t.wait
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,75 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_QueueOn429(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		until     time.Time
+		throttled atomic.Int64
+		requests  atomic.Int64
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		mu.Lock()
+		defer mu.Unlock()
+		if until.IsZero() {
+			until = time.Now().Add(200 * time.Millisecond)
+		}
+		if time.Now().Before(until) {
+			throttled.Add(1)
+			w.Header().Set("X-Backoff-Ms", "200")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithQueueOn429(time.Second),
+		cliex.WithBackoffHeader("X-Backoff-Ms", time.Millisecond))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.Get(ctx, "/")
+	require.ErrorIs(t, err, cliex.ErrTooManyRequests)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Get(ctx, "/")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	assert.EqualValues(t, 1, throttled.Load())
+	assert.EqualValues(t, 6, requests.Load())
+
+	// Queued requests are canceled with the context
+	mu.Lock()
+	until = time.Time{}
+	mu.Unlock()
+	_, err = client.Get(ctx, "/")
+	require.ErrorIs(t, err, cliex.ErrTooManyRequests)
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = client.Get(ctx, "/")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualValues(t, 7, requests.Load())
+}
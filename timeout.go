@@ -0,0 +1,106 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// TimeoutPhase is the phase of the request that was in progress when the request timed out.
+type TimeoutPhase string
+
+const (
+	// PhaseConnect is getting a connection from the pool or dialing the server.
+	PhaseConnect TimeoutPhase = "connect"
+	// PhaseDNS is resolving the host of the server.
+	PhaseDNS TimeoutPhase = "dns"
+	// PhaseTLS is the TLS handshake with the server.
+	PhaseTLS TimeoutPhase = "tls handshake"
+	// PhaseWriteRequest is writing headers and body of the request to the connection.
+	PhaseWriteRequest TimeoutPhase = "write request"
+	// PhaseWaitHeaders is waiting for the first byte of the response after the request is written.
+	PhaseWaitHeaders TimeoutPhase = "wait for headers"
+	// PhaseReadHeaders is reading headers of the response.
+	PhaseReadHeaders TimeoutPhase = "read headers"
+	// PhaseReadBody is reading the body of the response.
+	PhaseReadBody TimeoutPhase = "read body"
+)
+
+// TimeoutError is returned when the request is timed out by the deadline of the context or by Config.RequestTimeout.
+// It contains the phase of the request that was in progress, so a slow DNS can be told from a slow server.
+type TimeoutError struct {
+	// Phase is the phase of the request that was in progress.
+	Phase TimeoutPhase
+	// Elapsed is the duration of the attempt until the timeout.
+	Elapsed time.Duration
+	// Err is the error of the request.
+	Err error
+}
+
+// Error returns the error message with the phase of the request.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timeout during %s after %s: %s", e.Phase, e.Elapsed.Round(time.Millisecond), e.Err)
+}
+
+// Unwrap returns the error of the request.
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// AsTimeoutError returns the TimeoutError from the error chain.
+func AsTimeoutError(err error) (*TimeoutError, bool) {
+	var timeoutErr *TimeoutError
+	ok := errors.As(err, &timeoutErr)
+	return timeoutErr, ok
+}
+
+// tracePhases sets hooks of the trace that track the phase of the request in the state.
+func tracePhases(trace *httptrace.ClientTrace, state *requestState) {
+	setPhase := func(phase TimeoutPhase) { state.phase.Store(phase) }
+
+	trace.GetConn = func(string) { setPhase(PhaseConnect) }
+	trace.DNSStart = func(httptrace.DNSStartInfo) { setPhase(PhaseDNS) }
+	trace.DNSDone = func(httptrace.DNSDoneInfo) { setPhase(PhaseConnect) }
+	trace.TLSHandshakeStart = func() { setPhase(PhaseTLS) }
+	trace.GotConn = func(httptrace.GotConnInfo) { setPhase(PhaseWriteRequest) }
+	trace.GotFirstResponseByte = func() { setPhase(PhaseReadHeaders) }
+
+	wroteRequest := trace.WroteRequest
+	trace.WroteRequest = func(info httptrace.WroteRequestInfo) {
+		if info.Err == nil {
+			setPhase(PhaseWaitHeaders)
+		}
+		if wroteRequest != nil {
+			wroteRequest(info)
+		}
+	}
+}
+
+// timeoutError wraps the timeout error of the attempt into TimeoutError with the phase that was in progress.
+func (s *requestState) timeoutError(resp *resty.Response, err error, elapsed time.Duration) error {
+	if err == nil || !isTimeout(err) {
+		return err
+	}
+	phase, _ := s.phase.Load().(TimeoutPhase)
+	if resp != nil && resp.RawResponse != nil {
+		phase = PhaseReadBody
+	}
+	if phase == "" {
+		phase = PhaseConnect
+	}
+	return &TimeoutError{Phase: phase, Elapsed: elapsed, Err: err}
+}
+
+// isTimeout returns true if the error is caused by the deadline of the context or by the timeout of the client.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,60 @@
+package cliex_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_TimeoutPhase(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/headers":
+			select {
+			case <-time.After(time.Second):
+			case <-done:
+			}
+		case "/body":
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-time.After(time.Second):
+			case <-done:
+			}
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = client.Get(ctx, "/headers")
+	require.Error(t, err)
+	assert.True(t, cliex.IsDeadlineExceeded(err))
+	timeoutErr, ok := cliex.AsTimeoutError(err)
+	require.True(t, ok)
+	assert.Equal(t, cliex.PhaseWaitHeaders, timeoutErr.Phase)
+	assert.GreaterOrEqual(t, timeoutErr.Elapsed, 50*time.Millisecond)
+	assert.Contains(t, err.Error(), "timeout during wait for headers")
+
+	client, err = cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithRequestTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	_, err = client.Get(context.Background(), "/body")
+	timeoutErr, ok = cliex.AsTimeoutError(err)
+	require.True(t, ok, err)
+	assert.Equal(t, cliex.PhaseReadBody, timeoutErr.Phase)
+
+	_, err = client.Get(context.Background(), "/")
+	require.NoError(t, err)
+}
@@ -0,0 +1,15 @@
+package cliex
+
+import "net/http"
+
+// applyTrailers declares trailers of RequestOpts.Trailers in the request. The body is switched to chunked encoding,
+// because trailers are not sent with Content-Length.
+func applyTrailers(req *http.Request) {
+	trailers := getRequestState(req.Context()).getTrailers()
+	if len(trailers) == 0 || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	req.Trailer = trailers
+	req.ContentLength = -1
+	req.Header.Del("Content-Length")
+}
@@ -0,0 +1,38 @@
+package cliex_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Trailers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Write([]byte(string(body) + ":" + r.Trailer.Get("X-Checksum")))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	resp, err := client.Do(context.Background(), "/", cliex.RequestOpts{
+		Method:   http.MethodPost,
+		Body:     []byte("data"),
+		Trailers: http.Header{"X-Checksum": {"abc"}},
+	})
+	require.NoError(t, err)
+
+	body, err := resp.String()
+	require.NoError(t, err)
+	assert.Equal(t, "data:abc", body)
+	assert.Equal(t, "0", resp.Trailer().Get("Grpc-Status"))
+}
@@ -0,0 +1,60 @@
+package cliex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ChunkedMode is the mode of chunked transfer encoding of the request body, see RequestOpts.Chunked.
+type ChunkedMode string
+
+const (
+	// ChunkedAuto sends in-memory bodies and bodies with RequestOpts.ContentLength with Content-Length header,
+	// other streamed bodies are chunked.
+	ChunkedAuto ChunkedMode = ""
+	// ChunkedOn always sends the body with chunked encoding, e.g. for servers that process the body while it is sent.
+	ChunkedOn ChunkedMode = "on"
+	// ChunkedOff always sends the body with Content-Length header for servers that don't accept chunked encoding.
+	// Streamed body without RequestOpts.ContentLength is buffered in memory to get its size.
+	ChunkedOff ChunkedMode = "off"
+)
+
+// applyTransferEncoding sets the length of the request body according to RequestOpts.Chunked and
+// RequestOpts.ContentLength. Zero ContentLength of http.Request with the body means unknown size (chunked),
+// -1 forces chunked encoding for in-memory bodies.
+func applyTransferEncoding(req *http.Request) error {
+	state := getRequestState(req.Context())
+	if state == nil || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	if state.contentLength > 0 && req.ContentLength <= 0 {
+		req.ContentLength = state.contentLength
+	}
+
+	switch state.chunked {
+	case ChunkedOn:
+		req.ContentLength = -1
+		req.Header.Del("Content-Length")
+
+	case ChunkedOff:
+		if req.ContentLength > 0 {
+			return nil
+		}
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+		req.ContentLength = int64(len(data))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+		req.Body, _ = req.GetBody()
+		if len(data) == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cliex_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingConn struct {
+	net.Conn
+	written *atomic.Int64
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written.Add(int64(n))
+	return n, err
+}
+
+func TestHTTP_Chunked(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(strconv.FormatInt(r.ContentLength, 10) + " " + strings.Join(r.TransferEncoding, ",") + " " + string(body)))
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name string
+		opts cliex.RequestOpts
+		want string
+	}{
+		{"stream", cliex.RequestOpts{Body: strings.NewReader("data")}, "4  data"},
+		{"unknown size", cliex.RequestOpts{Body: io.MultiReader(strings.NewReader("data"))}, "-1 chunked data"},
+		{"content length", cliex.RequestOpts{Body: io.MultiReader(strings.NewReader("data")), ContentLength: 4}, "4  data"},
+		{"chunked off", cliex.RequestOpts{Body: io.MultiReader(strings.NewReader("data")), Chunked: cliex.ChunkedOff}, "4  data"},
+		{"chunked on", cliex.RequestOpts{Body: []byte("data"), Chunked: cliex.ChunkedOn}, "-1 chunked data"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Method = http.MethodPost
+			resp, err := client.Request(ctx, "/", tc.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, resp.String())
+		})
+	}
+}
+
+func TestHTTP_ExpectContinue(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer srv.Close()
+
+	client, err := cliex.New(cliex.WithBaseURL(srv.URL), cliex.WithExpectContinueTimeout(5*time.Second))
+	require.NoError(t, err)
+
+	var written atomic.Int64
+	transport, err := client.C().Transport()
+	require.NoError(t, err)
+	dial := transport.DialContext
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		return &countingConn{Conn: conn, written: &written}, err
+	}
+
+	const size = 1 << 20
+	_, err = client.Request(context.Background(), "/", cliex.RequestOpts{
+		Method:         http.MethodPut,
+		Body:           strings.NewReader(strings.Repeat("a", size)),
+		ExpectContinue: true,
+	})
+	require.ErrorIs(t, err, cliex.ErrUnauthorized)
+	assert.Less(t, written.Load(), int64(size))
+
+	written.Store(0)
+	_, err = client.Request(context.Background(), "/", cliex.RequestOpts{
+		Method:         http.MethodPut,
+		Body:           strings.NewReader(strings.Repeat("a", size)),
+		ExpectContinue: true,
+		AuthToken:      "token",
+	})
+	require.NoError(t, err)
+	assert.Greater(t, written.Load(), int64(size))
+}
@@ -0,0 +1,232 @@
+package cliex
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maxbolgarin/lang"
+)
+
+const (
+	tusVersion = "1.0.0"
+
+	defaultChunkSize         = 4 << 20
+	defaultChunkRetries      = 3
+	defaultChunkRetryWait    = time.Second
+	tusOffsetOctetStreamMIME = "application/offset+octet-stream"
+)
+
+// ErrUploadSession is returned when the server responded to the tus request without required headers
+// or with Upload-Offset that doesn't advance after the uploaded chunk.
+var ErrUploadSession = errors.New("invalid upload session response")
+
+// ResumableUploadOpts is the options for resumable uploads with tus.io protocol.
+type ResumableUploadOpts struct {
+	// Headers is the map of headers that are added to every request of the upload (e.g. authorization).
+	Headers map[string]string
+
+	// UploadURL is the URL of the existing upload session to resume.
+	// Default is empty, means a new session is created with POST request to the URL.
+	UploadURL string
+
+	// OnSessionCreated is called with the URL of the created upload session,
+	// persist it to resume the upload after restart of the application.
+	OnSessionCreated func(uploadURL string)
+
+	// Metadata is the Upload-Metadata of the new session, e.g. "filename" and "filetype".
+	// Default is "filename" with the base name of the file.
+	Metadata map[string]string
+
+	// ChunkSize is the size of a single PATCH request in bytes. Default is 4 MB.
+	ChunkSize int64
+
+	// RetryCount is the number of retries of a single chunk, the offset is synced with HEAD request
+	// before every retry. Default is 3.
+	RetryCount int
+
+	// RetryWaitTime is the wait time before the retry of the chunk. Default is 1 second.
+	RetryWaitTime time.Duration
+
+	// OnProgress is called after every uploaded chunk with uploaded and total bytes.
+	OnProgress func(bytesDone, totalBytes int64)
+}
+
+// UploadResumable uploads the file using tus.io resumable upload protocol (https://tus.io/protocols/resumable-upload).
+// It creates the upload session with POST request to the URL (or resumes opts.UploadURL),
+// then uploads the file by chunks, resuming from the offset reported by the server after failures.
+// It returns the URL of the upload session.
+func (c *HTTP) UploadResumable(ctx context.Context, url, path string, opts ResumableUploadOpts) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("stat file: %w", err)
+	}
+	size := info.Size()
+
+	opts.ChunkSize = lang.Check(opts.ChunkSize, defaultChunkSize)
+	opts.RetryCount = lang.Check(opts.RetryCount, defaultChunkRetries)
+	opts.RetryWaitTime = lang.Check(opts.RetryWaitTime, defaultChunkRetryWait)
+	if opts.Metadata == nil {
+		opts.Metadata = map[string]string{"filename": info.Name()}
+	}
+
+	uploadURL := opts.UploadURL
+	offset := int64(0)
+	if uploadURL == "" {
+		uploadURL, err = c.createUploadSession(ctx, url, size, opts)
+		if err != nil {
+			return "", fmt.Errorf("create upload session: %w", err)
+		}
+		if opts.OnSessionCreated != nil {
+			opts.OnSessionCreated(uploadURL)
+		}
+	} else {
+		offset, err = c.uploadOffset(ctx, uploadURL, opts)
+		if err != nil {
+			return uploadURL, fmt.Errorf("get upload offset: %w", err)
+		}
+	}
+
+	chunk := make([]byte, opts.ChunkSize)
+	for offset < size {
+		n, err := file.ReadAt(chunk[:min(opts.ChunkSize, size-offset)], offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return uploadURL, fmt.Errorf("read file: %w", err)
+		}
+
+		newOffset, err := c.uploadChunk(ctx, uploadURL, offset, chunk[:n], opts)
+		for retry := 0; err != nil && retry < opts.RetryCount; retry++ {
+			select {
+			case <-ctx.Done():
+				return uploadURL, ctx.Err()
+			case <-time.After(opts.RetryWaitTime):
+			}
+			// The server may have received a part of the chunk, so the upload continues from its offset
+			newOffset, err = c.uploadOffset(ctx, uploadURL, opts)
+			if err == nil && newOffset == offset {
+				newOffset, err = c.uploadChunk(ctx, uploadURL, offset, chunk[:n], opts)
+			}
+		}
+		if err != nil {
+			return uploadURL, fmt.Errorf("upload chunk at offset %d: %w", offset, err)
+		}
+		if newOffset <= offset {
+			// The server accepted the chunk without progress, sending it again would loop forever
+			return uploadURL, fmt.Errorf("%w: Upload-Offset %d after the chunk at offset %d", ErrUploadSession, newOffset, offset)
+		}
+
+		offset = newOffset
+		if opts.OnProgress != nil {
+			opts.OnProgress(offset, size)
+		}
+	}
+
+	return uploadURL, nil
+}
+
+func (c *HTTP) createUploadSession(ctx context.Context, url string, size int64, opts ResumableUploadOpts) (string, error) {
+	headers := tusHeaders(opts.Headers)
+	headers["Upload-Length"] = strconv.FormatInt(size, 10)
+	if len(opts.Metadata) > 0 {
+		headers["Upload-Metadata"] = encodeUploadMetadata(opts.Metadata)
+	}
+
+	resp, err := c.Request(ctx, url, RequestOpts{
+		Method:      http.MethodPost,
+		Headers:     headers,
+		RequestName: "create upload",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	location := resp.Header().Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("%w: no Location header", ErrUploadSession)
+	}
+	base, err := neturl.Parse(c.prepareURL(lang.If(strings.HasPrefix(url, "http"), url, c.cli.BaseURL+url)))
+	if err != nil {
+		return location, nil
+	}
+	ref, err := neturl.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid Location header %q", ErrUploadSession, location)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+func (c *HTTP) uploadOffset(ctx context.Context, uploadURL string, opts ResumableUploadOpts) (int64, error) {
+	resp, err := c.Request(ctx, uploadURL, RequestOpts{
+		Method:      http.MethodHead,
+		Headers:     tusHeaders(opts.Headers),
+		RequestName: "upload offset",
+	})
+	if err != nil {
+		return 0, err
+	}
+	return parseUploadOffset(resp.Header())
+}
+
+func (c *HTTP) uploadChunk(ctx context.Context, uploadURL string, offset int64, chunk []byte, opts ResumableUploadOpts) (int64, error) {
+	headers := tusHeaders(opts.Headers)
+	headers["Upload-Offset"] = strconv.FormatInt(offset, 10)
+	headers["Content-Type"] = tusOffsetOctetStreamMIME
+
+	resp, err := c.Request(ctx, uploadURL, RequestOpts{
+		Method:      http.MethodPatch,
+		Headers:     headers,
+		Body:        chunk,
+		RequestName: "upload chunk",
+	})
+	if err != nil {
+		return 0, err
+	}
+	return parseUploadOffset(resp.Header())
+}
+
+func tusHeaders(headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers)+3)
+	for k, v := range headers {
+		out[k] = v
+	}
+	out["Tus-Resumable"] = tusVersion
+	return out
+}
+
+func parseUploadOffset(h http.Header) (int64, error) {
+	offset, err := strconv.ParseInt(h.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("%w: invalid Upload-Offset header %q", ErrUploadSession, h.Get("Upload-Offset"))
+	}
+	return offset, nil
+}
+
+// encodeUploadMetadata returns the value of Upload-Metadata header: comma separated keys with base64 encoded values.
+func encodeUploadMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+" "+base64.StdEncoding.EncodeToString([]byte(metadata[k])))
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,149 @@
+package cliex_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tusServer is a minimal in-memory tus.io server that fails selected PATCH requests after a partial write.
+type tusServer struct {
+	mu       sync.Mutex
+	uploads  map[string]*bytes.Buffer
+	lengths  map[string]int64
+	metadata string
+	patches  atomic.Int64
+	failAt   int64
+}
+
+func (s *tusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Tus-Resumable") != "1.0.0" {
+		http.Error(w, "unsupported version", http.StatusPreconditionFailed)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPost:
+		length, _ := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		id := "/files/" + strconv.Itoa(len(s.uploads)+1)
+		s.uploads[id] = &bytes.Buffer{}
+		s.lengths[id] = length
+		s.metadata = r.Header.Get("Upload-Metadata")
+		w.Header().Set("Location", id)
+		w.WriteHeader(http.StatusCreated)
+
+	case http.MethodHead:
+		w.Header().Set("Upload-Offset", strconv.Itoa(s.uploads[r.URL.Path].Len()))
+
+	case http.MethodPatch:
+		buf := s.uploads[r.URL.Path]
+		offset, _ := strconv.Atoi(r.Header.Get("Upload-Offset"))
+		if offset != buf.Len() || r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+			http.Error(w, "conflict", http.StatusConflict)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		if s.patches.Add(1) == s.failAt {
+			// Save only a half of the chunk as if the connection was broken
+			buf.Write(data[:len(data)/2])
+			http.Error(w, "broken", http.StatusBadGateway)
+			return
+		}
+		buf.Write(data)
+		w.Header().Set("Upload-Offset", strconv.Itoa(buf.Len()))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestHTTP_UploadResumable(t *testing.T) {
+	server := &tusServer{uploads: map[string]*bytes.Buffer{}, lengths: map[string]int64{}, failAt: 2}
+	mockServer := httptest.NewServer(server)
+	defer mockServer.Close()
+
+	content := make([]byte, 10_000)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "big.bin")
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL})
+	require.NoError(t, err)
+
+	var (
+		sessionURL string
+		lastDone   int64
+	)
+	uploadURL, err := client.UploadResumable(context.Background(), "/files", path, cliex.ResumableUploadOpts{
+		ChunkSize:        3000,
+		RetryWaitTime:    time.Millisecond,
+		OnSessionCreated: func(url string) { sessionURL = url },
+		OnProgress: func(done, total int64) {
+			assert.Equal(t, int64(len(content)), total)
+			assert.Greater(t, done, lastDone)
+			lastDone = done
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, mockServer.URL+"/files/1", uploadURL)
+	assert.Equal(t, uploadURL, sessionURL)
+	assert.Equal(t, int64(len(content)), lastDone)
+	assert.Equal(t, int64(len(content)), server.lengths["/files/1"])
+	assert.True(t, bytes.Equal(content, server.uploads["/files/1"].Bytes()))
+	assert.Equal(t, "filename "+base64.StdEncoding.EncodeToString([]byte("big.bin")), server.metadata)
+
+	// Resume the existing session from the offset reported by the server
+	server.uploads["/files/1"].Truncate(4000)
+	_, err = client.UploadResumable(context.Background(), "/files", path, cliex.ResumableUploadOpts{UploadURL: uploadURL})
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(content, server.uploads["/files/1"].Bytes()))
+	assert.Len(t, server.uploads, 1)
+
+	_, err = client.UploadResumable(context.Background(), "/files", filepath.Join(t.TempDir(), "missing"), cliex.ResumableUploadOpts{})
+	assert.True(t, strings.HasPrefix(err.Error(), "open file"))
+}
+
+func TestHTTP_UploadResumableNoProgress(t *testing.T) {
+	var patches atomic.Int64
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Location", "/files/1")
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodPatch:
+			patches.Add(1)
+			io.Copy(io.Discard, r.Body)
+			w.Header().Set("Upload-Offset", r.Header.Get("Upload-Offset"))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer mockServer.Close()
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	require.NoError(t, os.WriteFile(path, make([]byte, 100), 0o600))
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL})
+	require.NoError(t, err)
+
+	_, err = client.UploadResumable(context.Background(), "/files", path, cliex.ResumableUploadOpts{ChunkSize: 10})
+	require.ErrorIs(t, err, cliex.ErrUploadSession)
+	assert.Equal(t, int64(1), patches.Load())
+}
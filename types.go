@@ -2,9 +2,13 @@ package cliex
 
 import (
 	"errors"
+	"io"
+	"io/fs"
 	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/sony/gobreaker/v2"
 )
 
@@ -24,16 +28,34 @@ type RequestOpts struct {
 	// Method is the HTTP method to use.
 	Method string
 
-	// Headers is the headers of the request.
+	// Headers is the headers of the request, every value replaces the previous value of the header.
 	Headers map[string]string
 
+	// HeaderValues is the multi-value headers of the request, values are added after Headers,
+	// so repeated headers (e.g. several Forwarded entries) are sent as separate header lines.
+	HeaderValues http.Header
+
+	// DeniedHeaders is the list of headers that are stripped from Headers in addition to Config.DeniedHeaders.
+	DeniedHeaders []string
+
 	// Query is the query string of the request.
 	Query map[string]string
 
+	// QueryValues is the multi-value query string of the request, e.g. ?id=1&id=2, values are added after Query.
+	QueryValues url.Values
+
 	// PathParams is the path parameters of the request, e.g. /v1/users/{userId} and userId is a path parameter
 	// {"userId": "sample@sample.com"}
 	PathParams map[string]string
 
+	// Route is the URL template of the request, e.g. /v1/users/{userId}. It is used in logs and as the circuit breaker key
+	// instead of the concrete URL to keep cardinality bounded when IDs are embedded in paths.
+	// Default is the URL of the request, so a templated URL with PathParams needs no Route.
+	Route string
+
+	// APIVersion is the version of the API of the request that overrides Config.APIVersion.
+	APIVersion string
+
 	// Cookies is the cookies of the request.
 	Cookies []*http.Cookie
 
@@ -43,6 +65,36 @@ type RequestOpts struct {
 	// Files is the files of the request, where key is fila name and value is file path.
 	Files map[string]string
 
+	// FilesFS is the file system of the paths of Files, e.g. embed.FS or fstest.MapFS.
+	// Files from it are streamed as multipart parts, see Multipart. Default is nil, means the OS file system.
+	FilesFS fs.FS
+
+	// Multipart is the parts of the multipart form that are streamed from readers without buffering in memory.
+	// If it is set, FormData and Files are streamed as parts of the same form before these parts.
+	Multipart []MultipartField
+
+	// ContentLength is the size of the streamed Body (e.g. io.Reader of a file) that is sent in Content-Length
+	// header instead of chunked encoding. Default is 0, means the size is known only for in-memory bodies.
+	ContentLength int64
+
+	// ContentEncoding is the content coding of the body (e.g. "gzip" or "zstd") that overrides Config.RequestEncoding,
+	// use EncodingIdentity to send the body without compression. Default is Config.RequestEncoding.
+	ContentEncoding string
+
+	// Chunked is the mode of chunked transfer encoding of the body. Default is ChunkedAuto,
+	// streamed bodies of unknown size are chunked.
+	Chunked ChunkedMode
+
+	// ExpectContinue sends the request with Expect: 100-continue header, so the body is sent only after
+	// the server accepts the headers (or after Config.ExpectContinueTimeout). It avoids sending large uploads
+	// to the server that rejects the request by its headers, e.g. because of authorization or size.
+	ExpectContinue bool
+
+	// IfMatch is the ETag of the resource that is sent in If-Match header for optimistic concurrency,
+	// e.g. from Response.ETag of the previous GET. The request fails with ErrPreconditionFailed
+	// if the resource was changed since. See HTTP.UpdateIfMatch.
+	IfMatch string
+
 	// AuthToken is the token for authentication
 	AuthToken string
 
@@ -55,18 +107,131 @@ type RequestOpts struct {
 	// ForceContentType tell Resty to parse a custom response (e.g. JSON if application/json) into your struct.
 	ForceContentType string
 
+	// JSONDecoding overrides Config.JSONDecoding for the response of this request. Default is nil.
+	JSONDecoding *JSONDecoding
+
+	// Accept is the list of accepted media types in order of preference that is sent in Accept header,
+	// types without explicit quality (e.g. "application/xml;q=0.5") get descending quality values.
+	// Response is decoded into Result by its Content-Type: JSON and XML or with Config.Decoders (e.g. msgpack).
+	Accept []string
+
 	// Body is the body of the request
 	Body any
 
+	// BodyReader is the streamed body of the request, it is used instead of Body if set.
+	// Reader is sent again during retries only if it implements io.Seeker or GetBody is provided,
+	// otherwise retries fail with ErrBodyNotReplayable instead of sending a truncated body.
+	BodyReader io.Reader
+
+	// GetBody returns a new reader of the request body. If it is set, it is called for every retry
+	// (and for the first attempt if BodyReader is nil) to replay streamed bodies.
+	GetBody func() (io.Reader, error)
+
 	// Result is the variable where the response body will be stored
 	Result any
 
+	// NewResult returns a new variable for the response body. It is used in HTTPSet requests to decode
+	// every response into its own variable instead of sharing Result between concurrent requests.
+	// Default is a new value of the type Result points to.
+	NewResult func() any
+
+	// IdentityEncoding is whether to send Accept-Encoding: identity to get the response without compression,
+	// e.g. to get accurate Content-Length. Accept-Encoding from Headers takes precedence.
+	IdentityEncoding bool
+
 	// OutputPath is the path to the output file where will be saved the response.
 	OutputPath string
 
-	// RequestName is the name of the request for logging retries.
+	// StreamResponse is whether to return the body of the successful response without reading it into memory,
+	// e.g. to process multi-gigabyte downloads. The body is available in resp.RawBody() (or Response.Reader)
+	// and must be closed by the caller, Result and TeeWriter are not used. See HTTP.Stream.
+	StreamResponse bool
+
+	// OutputSync is whether to flush the file to the disk with fsync before it replaces OutputPath.
+	// The response is always written to a temporary file that is atomically renamed to OutputPath on success.
+	OutputSync bool
+
+	// OutputEncoding is the mode of saving compressed responses to OutputPath.
+	// Default is OutputEncodingAuto, the content is saved as it is returned by the transport.
+	OutputEncoding OutputEncoding
+
+	// OnDownloadProgress is called while the response is saved to OutputPath or read from the body of StreamResponse
+	// with the number of received bytes and the expected size, totalBytes is -1 if it is unknown (e.g. content is decompressed).
+	OnDownloadProgress func(bytesDone, totalBytes int64)
+
+	// OnUploadProgress is called while the request body is sent with the number of sent bytes and the size
+	// of the body, totalBytes is -1 if it is unknown (e.g. chunked body). It starts from zero for every attempt.
+	OnUploadProgress func(bytesDone, totalBytes int64)
+
+	// TeeWriter receives a copy of the raw body of the successful response, the body is also unmarshaled into Result
+	// or saved to OutputPath.
+	// It is useful for audit logging and caching. Write errors are returned as the request error.
+	TeeWriter io.Writer
+
+	// ValidateResponse is called with the successful response before it is decoded into Result and returned,
+	// e.g. to detect HTML error pages served with 200 by broken proxies. The error is wrapped into ErrInvalidResponse
+	// and is treated like a failed request: it is retried and counted by the circuit breaker.
+	// It is called with unread body for StreamResponse and OutputPath, so only status and headers can be checked.
+	ValidateResponse func(resp *resty.Response) error
+
+	// ReturnOn3xx stops following redirects and returns the 3xx response as a successful one,
+	// use RedirectLocation to get its Location. It is useful for presigned URL issuers and OAuth flows.
+	ReturnOn3xx bool
+
+	// MaxRequestSize overrides Config.MaxRequestSize for this request. Default is 0, means the client limit.
+	MaxRequestSize int64
+
+	// NilOn404 returns 404 response without error and without retries, Result is not filled.
+	// It is useful to fetch optional resources, see GetOrNil.
+	NilOn404 bool
+
+	// OnInformational is called for every informational 1xx response (e.g. 103 Early Hints) received before
+	// the final response, it can be used to preconnect to hinted resources. The request continues to the final response.
+	OnInformational func(code int, header http.Header)
+
+	// Trailers is the trailer headers that are sent after the request body, e.g. a checksum of a streamed upload.
+	// The body is sent with chunked encoding. Values may be set until the body returns io.EOF,
+	// so a streaming reader can fill them with the values known at the end of the body.
+	Trailers http.Header
+
+	// CacheTTL is the duration for which a successful GET response is memoized by URL, path params and query.
+	// Requests within this duration return the memoized response without sending a request.
+	// It is useful for config endpoints polled by many goroutines. Default is 0, means no memoization.
+	CacheTTL time.Duration
+
+	// Dedupe coalesces identical concurrent GET requests (same URL, query, headers and credentials) into one upstream
+	// request and shares its response, even if Config.Singleflight is disabled. Default is false.
+	Dedupe bool
+
+	// NoCache sends the request without the HTTP cache (see Config.HTTPCache): the stored response is not used
+	// and the response is not stored.
+	NoCache bool
+
+	// Priority is the priority of the request in the queue when Config.MaxConcurrentRequests is reached.
+	// Default is PriorityNormal.
+	Priority Priority
+
+	// Idempotent marks the request with non-idempotent method (e.g. POST with an idempotency key)
+	// as safe to be sent again with RetrySafeOnly and to another client of HTTPSet in failover mode.
+	Idempotent bool
+
+	// Meta is the request-scoped values of any type and cardinality (e.g. job ID or the user object)
+	// that are passed to metrics hooks in RequestInfo, to audit records and to the context of the request,
+	// see MetaFromContext. Unlike RequestLabels, it is not put into logs and errors. It is not sent to the server.
+	Meta map[string]any
+
+	// ClientCertName is the name of the client certificate from Config.ClientCerts that is used for mTLS
+	// with this request. Default is empty, means the certificate from Config.ClientCertFile (if set) is used.
+	ClientCertName string
+
+	// RequestName is the name of the request that identifies it in logs, errors, metrics and audit records.
 	RequestName string
 
+	// RequestLabels is the low-cardinality string labels of the request (e.g. tenant or operation kind) that are
+	// attached with RequestName to logs, errors, metrics and audit records, so they are safe to be used as metric labels.
+	// Use Meta for high-cardinality or non-string values. They are not sent to the server.
+	RequestLabels map[string]string
+
 	// RetryCount is the number of times to retry the request.
 	RetryCount int
 
@@ -78,12 +243,32 @@ type RequestOpts struct {
 	// Default is 2 seconds.
 	RetryMaxWaitTime time.Duration
 
+	// RetryPolicy returns the wait time before the next retry and whether to retry, it overrides Config.RetryPolicy.
+	// RetryWaitTime, RetryMaxWaitTime and IgnoreRetryAfter are not used with it, see RetryAfterRetry.
+	// Default is nil, means exponential backoff with jitter and Retry-After.
+	RetryPolicy RetryPolicy
+
 	// InfiniteRetry is whether to retry the request infinitely
 	InfiniteRetry bool
 
 	// RetryOnlyServerErrors is whether to retry only 5xx errors.
 	RetryOnlyServerErrors bool
 
+	// IgnoreRetryAfter is whether to ignore Retry-After header of 429 and 503 responses and Config.BackoffHeader.
+	// By default the wait time before the next retry is taken from Retry-After (delta seconds or HTTP date)
+	// capped by RetryMaxWaitTime.
+	IgnoreRetryAfter bool
+
+	// RetrySafeOnly is whether to retry only failures that are safe to replay, see CanReplay: requests with
+	// idempotent method or Idempotent flag are retried after any error, other requests (e.g. POST) are retried
+	// only if they failed before being completely sent (ErrNotSent), so the server couldn't process them.
+	RetrySafeOnly bool
+
+	// RetryWithinDeadline is whether to stop retrying as soon as the next attempt is not expected to finish
+	// before the context deadline. The request fails with RetryError caused by ErrRetryDeadline
+	// instead of sleeping into the guaranteed cancellation.
+	RetryWithinDeadline bool
+
 	// NoLogRetryError is whether to log the retry error
 	NoLogRetryError bool
 
@@ -96,6 +281,11 @@ var (
 	ErrCBOpenState = gobreaker.ErrOpenState
 	// ErrTooManyRequests is returned when the CB state is half open and the requests count is over the cb maxRequests
 	ErrCBTooManyRequests = gobreaker.ErrTooManyRequests
+	// ErrUnsuccessfulResponse is returned when Config.IsSuccess rejects the response with status code below 400,
+	// e.g. 200 with an embedded error code
+	ErrUnsuccessfulResponse = errors.New("unsuccessful response")
+	// ErrInvalidResponse is returned when RequestOpts.ValidateResponse rejects the successful response
+	ErrInvalidResponse = errors.New("invalid response")
 )
 
 var (
@@ -0,0 +1,180 @@
+package cliex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/maxbolgarin/lang"
+)
+
+// defaultUploadConcurrency is the number of parallel uploads if the concurrency is not set.
+const defaultUploadConcurrency = 4
+
+// UploadOpts is the options for uploading files.
+type UploadOpts struct {
+	// RequestOpts is the options of every upload request, Body, BodyReader and Files are ignored.
+	// Files are read from FilesFS if it is set.
+	// Method is POST by default. PUT sends the file as the raw request body, other methods send it as multipart form.
+	// Path param "name" is set to the name of the file, e.g. "/files/{name}".
+	// RetryCount is applied to every file separately.
+	RequestOpts
+
+	// FieldName is the name of the multipart form field with the file. Default is "file".
+	FieldName string
+
+	// OnProgress is called after every finished upload with the number of finished and total files.
+	OnProgress func(done, total int, result UploadResult)
+}
+
+// UploadResult is the result of uploading a single file.
+type UploadResult struct {
+	// Name is the key of the file in the files map.
+	Name string
+	// Path is the path of the file.
+	Path string
+	// Size is the size of the file in bytes.
+	Size int64
+	// Duration is the time spent on uploading including retries.
+	Duration time.Duration
+	// Response is the response of the last attempt, it may be nil.
+	Response *resty.Response
+	// Err is the error of uploading, nil if the file is uploaded.
+	Err error
+}
+
+// UploadReport is the aggregated result of HTTP.UploadFiles.
+type UploadReport struct {
+	// Results contains results of every file sorted by name.
+	Results []UploadResult
+	// Succeeded is the number of uploaded files.
+	Succeeded int
+	// Failed is the number of files that failed to upload.
+	Failed int
+	// Bytes is the total size of uploaded files.
+	Bytes int64
+	// Duration is the total time of uploading.
+	Duration time.Duration
+}
+
+// UploadFiles uploads files in parallel with at most concurrency requests at once (default is 4).
+// Files is the map of names to file paths. It returns the report with results of every file
+// and an error that joins errors of failed uploads.
+func (c *HTTP) UploadFiles(ctx context.Context, url string, files map[string]string, concurrency int, opts ...UploadOpts) (UploadReport, error) {
+	var o UploadOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if concurrency <= 0 {
+		concurrency = defaultUploadConcurrency
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		start   = time.Now()
+		results = make([]UploadResult, len(names))
+		sem     = make(chan struct{}, concurrency)
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		done    int
+	)
+	for i, name := range names {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i] = UploadResult{Name: name, Path: files[name], Err: ctx.Err()}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result := c.uploadFile(ctx, url, name, files[name], o)
+			results[i] = result
+
+			if o.OnProgress != nil {
+				mu.Lock()
+				done++
+				o.OnProgress(done, len(names), result)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := UploadReport{Results: results, Duration: time.Since(start)}
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			report.Failed++
+			errs = append(errs, fmt.Errorf("upload %s: %w", r.Name, r.Err))
+			continue
+		}
+		report.Succeeded++
+		report.Bytes += r.Size
+	}
+	if len(errs) > 0 {
+		return report, fmt.Errorf("failed to upload %d of %d files: %w", report.Failed, len(names), errors.Join(errs...))
+	}
+	return report, nil
+}
+
+func (c *HTTP) uploadFile(ctx context.Context, url, name, path string, o UploadOpts) UploadResult {
+	result := UploadResult{Name: name, Path: path}
+	start := time.Now()
+
+	// Files are read from FilesFS if it is set, e.g. embedded assets
+	var (
+		info fs.FileInfo
+		err  error
+		open = func() (io.Reader, error) { return os.Open(path) }
+	)
+	if fsys := o.FilesFS; fsys != nil {
+		info, err = fs.Stat(fsys, path)
+		open = func() (io.Reader, error) { return fsys.Open(path) }
+	} else {
+		info, err = os.Stat(path)
+	}
+	if err != nil {
+		result.Err = fmt.Errorf("stat file: %w", err)
+		return result
+	}
+	result.Size = info.Size()
+
+	opts := o.RequestOpts
+	opts.Method = lang.Check(opts.Method, http.MethodPost)
+	opts.Body, opts.BodyReader, opts.GetBody, opts.Files = nil, nil, nil, nil
+
+	opts.PathParams = make(map[string]string, len(o.PathParams)+1)
+	for k, v := range o.PathParams {
+		opts.PathParams[k] = v
+	}
+	opts.PathParams["name"] = name
+
+	if opts.Method == http.MethodPut {
+		// Transport closes the body after sending, so the file is reopened for every attempt
+		opts.GetBody = open
+	} else {
+		opts.Files = map[string]string{lang.Check(o.FieldName, "file"): path}
+	}
+
+	result.Response, result.Err = c.Request(ctx, url, opts)
+	result.Duration = time.Since(start)
+
+	return result
+}
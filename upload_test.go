@@ -0,0 +1,108 @@
+package cliex_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxbolgarin/cliex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_UploadFiles(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		uploaded = map[string]string{}
+		active   atomic.Int64
+		peak     atomic.Int64
+	)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		if n > peak.Load() {
+			peak.Store(n)
+		}
+
+		file, header, err := r.FormFile("file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		data, _ := io.ReadAll(file)
+
+		mu.Lock()
+		uploaded[r.URL.Path+"/"+header.Filename] = string(data)
+		mu.Unlock()
+	}))
+	defer mockServer.Close()
+
+	dir := t.TempDir()
+	files := map[string]string{}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		path := filepath.Join(dir, name+".txt")
+		require.NoError(t, os.WriteFile(path, []byte("content "+name), 0o600))
+		files[name] = path
+	}
+	files["missing"] = filepath.Join(dir, "missing.txt")
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL})
+	require.NoError(t, err)
+
+	var progress atomic.Int64
+	report, err := client.UploadFiles(context.Background(), "/upload/{name}", files, 2, cliex.UploadOpts{
+		OnProgress: func(done, total int, result cliex.UploadResult) {
+			assert.Equal(t, 5, total)
+			progress.Add(1)
+		},
+	})
+	assert.ErrorContains(t, err, "failed to upload 1 of 5 files")
+	assert.ErrorContains(t, err, "upload missing: stat file")
+
+	assert.Equal(t, 4, report.Succeeded)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, int64(4*len("content a")), report.Bytes)
+	require.Len(t, report.Results, 5)
+	assert.Equal(t, "a", report.Results[0].Name)
+	assert.Equal(t, int64(5), progress.Load())
+	assert.LessOrEqual(t, peak.Load(), int64(2))
+
+	assert.Equal(t, map[string]string{
+		"/upload/a/a.txt": "content a",
+		"/upload/b/b.txt": "content b",
+		"/upload/c/c.txt": "content c",
+		"/upload/d/d.txt": "content d",
+	}, uploaded)
+}
+
+func TestHTTP_UploadFilesPut(t *testing.T) {
+	var attempts atomic.Int64
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		data, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "raw content", string(data))
+		if attempts.Add(1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	defer mockServer.Close()
+
+	path := filepath.Join(t.TempDir(), "raw.bin")
+	require.NoError(t, os.WriteFile(path, []byte("raw content"), 0o600))
+
+	client, err := cliex.NewWithConfig(cliex.Config{BaseURL: mockServer.URL})
+	require.NoError(t, err)
+
+	report, err := client.UploadFiles(context.Background(), "/files/{name}", map[string]string{"raw": path}, 0, cliex.UploadOpts{
+		RequestOpts: cliex.RequestOpts{Method: http.MethodPut, RetryCount: 2, RetryWaitTime: 10 * time.Millisecond, RetryMaxWaitTime: 20 * time.Millisecond, NoLogRetryError: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, int64(2), attempts.Load())
+}
@@ -0,0 +1,38 @@
+package cliex
+
+import (
+	"sync/atomic"
+)
+
+// UserAgentProvider returns the User-Agent header for every request.
+type UserAgentProvider interface {
+	UserAgent() string
+}
+
+// UserAgentFunc is a function that implements UserAgentProvider.
+type UserAgentFunc func() string
+
+// UserAgent calls the function.
+func (f UserAgentFunc) UserAgent() string {
+	return f()
+}
+
+// RoundRobinUserAgents is the UserAgentProvider that rotates the list of User-Agent headers.
+type RoundRobinUserAgents struct {
+	agents []string
+	next   atomic.Uint64
+}
+
+// NewRoundRobinUserAgents returns a new UserAgentProvider that returns agents one by one in cycle.
+func NewRoundRobinUserAgents(agents ...string) *RoundRobinUserAgents {
+	return &RoundRobinUserAgents{agents: agents}
+}
+
+// UserAgent returns the next User-Agent, empty string if the list is empty.
+func (r *RoundRobinUserAgents) UserAgent() string {
+	if len(r.agents) == 0 {
+		return ""
+	}
+	n := r.next.Add(1) - 1
+	return r.agents[n%uint64(len(r.agents))]
+}